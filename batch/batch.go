@@ -0,0 +1,292 @@
+// Package batch answers many weather queries at once: it reads them from
+// plain lines, CSV or JSONL, runs them through a bounded worker pool and
+// writes one JSONL result per query.
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"learn-go/ollama"
+
+	"golang.org/x/time/rate"
+)
+
+// Item is one query to answer.
+type Item struct {
+	ID    string `json:"id"`
+	Query string `json:"query"`
+}
+
+// Input formats accepted by Read.
+const (
+	FormatAuto  = "auto"
+	FormatLines = "lines"
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Read parses items in the given format. Plain lines are numbered from 1;
+// CSV needs a header with a "query" column and optionally an "id" column;
+// JSONL lines are objects with "query" and optionally "id". FormatAuto
+// picks JSONL when the input starts with "{", CSV when the first line is a
+// header naming a query column, and plain lines otherwise. Blank lines are
+// skipped.
+func Read(r io.Reader, format string) ([]Item, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading queries: %w", err)
+	}
+	if format == "" || format == FormatAuto {
+		format = detect(data)
+	}
+
+	var items []Item
+	switch format {
+	case FormatLines:
+		items = readLines(data)
+	case FormatCSV:
+		items, err = readCSV(data)
+	case FormatJSONL:
+		items, err = readJSONL(data)
+	default:
+		return nil, fmt.Errorf("unknown input format %q (want lines, csv or jsonl)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(items))
+	for i := range items {
+		if items[i].ID == "" {
+			items[i].ID = strconv.Itoa(i + 1)
+		}
+		if seen[items[i].ID] {
+			return nil, fmt.Errorf("duplicate id %q", items[i].ID)
+		}
+		seen[items[i].ID] = true
+	}
+	return items, nil
+}
+
+func detect(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return FormatJSONL
+	}
+	first, _, _ := strings.Cut(string(trimmed), "\n")
+	for _, col := range strings.Split(first, ",") {
+		if strings.EqualFold(strings.TrimSpace(col), "query") {
+			return FormatCSV
+		}
+	}
+	return FormatLines
+}
+
+func readLines(data []byte) []Item {
+	var items []Item
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if q := strings.TrimSpace(scanner.Text()); q != "" {
+			items = append(items, Item{Query: q})
+		}
+	}
+	return items
+}
+
+func readCSV(data []byte) ([]Item, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	idCol, queryCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "id":
+			idCol = i
+		case "query":
+			queryCol = i
+		}
+	}
+	if queryCol < 0 {
+		return nil, errors.New(`CSV header has no "query" column`)
+	}
+
+	var items []Item
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		if queryCol >= len(record) || strings.TrimSpace(record[queryCol]) == "" {
+			continue
+		}
+		item := Item{Query: strings.TrimSpace(record[queryCol])}
+		if idCol >= 0 && idCol < len(record) {
+			item.ID = strings.TrimSpace(record[idCol])
+		}
+		items = append(items, item)
+	}
+}
+
+func readJSONL(data []byte) ([]Item, error) {
+	var items []Item
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		// IDs may be written as numbers or strings.
+		var raw struct {
+			ID    json.RawMessage `json:"id"`
+			Query string          `json:"query"`
+		}
+		if err := json.Unmarshal(line, &raw); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if strings.TrimSpace(raw.Query) == "" {
+			return nil, fmt.Errorf("line %d: missing query", n)
+		}
+		item := Item{Query: strings.TrimSpace(raw.Query)}
+		if len(raw.ID) > 0 && string(raw.ID) != "null" {
+			var s string
+			if json.Unmarshal(raw.ID, &s) != nil {
+				s = string(raw.ID)
+			}
+			item.ID = s
+		}
+		items = append(items, item)
+	}
+	return items, scanner.Err()
+}
+
+// Result is the outcome of one item, written as a JSONL line.
+type Result struct {
+	ID         string                  `json:"id"`
+	Query      string                  `json:"query"`
+	Response   *ollama.WeatherResponse `json:"response,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+	DurationMS int64                   `json:"duration_ms"`
+}
+
+// AnswerFunc answers a single query.
+type AnswerFunc func(query string) (*ollama.WeatherResponse, error)
+
+// Options configures Run.
+type Options struct {
+	// Workers is the number of queries answered at once; at least one.
+	Workers int
+	// Rate caps how many queries are started per second, to stay within
+	// the upstream services' usage limits. Zero means no cap.
+	Rate float64
+}
+
+// Summary counts the outcomes of a run.
+type Summary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Elapsed   time.Duration
+}
+
+// Run answers items with opts.Workers concurrent calls to answer and
+// writes a Result per item to out, in input order. A failed item is
+// reported in its result and doesn't stop the run; items left when ctx is
+// cancelled are reported with ctx's error.
+func Run(ctx context.Context, items []Item, answer AnswerFunc, opts Options, out io.Writer) (Summary, error) {
+	start := time.Now()
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	var limiter *rate.Limiter
+	if opts.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.Rate), 1)
+	}
+
+	jobs := make(chan int)
+	results := make([]chan Result, len(items))
+	for i := range results {
+		results[i] = make(chan Result, 1)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] <- answerItem(ctx, items[i], answer, limiter)
+			}
+		}()
+	}
+	go func() {
+		for i := range items {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}()
+
+	summary := Summary{Total: len(items)}
+	enc := json.NewEncoder(out)
+	var writeErr error
+	for _, ch := range results {
+		r := <-ch
+		if r.Error == "" {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+		if writeErr == nil {
+			writeErr = enc.Encode(r)
+		}
+	}
+	summary.Elapsed = time.Since(start)
+	if writeErr != nil {
+		return summary, fmt.Errorf("writing results: %w", writeErr)
+	}
+	return summary, nil
+}
+
+func answerItem(ctx context.Context, item Item, answer AnswerFunc, limiter *rate.Limiter) Result {
+	result := Result{ID: item.ID, Query: item.Query}
+	if err := ctx.Err(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	start := time.Now()
+	resp, err := answer(item.Query)
+	result.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Response = resp
+	return result
+}
@@ -0,0 +1,200 @@
+package batch_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"learn-go/batch"
+	"learn-go/ollama"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   []batch.Item
+	}{
+		{
+			"lines", batch.FormatAuto,
+			"weather in Miami\n\n  Is it raining in Seattle?  \n",
+			[]batch.Item{{ID: "1", Query: "weather in Miami"}, {ID: "2", Query: "Is it raining in Seattle?"}},
+		},
+		{
+			"csv", batch.FormatAuto,
+			"id,query\nhq,\"weather in Austin, TX\"\nnyc,weather in New York\n",
+			[]batch.Item{{ID: "hq", Query: "weather in Austin, TX"}, {ID: "nyc", Query: "weather in New York"}},
+		},
+		{
+			"csv without ids", batch.FormatCSV,
+			"office,query\nHQ,weather in Denver\n",
+			[]batch.Item{{ID: "1", Query: "weather in Denver"}},
+		},
+		{
+			"jsonl", batch.FormatAuto,
+			`{"id": 7, "query": "weather in Boston"}` + "\n" + `{"id": "b", "query": "alerts for Tulsa"}` + "\n",
+			[]batch.Item{{ID: "7", Query: "weather in Boston"}, {ID: "b", Query: "alerts for Tulsa"}},
+		},
+		{
+			"forced lines", batch.FormatLines,
+			"id,query\n",
+			[]batch.Item{{ID: "1", Query: "id,query"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := batch.Read(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Read = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("item %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name, format, input, wantErr string
+	}{
+		{"csv without query column", batch.FormatCSV, "id,location\n1,Miami\n", `no "query" column`},
+		{"bad jsonl", batch.FormatJSONL, "{\"query\": \"ok\"}\n{oops\n", "line 2"},
+		{"jsonl without query", batch.FormatJSONL, `{"id": 1}`, "missing query"},
+		{"duplicate ids", batch.FormatCSV, "id,query\na,x\na,y\n", `duplicate id "a"`},
+		{"unknown format", "xml", "<q/>", "unknown input format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := batch.Read(strings.NewReader(tt.input), tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func decodeResults(t *testing.T, out *bytes.Buffer) []batch.Result {
+	t.Helper()
+	var results []batch.Result
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var r batch.Result
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("decoding result: %v", err)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestRun(t *testing.T) {
+	items := []batch.Item{
+		{ID: "a", Query: "slow"},
+		{ID: "b", Query: "fail"},
+		{ID: "c", Query: "fast"},
+	}
+
+	var running, peak atomic.Int32
+	answer := func(query string) (*ollama.WeatherResponse, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		switch query {
+		case "slow":
+			time.Sleep(50 * time.Millisecond)
+		case "fail":
+			return nil, errors.New("geocoding location: location not found")
+		}
+		return &ollama.WeatherResponse{Location: query, Description: "Sunny."}, nil
+	}
+
+	var out bytes.Buffer
+	summary, err := batch.Run(context.Background(), items, answer, batch.Options{Workers: 2}, &out)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if summary.Total != 3 || summary.Succeeded != 2 || summary.Failed != 1 {
+		t.Errorf("summary = %+v, want 2 of 3 succeeded", summary)
+	}
+	if p := peak.Load(); p != 2 {
+		t.Errorf("peak concurrency = %d, want 2 workers", p)
+	}
+
+	results := decodeResults(t, &out)
+	if len(results) != 3 {
+		t.Fatalf("wrote %d results, want 3", len(results))
+	}
+	for i, r := range results {
+		if r.ID != items[i].ID {
+			t.Errorf("result %d has id %q, want input order", i, r.ID)
+		}
+	}
+	if results[1].Error != "geocoding location: location not found" || results[1].Response != nil {
+		t.Errorf("failed item = %+v, want its error and no response", results[1])
+	}
+	if results[0].Response == nil || results[0].Response.Description != "Sunny." || results[0].DurationMS < 50 {
+		t.Errorf("slow item = %+v, want a response and its duration", results[0])
+	}
+}
+
+func TestRunRateLimit(t *testing.T) {
+	items := []batch.Item{{ID: "1", Query: "q"}, {ID: "2", Query: "q"}, {ID: "3", Query: "q"}}
+	answer := func(string) (*ollama.WeatherResponse, error) { return &ollama.WeatherResponse{}, nil }
+
+	var out bytes.Buffer
+	summary, err := batch.Run(context.Background(), items, answer, batch.Options{Workers: 3, Rate: 20}, &out)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// Three starts at 20 per second need at least two 50ms gaps.
+	if summary.Elapsed < 90*time.Millisecond {
+		t.Errorf("run took %v, want the rate limit to space out starts", summary.Elapsed)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	items := []batch.Item{{ID: "1", Query: "first"}, {ID: "2", Query: "second"}}
+
+	var mu sync.Mutex
+	var answered []string
+	answer := func(query string) (*ollama.WeatherResponse, error) {
+		mu.Lock()
+		answered = append(answered, query)
+		mu.Unlock()
+		cancel()
+		return &ollama.WeatherResponse{}, nil
+	}
+
+	var out bytes.Buffer
+	summary, err := batch.Run(ctx, items, answer, batch.Options{Workers: 1}, &out)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if summary.Succeeded != 1 || summary.Failed != 1 || len(answered) != 1 {
+		t.Errorf("summary = %+v after answering %v, want the second item skipped", summary, answered)
+	}
+	if r := decodeResults(t, &out); len(r) != 2 || r[1].Error != context.Canceled.Error() {
+		t.Errorf("results = %+v, want the skipped item reported as cancelled", r)
+	}
+}
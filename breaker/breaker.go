@@ -0,0 +1,243 @@
+// Package breaker implements circuit breakers for upstream services. A
+// breaker opens after a run of consecutive failures, fails calls fast while
+// open, and after a cooldown lets trial calls through to decide whether to
+// close again.
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is the position of a breaker.
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// MarshalText makes states read as their names in JSON.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a state name written by MarshalText.
+func (s *State) UnmarshalText(text []byte) error {
+	for _, state := range []State{Closed, Open, HalfOpen} {
+		if string(text) == state.String() {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown breaker state %q", text)
+}
+
+// Settings configures a Breaker. Zero fields take their value from
+// DefaultSettings.
+type Settings struct {
+	// Failures is the number of consecutive failures that opens the breaker.
+	Failures int
+	// Cooldown is how long the breaker stays open before a trial call.
+	Cooldown time.Duration
+	// HalfOpenCalls is how many trial calls may run while half-open; that
+	// many successes close the breaker again.
+	HalfOpenCalls int
+	// IsFailure decides which errors count against the upstream, so that a
+	// 404 or a cancelled request doesn't open the breaker. Nil counts every
+	// error.
+	IsFailure func(error) bool
+	// Now is the clock, for tests.
+	Now func() time.Time
+}
+
+// DefaultSettings opens after five failures in a row and retries after
+// thirty seconds.
+var DefaultSettings = Settings{
+	Failures:      5,
+	Cooldown:      30 * time.Second,
+	HalfOpenCalls: 1,
+}
+
+// ErrOpen matches, via errors.Is, every error returned for a call rejected
+// by an open breaker.
+var ErrOpen = errors.New("circuit breaker open")
+
+// OpenError is returned instead of calling the upstream while the breaker is
+// open.
+type OpenError struct {
+	Name string
+	// RetryIn is the time left until the breaker lets a trial call through.
+	RetryIn time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s: circuit breaker open, retry in %v", e.Name, e.RetryIn.Round(time.Second))
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// Stats is a snapshot of a breaker for health checks and metrics.
+type Stats struct {
+	Name                string    `json:"name"`
+	State               State     `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Successes           uint64    `json:"successes"`
+	Failures            uint64    `json:"failures"`
+	Rejected            uint64    `json:"rejected"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker guards calls to one upstream. It is safe for concurrent use.
+type Breaker struct {
+	name     string
+	settings Settings
+
+	mu          sync.Mutex
+	state       State
+	consecutive int
+	openedAt    time.Time
+	trials      int
+	trialOK     int
+	successes   uint64
+	failures    uint64
+	rejected    uint64
+}
+
+// New returns a closed breaker named after the upstream it guards.
+func New(name string, settings Settings) *Breaker {
+	if settings.Failures <= 0 {
+		settings.Failures = DefaultSettings.Failures
+	}
+	if settings.Cooldown <= 0 {
+		settings.Cooldown = DefaultSettings.Cooldown
+	}
+	if settings.HalfOpenCalls <= 0 {
+		settings.HalfOpenCalls = DefaultSettings.HalfOpenCalls
+	}
+	if settings.Now == nil {
+		settings.Now = time.Now
+	}
+	return &Breaker{name: name, settings: settings}
+}
+
+// Name is the upstream the breaker guards.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Do calls fn unless the breaker is open, in which case it returns an
+// *OpenError without calling fn. The outcome of fn updates the breaker.
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.record(err)
+	return err
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		wait := b.settings.Cooldown - b.settings.Now().Sub(b.openedAt)
+		if wait > 0 {
+			b.rejected++
+			return &OpenError{Name: b.name, RetryIn: wait}
+		}
+		b.state = HalfOpen
+		b.trials, b.trialOK = 0, 0
+	}
+
+	if b.state == HalfOpen {
+		if b.trials >= b.settings.HalfOpenCalls {
+			b.rejected++
+			return &OpenError{Name: b.name}
+		}
+		b.trials++
+	}
+	return nil
+}
+
+func (b *Breaker) record(err error) {
+	failed := err != nil && (b.settings.IsFailure == nil || b.settings.IsFailure(err))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if failed {
+		b.failures++
+		b.consecutive++
+	} else {
+		b.successes++
+		b.consecutive = 0
+	}
+
+	switch b.state {
+	case HalfOpen:
+		if failed {
+			b.trip()
+			return
+		}
+		if b.trialOK++; b.trialOK >= b.settings.HalfOpenCalls {
+			b.state = Closed
+		}
+	case Closed:
+		if b.consecutive >= b.settings.Failures {
+			b.trip()
+		}
+	}
+}
+
+func (b *Breaker) trip() {
+	b.state = Open
+	b.openedAt = b.settings.Now()
+}
+
+// State reports the breaker's position. An open breaker whose cooldown has
+// passed reads as half-open.
+func (b *Breaker) State() State {
+	return b.Stats().State
+}
+
+// Stats returns a snapshot of the breaker.
+func (b *Breaker) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == Open && b.settings.Now().Sub(b.openedAt) >= b.settings.Cooldown {
+		state = HalfOpen
+	}
+	stats := Stats{
+		Name:                b.name,
+		State:               state,
+		ConsecutiveFailures: b.consecutive,
+		Successes:           b.successes,
+		Failures:            b.failures,
+		Rejected:            b.rejected,
+	}
+	if state != Closed {
+		stats.OpenedAt = b.openedAt
+	}
+	return stats
+}
@@ -0,0 +1,135 @@
+package breaker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"learn-go/breaker"
+)
+
+var errDown = errors.New("upstream down")
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newBreaker(c *clock) *breaker.Breaker {
+	return breaker.New("nws", breaker.Settings{
+		Failures:      3,
+		Cooldown:      10 * time.Second,
+		HalfOpenCalls: 1,
+		Now:           c.now,
+	})
+}
+
+func fail() error    { return errDown }
+func succeed() error { return nil }
+
+func TestBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+	b := newBreaker(c)
+
+	b.Do(fail)
+	b.Do(fail)
+	b.Do(succeed)
+	b.Do(fail)
+	b.Do(fail)
+	if got := b.State(); got != breaker.Closed {
+		t.Fatalf("state = %v after interrupted failures, want closed", got)
+	}
+
+	b.Do(fail)
+	if got := b.State(); got != breaker.Open {
+		t.Fatalf("state = %v after 3 failures in a row, want open", got)
+	}
+
+	called := false
+	err := b.Do(func() error { called = true; return nil })
+	if called {
+		t.Error("open breaker called the upstream")
+	}
+	if !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("error = %v, want ErrOpen", err)
+	}
+	var open *breaker.OpenError
+	if !errors.As(err, &open) || open.Name != "nws" || open.RetryIn != 10*time.Second {
+		t.Errorf("error = %#v, want OpenError for nws retrying in 10s", err)
+	}
+
+	stats := b.Stats()
+	if stats.Failures != 5 || stats.Successes != 1 || stats.Rejected != 1 {
+		t.Errorf("stats = %+v, want 5 failures, 1 success, 1 rejected", stats)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		trial func() error
+		want  breaker.State
+	}{
+		{"trial succeeds", succeed, breaker.Closed},
+		{"trial fails", fail, breaker.Open},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &clock{t: time.Unix(0, 0)}
+			b := newBreaker(c)
+			for i := 0; i < 3; i++ {
+				b.Do(fail)
+			}
+
+			c.t = c.t.Add(10 * time.Second)
+			if got := b.State(); got != breaker.HalfOpen {
+				t.Fatalf("state = %v after cooldown, want half-open", got)
+			}
+
+			b.Do(tt.trial)
+			if got := b.State(); got != tt.want {
+				t.Errorf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerHalfOpenLimitsTrials(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+	b := newBreaker(c)
+	for i := 0; i < 3; i++ {
+		b.Do(fail)
+	}
+	c.t = c.t.Add(10 * time.Second)
+
+	err := b.Do(func() error {
+		// A second caller arrives while the trial is still running.
+		if err := b.Do(succeed); !errors.Is(err, breaker.ErrOpen) {
+			t.Errorf("concurrent trial error = %v, want ErrOpen", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("trial: %v", err)
+	}
+	if got := b.State(); got != breaker.Closed {
+		t.Errorf("state = %v, want closed", got)
+	}
+}
+
+func TestBreakerIsFailure(t *testing.T) {
+	notFound := errors.New("not found")
+	b := breaker.New("geocode", breaker.Settings{
+		Failures:  1,
+		IsFailure: func(err error) bool { return err != notFound },
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := b.Do(func() error { return notFound }); err != notFound {
+			t.Fatalf("error = %v, want the upstream's error", err)
+		}
+	}
+	if got := b.State(); got != breaker.Closed {
+		t.Errorf("state = %v after ignored errors, want closed", got)
+	}
+}
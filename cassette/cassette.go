@@ -0,0 +1,256 @@
+// Package cassette records upstream HTTP exchanges to disk and replays them
+// deterministically, so a session against the real NWS, Nominatim and Ollama
+// APIs can be reproduced later without network access.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode selects what a Recorder does with each request.
+type Mode string
+
+const (
+	ModeOff    Mode = ""
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// ParseMode converts a flag or environment value into a Mode.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case ModeOff, "off":
+		return ModeOff, nil
+	case ModeRecord, ModeReplay:
+		return m, nil
+	default:
+		return ModeOff, fmt.Errorf("unknown cassette mode %q (want record or replay)", s)
+	}
+}
+
+// ErrNoInteraction is returned in replay mode when a request has no
+// matching recording.
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Cassette is the on-disk format.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records to or replays from a
+// cassette file.
+type Recorder struct {
+	mode Mode
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+}
+
+// New returns a Recorder for the cassette at path. In replay mode the file
+// must exist; in record mode it is created or overwritten. next is used to
+// reach the real upstream while recording and defaults to
+// http.DefaultTransport.
+func New(path string, mode Mode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &Recorder{mode: mode, path: path, next: next}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("decoding cassette %s: %w", path, err)
+		}
+		r.replayed = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Mode reports the mode the recorder was created with.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cassette: reading request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := RecordedRequest{
+		Method: req.Method,
+		URL:    sanitizeURL(req.URL),
+		Body:   string(body),
+	}
+
+	switch r.mode {
+	case ModeReplay:
+		return r.replay(req, key)
+	case ModeRecord:
+		return r.record(req, key)
+	default:
+		return r.next.RoundTrip(req)
+	}
+}
+
+func (r *Recorder) replay(req *http.Request, key RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Prefer the first unused match so repeated identical requests replay in
+	// recorded order; once exhausted, the last match repeats.
+	last := -1
+	for i, in := range r.cassette.Interactions {
+		if in.Request != key {
+			continue
+		}
+		if !r.replayed[i] {
+			r.replayed[i] = true
+			return in.Response.toHTTP(req), nil
+		}
+		last = i
+	}
+	if last >= 0 {
+		return r.cassette.Interactions[last].Response.toHTTP(req), nil
+	}
+
+	return nil, fmt.Errorf("cassette %s: %w for %s %s", r.path, ErrNoInteraction, key.Method, key.URL)
+}
+
+func (r *Recorder) record(req *http.Request, key RecordedRequest) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: key,
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: sanitizeHeader(resp.Header),
+			Body:   string(body),
+		},
+	})
+
+	// Write after every exchange so an interrupted session still leaves a
+	// usable cassette behind.
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("creating cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+func (rr RecordedResponse) toHTTP(req *http.Request) *http.Response {
+	header := rr.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rr.Status, http.StatusText(rr.Status)),
+		StatusCode:    rr.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(rr.Body)),
+		ContentLength: int64(len(rr.Body)),
+		Request:       req,
+	}
+}
+
+// sensitiveParams are query parameters whose values never reach disk.
+var sensitiveParams = []string{"key", "api_key", "apikey", "token", "access_token", "email"}
+
+func sanitizeURL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+	q := clean.Query()
+	for _, p := range sensitiveParams {
+		if q.Has(p) {
+			q.Set(p, "REDACTED")
+		}
+	}
+	clean.RawQuery = q.Encode()
+	return clean.String()
+}
+
+// droppedHeaders are either secrets or vary between runs without affecting
+// behaviour.
+var droppedHeaders = []string{
+	"Set-Cookie", "Authorization", "Proxy-Authorization", "X-Api-Key",
+	"Date", "Content-Length", "X-Request-Id", "X-Correlation-Id",
+}
+
+func sanitizeHeader(h http.Header) http.Header {
+	clean := h.Clone()
+	for _, k := range droppedHeaders {
+		clean.Del(k)
+	}
+	return clean
+}
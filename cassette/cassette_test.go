@@ -0,0 +1,157 @@
+package cassette_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"learn-go/cassette"
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+	"learn-go/weather"
+	"learn-go/weather/weathertest"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "miami.json")
+
+	nws := weathertest.NewServer()
+	ai := ollamatest.NewServer()
+	ai.Chat(ollamatest.Reply("Warm and partly cloudy."))
+
+	run := func(rec *cassette.Recorder) *ollama.WeatherResponse {
+		t.Helper()
+		svc := nws.NewService(weather.WithTransport(rec))
+		client := ollama.NewClient(svc,
+			ollama.WithBaseURL(ai.BaseURL()),
+			ollama.WithRetryDelay(0),
+			ollama.WithTransport(rec),
+		)
+		resp, err := client.GetWeather("What's the weather in Miami?", 0)
+		if err != nil {
+			t.Fatalf("GetWeather: %v", err)
+		}
+		return resp
+	}
+
+	rec, err := cassette.New(path, cassette.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := run(rec)
+
+	// Replay must not touch the network at all.
+	nws.Close()
+	ai.Close()
+
+	player, err := cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := run(player)
+
+	if replayed.Description != recorded.Description {
+		t.Errorf("Description = %q, want %q", replayed.Description, recorded.Description)
+	}
+	if *replayed.Weather != *recorded.Weather {
+		t.Errorf("Weather = %+v, want %+v", replayed.Weather, recorded.Weather)
+	}
+}
+
+func TestReplayMissingInteraction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(path, []byte(`{"interactions":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&http.Client{Transport: rec}).Get("http://example.invalid/points/1,2")
+	if !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Fatalf("error = %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestReplayRepeatsInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seq.json")
+	data := `{"interactions":[
+		{"request":{"method":"GET","url":"http://nws.test/a"},"response":{"status":503,"body":"busy"}},
+		{"request":{"method":"GET","url":"http://nws.test/a"},"response":{"status":200,"body":"ok"}}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec}
+
+	for _, want := range []string{"busy", "ok", "ok"} {
+		resp, err := client.Get("http://nws.test/a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Errorf("body = %q, want %q", body, want)
+		}
+	}
+}
+
+func TestRecordSanitizes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret.json")
+
+	srv := weathertest.NewServer()
+	defer srv.Close()
+	srv.Handle("/private", weathertest.Response{
+		Body:   `{}`,
+		Header: http.Header{"Set-Cookie": {"session=abc123"}},
+	})
+
+	rec, err := cassette.New(path, cassette.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", srv.URL+"/private?api_key=hunter2&q=Miami", nil)
+	req.Header.Set("X-API-Key", "hunter2")
+	resp, err := (&http.Client{Transport: rec}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", "abc123"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]cassette.Mode{
+		"":        cassette.ModeOff,
+		"off":     cassette.ModeOff,
+		"Record":  cassette.ModeRecord,
+		"replay ": cassette.ModeReplay,
+	} {
+		got, err := cassette.ParseMode(in)
+		if err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := cassette.ParseMode("rewind"); err == nil {
+		t.Error("ParseMode(rewind) succeeded, want error")
+	}
+}
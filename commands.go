@@ -0,0 +1,543 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"learn-go/batch"
+	"learn-go/breaker"
+	"learn-go/cassette"
+	"learn-go/config"
+	"learn-go/middleware"
+	"learn-go/ollama"
+	"learn-go/profile"
+	"learn-go/render"
+	"learn-go/server"
+	"learn-go/weather"
+)
+
+// maxRetries is the retry count for model calls made by the CLI.
+const maxRetries = 3
+
+// app carries the configuration and streams shared by every command.
+type app struct {
+	cfg     *config.Config
+	profile *profile.Profile
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+var commands []command
+
+func init() {
+	// Assigned here rather than in the declaration because help refers
+	// back to commands.
+	commands = []command{
+		{"ask", "<query>", "answer a question about the weather", (*app).ask},
+		{"current", "<location>", "show current conditions", (*app).current},
+		{"forecast", "<location>", "show the forecast (-days N, -hourly)", (*app).forecast},
+		{"alerts", "<location>", "show active weather alerts", (*app).alerts},
+		{"batch", "<file|->", "answer a file of queries as JSONL", (*app).batch},
+		{"serve", "", "serve the HTTP API", (*app).serve},
+		{"doctor", "", "check that Ollama, NWS and Nominatim are reachable", (*app).doctor},
+		{"eval-semantic", "<file>", "measure semantic cache hit rates on labeled queries", (*app).evalSemantic},
+		{"profile", "[home|save|remove|units|output ...]", "show or edit saved places and preferences", (*app).profileCmd},
+		{"watch", "<location>", "print changes in the weather as they happen (-every 10m)", (*app).watch},
+		{"notify", "", "send new alerts for watched areas to webhooks (-config, -once)", (*app).notify},
+		{"tui", "[location ...]", "show a full-screen dashboard (-every 10m)", (*app).tui},
+		{"repl", "", "start the interactive prompt (the default)", (*app).repl},
+		{"help", "", "show this help", (*app).help},
+	}
+}
+
+// dispatch runs the command named by args[0]. With no command, or only
+// flags, the interactive prompt starts.
+func (a *app) dispatch(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "-help" && args[0] != "--help" {
+		return a.repl(args)
+	}
+	name := strings.TrimLeft(args[0], "-")
+	if name == "h" {
+		name = "help"
+	}
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(a, args[1:])
+		}
+	}
+	return usagef("unknown command %q", args[0])
+}
+
+func (a *app) help(args []string) error {
+	fmt.Fprintln(a.stdout, "Usage: weather <command> [flags] [arguments]")
+	fmt.Fprintln(a.stdout, "\nCommands:")
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", c.name, c.args, c.summary)
+	}
+	w.Flush()
+	fmt.Fprintln(a.stdout, "\nRun 'weather <command> -h' for the flags of a command.")
+	fmt.Fprintln(a.stdout, "\nExit codes: 0 ok, 1 error, 2 usage, 3 location not found,")
+	fmt.Fprintln(a.stdout, "4 upstream unavailable or timed out, 5 batch partly failed.")
+	return nil
+}
+
+// options are the flags shared by every command. Each defaults to its
+// environment setting, so flags override .env and the environment.
+type options struct {
+	model        string
+	units        string
+	output       string
+	timeout      time.Duration
+	noAI         bool
+	plain        bool
+	extract      string
+	cassette     string
+	cassetteMode string
+	semantic     string
+	template     string
+	templateFile string
+
+	// display and renderer print results in the chosen -output format and
+	// -units, fitted to the terminal.
+	display  render.Options
+	renderer render.Renderer
+	strategy ollama.ExtractStrategy
+	priority ollama.Priority
+	// recorder is opened once, so clients rebuilt mid-session keep
+	// recording to the same cassette.
+	recorder *cassette.Recorder
+}
+
+func (a *app) flags(name string) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+
+	o := &options{}
+	fs.StringVar(&o.model, "model", a.cfg.OllamaModel, "Ollama model used for extraction and descriptions")
+	fs.StringVar(&o.units, "units", a.cfg.Units, "units for printed values: imperial or metric")
+	fs.BoolVar(&o.plain, "plain", a.cfg.Plain, "no emoji, box drawing or color, for screen readers and logs")
+	fs.StringVar(&o.output, "output", a.cfg.Output, "output format: "+strings.Join(render.Formats, ", "))
+	fs.DurationVar(&o.timeout, "timeout", a.cfg.Timeout, "give up after this long (0 for no limit)")
+	fs.BoolVar(&o.noAI, "no-ai", a.cfg.NoAI, "skip the model: treat input as a location and use template descriptions")
+	fs.StringVar(&o.extract, "extract", a.cfg.ExtractMode, "location extraction: auto (rules, model when unsure), rules or llm")
+	fs.StringVar(&o.cassette, "cassette", a.cfg.CassettePath, "cassette file for recording or replaying upstream HTTP traffic")
+	fs.StringVar(&o.cassetteMode, "cassette-mode", a.cfg.CassetteMode, "cassette mode: record or replay (default replay when -cassette is set)")
+	fs.StringVar(&o.template, "template", "", "print with a built-in template ("+strings.Join(render.BuiltinNames(), ", ")+") or inline text/template text instead of -output")
+	fs.StringVar(&o.templateFile, "template-file", "", "print with the text/template in this file instead of -output")
+	fs.StringVar(&o.semantic, "semantic-cache", a.cfg.SemanticCache, "file of query embeddings used to reuse locations of similar earlier queries")
+	return fs, o
+}
+
+// parse parses args, allowing flags after positional arguments
+// ("forecast Denver -days 3"). Everything after "--" is positional.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, rest = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	return append(positional, rest...), nil
+}
+
+// setup parses args for a command and builds the clients it needs.
+func (a *app) setup(fs *flag.FlagSet, o *options, args []string, priority ollama.Priority) ([]string, *weather.NWSService, *ollama.Client, error) {
+	positional, err := parse(fs, args)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	a.preferences(set, o)
+
+	units, err := render.ParseUnits(o.units)
+	if err != nil {
+		return nil, nil, nil, &usageError{msg: err.Error()}
+	}
+	tty := render.Detect(a.stdout)
+	o.display = render.Options{Units: units, Width: tty.Width, Color: tty.Color, Plain: o.plain}
+	if o.renderer, err = o.newRenderer(); err != nil {
+		return nil, nil, nil, err
+	}
+	if o.strategy, err = ollama.ParseExtractStrategy(o.extract); err != nil {
+		return nil, nil, nil, &usageError{msg: err.Error()}
+	}
+	o.priority = priority
+
+	if o.cassette != "" {
+		if o.recorder, err = newRecorder(o.cassette, o.cassetteMode); err != nil {
+			return nil, nil, nil, fmt.Errorf("opening cassette: %w", err)
+		}
+		fmt.Fprintf(a.stderr, "%sCassette %s (%s)\n", o.display.Symbol("📼 ", ""), o.cassette, o.recorder.Mode())
+	}
+
+	weatherSvc, client, err := a.build(o)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return positional, weatherSvc, client, nil
+}
+
+// build creates the weather service and model client described by o.
+func (a *app) build(o *options) (*weather.NWSService, *ollama.Client, error) {
+	cfg := a.cfg
+	breakers := breaker.Settings{
+		Failures: cfg.BreakerFailures,
+		Cooldown: cfg.BreakerCooldown,
+	}
+	weatherOpts := []weather.Option{
+		weather.WithBreakerSettings(breakers),
+	}
+	placeWeatherOpts, placeOllamaOpts := a.placeOptions()
+	weatherOpts = append(weatherOpts, placeWeatherOpts...)
+	ollamaOpts := []ollama.Option{
+		ollama.WithBaseURL(cfg.OllamaURL),
+		ollama.WithModel(o.model),
+		ollama.WithAI(!o.noAI),
+		ollama.WithExtractStrategy(o.strategy),
+		ollama.WithBreakerSettings(breakers),
+		ollama.WithAdmission(ollama.NewAdmission(ollama.AdmissionSettings{
+			MaxInFlight: cfg.OllamaMaxInFlight,
+			MaxQueue:    cfg.OllamaMaxQueue,
+			MaxWait:     cfg.OllamaMaxWait,
+		})),
+		ollama.WithPriority(o.priority),
+		ollama.WithDescriptionCache(cfg.DescriptionCacheSize, cfg.DescriptionCacheTTL),
+		ollama.WithEmbedModel(cfg.EmbedModel),
+	}
+	ollamaOpts = append(ollamaOpts, placeOllamaOpts...)
+
+	if o.semantic != "" {
+		cache, err := ollama.NewSemanticCache(o.semantic, cfg.SemanticThreshold, cfg.SemanticCacheSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening semantic cache: %w", err)
+		}
+		ollamaOpts = append(ollamaOpts, ollama.WithSemanticCache(cache))
+	}
+
+	if o.recorder != nil {
+		weatherOpts = append(weatherOpts, weather.WithTransport(o.recorder))
+		ollamaOpts = append(ollamaOpts, ollama.WithTransport(o.recorder))
+	}
+
+	weatherSvc := weather.NewNWSService(weatherOpts...)
+	return weatherSvc, ollama.NewClient(weatherSvc, ollamaOpts...), nil
+}
+
+// newRenderer returns the renderer for -template, -template-file or
+// -output, in that order of preference.
+func (o *options) newRenderer() (render.Renderer, error) {
+	opts := o.display
+	text := o.template
+	switch {
+	case o.templateFile != "":
+		data, err := os.ReadFile(o.templateFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading template: %w", err)
+		}
+		text = string(data)
+	case text == "":
+		r, err := render.New(o.output, opts)
+		if err != nil {
+			return nil, &usageError{msg: err.Error()}
+		}
+		return r, nil
+	}
+
+	if builtin, ok := render.Builtin(text); ok && o.templateFile == "" {
+		text = builtin
+	} else if o.templateFile == "" && !strings.Contains(text, "{{") {
+		return nil, usagef("unknown template %q (want one of %s, or template text)", text, strings.Join(render.BuiltinNames(), ", "))
+	}
+	r, err := render.NewTemplate(text, opts)
+	if err != nil {
+		return nil, &usageError{msg: err.Error()}
+	}
+	return r, nil
+}
+
+// oneShot answers a single request with fetch and prints the result.
+func (a *app) oneShot(name, what string, args []string, extra func(fs *flag.FlagSet), fetch func(client *ollama.Client, arg string) (*ollama.WeatherResponse, error)) error {
+	fs, o := a.flags(name)
+	if extra != nil {
+		extra(fs)
+	}
+	positional, _, client, err := a.setup(fs, o, args, ollama.PriorityInteractive)
+	if err != nil {
+		return helpOK(err)
+	}
+	arg := strings.TrimSpace(strings.Join(positional, " "))
+	if arg == "" {
+		return usagef("%s: missing %s", name, what)
+	}
+
+	resp, err := withTimeout(o.timeout, func() (*ollama.WeatherResponse, error) {
+		return fetch(client, arg)
+	})
+	if err != nil {
+		return err
+	}
+	return o.renderer.Render(a.stdout, resp)
+}
+
+// helpOK turns a request for -h into success.
+func helpOK(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+func (a *app) ask(args []string) error {
+	return a.oneShot("ask", "query", args, nil, func(client *ollama.Client, query string) (*ollama.WeatherResponse, error) {
+		return client.GetWeather(query, maxRetries)
+	})
+}
+
+func (a *app) current(args []string) error {
+	return a.oneShot("current", "location", args, nil, func(client *ollama.Client, location string) (*ollama.WeatherResponse, error) {
+		return client.GetWeatherData(location, maxRetries)
+	})
+}
+
+func (a *app) forecast(args []string) error {
+	var days int
+	var hourly bool
+	return a.oneShot("forecast", "location", args, func(fs *flag.FlagSet) {
+		fs.IntVar(&days, "days", 3, "number of days to forecast")
+		fs.BoolVar(&hourly, "hourly", false, "forecast hour by hour")
+	}, func(client *ollama.Client, location string) (*ollama.WeatherResponse, error) {
+		if days < 1 {
+			return nil, usagef("forecast: -days must be at least 1")
+		}
+		return client.GetForecastData(location, days, hourly, maxRetries)
+	})
+}
+
+func (a *app) alerts(args []string) error {
+	return a.oneShot("alerts", "location", args, nil, func(client *ollama.Client, location string) (*ollama.WeatherResponse, error) {
+		return client.GetAlertsData(location, maxRetries)
+	})
+}
+
+func (a *app) serve(args []string) error {
+	fs, o := a.flags("serve")
+	port := fs.String("port", a.cfg.Port, "port to listen on")
+	_, weatherSvc, client, err := a.setup(fs, o, args, ollama.PriorityInteractive)
+	if err != nil {
+		return helpOK(err)
+	}
+
+	mw := []middleware.Middleware{middleware.RateLimit(a.cfg.RateLimit), middleware.CORS(), middleware.Logger()}
+	if a.cfg.APIKey != "" {
+		mw = append([]middleware.Middleware{middleware.Auth()}, mw...)
+	}
+
+	addr := ":" + *port
+	log.Printf("Serving on %s", addr)
+	breakers := append(weatherSvc.Breakers(), client.Breakers()...)
+	return http.ListenAndServe(addr, server.New(client, breakers...).Handler(mw...))
+}
+
+func (a *app) batch(args []string) error {
+	fs, o := a.flags("batch")
+	format := fs.String("format", batch.FormatAuto, "input format: auto, lines, csv or jsonl")
+	outPath := fs.String("o", "", "write results to this file instead of stdout")
+	workers := fs.Int("workers", a.cfg.BatchWorkers, "queries answered at once")
+	rate := fs.Float64("rate", a.cfg.BatchRate, "most queries started per second (0 for no limit)")
+	// Batch work waits behind interactive queries for the model.
+	positional, _, client, err := a.setup(fs, o, args, ollama.PriorityBatch)
+	if err != nil {
+		return helpOK(err)
+	}
+	if len(positional) != 1 {
+		return usagef("batch: want one input file, or - for stdin")
+	}
+
+	var in io.Reader = a.stdin
+	if path := positional[0]; path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	items, err := batch.Read(in, *format)
+	if err != nil {
+		return err
+	}
+
+	out := a.stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	summary, err := batch.Run(ctx, items, func(query string) (*ollama.WeatherResponse, error) {
+		return client.GetWeather(query, maxRetries)
+	}, batch.Options{Workers: *workers, Rate: *rate}, out)
+	fmt.Fprintf(a.stderr, "%d queries: %d answered, %d failed in %v\n",
+		summary.Total, summary.Succeeded, summary.Failed, summary.Elapsed.Round(time.Second))
+	if err == nil && summary.Failed > 0 {
+		return errBatchFailed
+	}
+	return err
+}
+
+// semanticThresholds are the cut-offs reported by eval-semantic.
+var semanticThresholds = []float64{0.80, 0.85, 0.90, 0.92, 0.94, 0.96, 0.98}
+
+func (a *app) evalSemantic(args []string) error {
+	fs, o := a.flags("eval-semantic")
+	positional, _, client, err := a.setup(fs, o, args, ollama.PriorityBatch)
+	if err != nil {
+		return helpOK(err)
+	}
+	if len(positional) != 1 {
+		return usagef("eval-semantic: want one JSONL file of {\"query\",\"location\"}")
+	}
+	path := positional[0]
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var queries []ollama.LabeledQuery
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var q ollama.LabeledQuery
+		if err := dec.Decode(&q); err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		queries = append(queries, q)
+	}
+
+	results, err := client.EvaluateSemanticCache(queries, semanticThresholds)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Threshold\tHits\tHit rate\tFalse hits\tFalse-hit rate\n")
+	for _, r := range results {
+		fmt.Fprintf(w, "%.2f\t%d/%d\t%.1f%%\t%d\t%.1f%%\n", r.Threshold, r.Hits, r.Queries, 100*r.HitRate(), r.FalseHits, 100*r.FalseHitRate())
+	}
+	return w.Flush()
+}
+
+// doctorLocation is looked up to check NWS and Nominatim end to end.
+const doctorLocation = "Washington, DC"
+
+// doctor checks each upstream the CLI depends on and reports what it finds.
+func (a *app) doctor(args []string) error {
+	fs, o := a.flags("doctor")
+	_, weatherSvc, client, err := a.setup(fs, o, args, ollama.PriorityInteractive)
+	if err != nil {
+		return helpOK(err)
+	}
+	timeout := o.timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	failed := 0
+	check := func(ok bool, format string, args ...interface{}) {
+		mark := o.display.Symbol("✓", "ok:")
+		if !ok {
+			mark = o.display.Symbol("✗", "FAIL:")
+			failed++
+		}
+		fmt.Fprintf(a.stdout, "%s %s\n", mark, fmt.Sprintf(format, args...))
+	}
+
+	if o.noAI {
+		fmt.Fprintln(a.stdout, "- Ollama skipped (-no-ai)")
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		models, err := client.Models(ctx)
+		cancel()
+		if err != nil {
+			check(false, "Ollama at %s: %v", a.cfg.OllamaURL, err)
+		} else {
+			check(true, "Ollama at %s (%d models installed)", a.cfg.OllamaURL, len(models))
+			want := []string{client.Model()}
+			if o.semantic != "" {
+				want = append(want, client.EmbedModel())
+			}
+			for _, name := range want {
+				if ollama.HasModel(models, name) {
+					check(true, "Model %s installed", name)
+				} else {
+					check(false, "Model %s not installed (run: ollama pull %s)", name, name)
+				}
+			}
+		}
+	}
+
+	start := time.Now()
+	data, err := withTimeout(timeout, func() (*weather.WeatherData, error) {
+		return weatherSvc.GetWeather(doctorLocation)
+	})
+	if err != nil {
+		check(false, "Nominatim and NWS lookup for %s: %v", doctorLocation, err)
+	} else {
+		check(true, "Nominatim and NWS lookup for %s: %s, %s (%v)", doctorLocation, render.ObservedTemp(data, render.Imperial.WholeTemp), data.Conditions, time.Since(start).Round(time.Millisecond))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed: %w", failed, errUnhealthy)
+	}
+	return nil
+}
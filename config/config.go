@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -12,21 +16,160 @@ type Config struct {
 	OllamaModel    string
 	RateLimit      float64
 	AllowedOrigins []string
+	CassettePath   string
+	CassetteMode   string
+	NoAI           bool
+	ExtractMode    string
+	// Units and Output choose how results are printed, and Timeout bounds
+	// each command or query; zero means no limit.
+	Units   string
+	Output  string
+	Timeout time.Duration
+	// Plain drops emoji, box drawing and color from text output.
+	Plain bool
+	// ConfigDir holds the prompt's history and recent locations; History
+	// turns saving history off when false.
+	ConfigDir string
+	History   bool
+	// BreakerFailures consecutive upstream failures open a circuit breaker
+	// for BreakerCooldown.
+	BreakerFailures int
+	BreakerCooldown time.Duration
+	// OllamaMaxInFlight generations run at once; up to OllamaMaxQueue more
+	// wait at most OllamaMaxWait for a slot before being turned away.
+	OllamaMaxInFlight int
+	OllamaMaxQueue    int
+	OllamaMaxWait     time.Duration
+	// DescriptionCacheSize model descriptions are kept for at most
+	// DescriptionCacheTTL; zero size turns the cache off.
+	DescriptionCacheSize int
+	DescriptionCacheTTL  time.Duration
+	// SemanticCache is where query embeddings are stored, at most
+	// SemanticCacheSize of them; empty turns the semantic cache off.
+	SemanticCache     string
+	SemanticCacheSize int
+	SemanticThreshold float64
+	EmbedModel        string
+	// BatchWorkers queries are answered at once in batch mode, starting at
+	// most BatchRate per second.
+	BatchWorkers int
+	BatchRate    float64
 }
 
+// Load reads the configuration from the environment. A setting that
+// doesn't parse is an error naming the variable, rather than a silent zero.
 func Load() (*Config, error) {
-	rateLimit, _ := strconv.ParseFloat(getEnvOrDefault("RATE_LIMIT", "1"), 64)
+	var env parser
+	rateLimit := env.float("RATE_LIMIT", "1")
+	noAI := env.bool("WEATHER_NO_AI", "false")
+	plain := env.bool("WEATHER_PLAIN", "false")
+	history := env.bool("WEATHER_HISTORY", "true")
+	timeout := env.duration("WEATHER_TIMEOUT", "0")
+	breakerFailures := env.int("BREAKER_FAILURES", "5")
+	breakerCooldown := env.duration("BREAKER_COOLDOWN", "30s")
+	maxInFlight := env.int("OLLAMA_MAX_IN_FLIGHT", "2")
+	maxQueue := env.int("OLLAMA_MAX_QUEUE", "16")
+	maxWait := env.duration("OLLAMA_MAX_WAIT", "20s")
+	cacheSize := env.int("DESCRIPTION_CACHE_SIZE", "256")
+	cacheTTL := env.duration("DESCRIPTION_CACHE_TTL", "1h")
+	semanticSize := env.int("SEMANTIC_CACHE_SIZE", "1000")
+	semanticThreshold := env.float("SEMANTIC_THRESHOLD", "0.92")
+	batchWorkers := env.int("BATCH_WORKERS", "4")
+	// Nominatim's usage policy allows one request per second.
+	batchRate := env.float("BATCH_RATE", "1")
+	if err := errors.Join(env.errs...); err != nil {
+		return nil, err
+	}
 
 	return &Config{
-		Port:           getEnvOrDefault("PORT", "8080"),
-		APIKey:         os.Getenv("API_KEY"),
-		OllamaURL:      getEnvOrDefault("OLLAMA_URL", "http://localhost:11434/api"),
-		OllamaModel:    getEnvOrDefault("OLLAMA_MODEL", "phi4"),
-		RateLimit:      rateLimit,
-		AllowedOrigins: []string{"*"}, // Configure as needed
+		Port:                 getEnvOrDefault("PORT", "8080"),
+		APIKey:               os.Getenv("API_KEY"),
+		OllamaURL:            getEnvOrDefault("OLLAMA_URL", "http://localhost:11434/api"),
+		OllamaModel:          getEnvOrDefault("OLLAMA_MODEL", "phi4"),
+		RateLimit:            rateLimit,
+		AllowedOrigins:       []string{"*"}, // Configure as needed
+		CassettePath:         os.Getenv("WEATHER_CASSETTE"),
+		CassetteMode:         os.Getenv("WEATHER_CASSETTE_MODE"),
+		NoAI:                 noAI,
+		ExtractMode:          getEnvOrDefault("EXTRACT_STRATEGY", "auto"),
+		Units:                getEnvOrDefault("WEATHER_UNITS", "imperial"),
+		Output:               getEnvOrDefault("WEATHER_OUTPUT", "text"),
+		Timeout:              timeout,
+		Plain:                plain,
+		ConfigDir:            getEnvOrDefault("WEATHER_CONFIG_DIR", defaultConfigDir()),
+		History:              history,
+		BreakerFailures:      breakerFailures,
+		BreakerCooldown:      breakerCooldown,
+		OllamaMaxInFlight:    maxInFlight,
+		OllamaMaxQueue:       maxQueue,
+		OllamaMaxWait:        maxWait,
+		DescriptionCacheSize: cacheSize,
+		DescriptionCacheTTL:  cacheTTL,
+		SemanticCache:        os.Getenv("SEMANTIC_CACHE"),
+		SemanticCacheSize:    semanticSize,
+		SemanticThreshold:    semanticThreshold,
+		EmbedModel:           getEnvOrDefault("EMBED_MODEL", "nomic-embed-text"),
+		BatchWorkers:         batchWorkers,
+		BatchRate:            batchRate,
 	}, nil
 }
 
+// defaultConfigDir is "weather" under the user's configuration directory,
+// such as ~/.config/weather, or empty when there is none.
+func defaultConfigDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "weather")
+}
+
+// parser reads typed settings, collecting an error for each variable that
+// doesn't parse.
+type parser struct {
+	errs []error
+}
+
+func (p *parser) fail(key, value, want string) {
+	p.errs = append(p.errs, fmt.Errorf("%s=%q: want %s", key, value, want))
+}
+
+func (p *parser) float(key, defaultValue string) float64 {
+	value := getEnvOrDefault(key, defaultValue)
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.fail(key, value, "a number")
+	}
+	return f
+}
+
+func (p *parser) int(key, defaultValue string) int {
+	value := getEnvOrDefault(key, defaultValue)
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.fail(key, value, "a whole number")
+	}
+	return n
+}
+
+func (p *parser) bool(key, defaultValue string) bool {
+	value := getEnvOrDefault(key, defaultValue)
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		p.fail(key, value, "true or false")
+	}
+	return b
+}
+
+func (p *parser) duration(key, defaultValue string) time.Duration {
+	value := getEnvOrDefault(key, defaultValue)
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		p.fail(key, value, "a duration with a unit, such as 10s or 2m")
+	}
+	return d
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -4,5 +4,8 @@ go 1.21
 
 require (
 	github.com/joho/godotenv v1.5.1
+	golang.org/x/term v0.29.0
 	golang.org/x/time v0.9.0
 )
+
+require golang.org/x/sys v0.30.0 // indirect
@@ -0,0 +1,253 @@
+// Package guard checks AI-written weather descriptions against the data they
+// were generated from, catching rounded or invented numbers and weather
+// that isn't happening.
+package guard
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"learn-go/weather"
+)
+
+// Claim kinds.
+const (
+	KindTemperature = "temperature"
+	KindPercentage  = "percentage"
+	KindSpeed       = "speed"
+	KindCondition   = "condition"
+)
+
+// Issue is a statement in the text that the data doesn't support.
+type Issue struct {
+	Kind  string `json:"kind"`
+	Claim string `json:"claim"` // as written in the text
+	Want  string `json:"want"`  // what the data says
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%q (%s) does not match the data: %s", i.Claim, i.Kind, i.Want)
+}
+
+// Tolerances are the allowed differences between a stated and an observed
+// value.
+type Tolerances struct {
+	TemperatureF float64
+	Percent      float64
+	SpeedMph     float64
+}
+
+// DefaultTolerances allow the rounding a human forecaster would do.
+var DefaultTolerances = Tolerances{
+	TemperatureF: 1.5,
+	Percent:      5,
+	SpeedMph:     3,
+}
+
+var (
+	temperaturePattern = regexp.MustCompile(`(?i)(-?\d+(?:\.\d+)?)\s*(?:°|º|degrees?\b)\s*(f\b|c\b|fahrenheit|celsius)?`)
+	approxPattern      = regexp.MustCompile(`(?i)\b(?:around|about|near|roughly|approximately)\s+(-?\d+(?:\.\d+)?)\b(\s*(?:%|percent|mph|km/?h|kph|knots?|kt|m/s|°|º|degrees?))?`)
+	percentPattern     = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(?:%|percent\b)`)
+	speedPattern       = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(mph|miles per hour|km/?h|kph|kilometers per hour|knots?|kt\b|m/s)`)
+)
+
+// conditionFamilies group words that describe the same weather. A family is
+// supported when any of its words appears in the observed conditions.
+var conditionFamilies = []struct {
+	name  string
+	words []string
+}{
+	{"rain", []string{"rain", "showers", "drizzle"}},
+	{"snow", []string{"snow", "flurries", "sleet", "wintry"}},
+	{"thunderstorm", []string{"thunder", "storm"}},
+	{"fog", []string{"fog", "mist", "haze"}},
+}
+
+// hedges before a condition word mean the text isn't claiming it is
+// happening.
+var hedges = []string{"no ", "not ", "without ", "n't ", "chance of ", "possibility of ", "risk of ", "free of "}
+
+// Check compares text against d using DefaultTolerances.
+func Check(text string, d *weather.WeatherData) []Issue {
+	return CheckWith(text, d, DefaultTolerances)
+}
+
+// CheckWith compares every numeric and condition claim in text against d.
+func CheckWith(text string, d *weather.WeatherData, tol Tolerances) []Issue {
+	var issues []Issue
+	issues = append(issues, checkTemperatures(text, d, tol)...)
+	issues = append(issues, checkPercentages(text, d, tol)...)
+	issues = append(issues, checkSpeeds(text, d, tol)...)
+	issues = append(issues, checkConditions(text, d)...)
+	return issues
+}
+
+func checkTemperatures(text string, d *weather.WeatherData, tol Tolerances) []Issue {
+	var refs []float64
+	want := "temperature is unavailable"
+	if !d.TemperatureMissing {
+		refs = append(refs, d.Temperature)
+		want = fmt.Sprintf("temperature is %.1f°F", d.Temperature)
+	}
+	for _, v := range []float64{d.FeelsLike, d.DewPoint} {
+		if v != 0 {
+			refs = append(refs, v)
+		}
+	}
+
+	var issues []Issue
+	for _, m := range temperaturePattern.FindAllStringSubmatch(text, -1) {
+		value, _ := strconv.ParseFloat(m[1], 64)
+		if unit := strings.ToLower(m[2]); strings.HasPrefix(unit, "c") {
+			value = value*9/5 + 32
+		}
+		if !near(value, refs, tol.TemperatureF) {
+			issues = append(issues, Issue{Kind: KindTemperature, Claim: strings.TrimSpace(m[0]), Want: want})
+		}
+	}
+
+	// "around 75" with no unit is how models usually round a temperature.
+	for _, m := range approxPattern.FindAllStringSubmatch(text, -1) {
+		if m[2] != "" {
+			continue // has a unit, checked by the matching pattern
+		}
+		value, _ := strconv.ParseFloat(m[1], 64)
+		if !near(value, refs, tol.TemperatureF) && !near(value, percentRefs(d), tol.Percent) {
+			issues = append(issues, Issue{Kind: KindTemperature, Claim: strings.TrimSpace(m[0]), Want: want})
+		}
+	}
+
+	return issues
+}
+
+func percentRefs(d *weather.WeatherData) []float64 {
+	return []float64{float64(d.Humidity), float64(d.CloudCover), float64(d.PrecipitationChance)}
+}
+
+func checkPercentages(text string, d *weather.WeatherData, tol Tolerances) []Issue {
+	want := fmt.Sprintf("humidity %d%%, cloud cover %d%%, precipitation chance %d%%", d.Humidity, d.CloudCover, d.PrecipitationChance)
+
+	var issues []Issue
+	for _, m := range percentPattern.FindAllStringSubmatch(text, -1) {
+		value, _ := strconv.ParseFloat(m[1], 64)
+		if !near(value, percentRefs(d), tol.Percent) {
+			issues = append(issues, Issue{Kind: KindPercentage, Claim: strings.TrimSpace(m[0]), Want: want})
+		}
+	}
+	return issues
+}
+
+func checkSpeeds(text string, d *weather.WeatherData, tol Tolerances) []Issue {
+	refs := []float64{d.WindSpeedMph()}
+	if d.WindGust > 0 {
+		refs = append(refs, d.WindGustMph())
+	}
+	want := fmt.Sprintf("wind is %.1f mph", d.WindSpeedMph())
+	if d.WindGust > 0 {
+		want += fmt.Sprintf(", gusting to %.1f mph", d.WindGustMph())
+	}
+
+	var issues []Issue
+	for _, m := range speedPattern.FindAllStringSubmatch(text, -1) {
+		value, _ := strconv.ParseFloat(m[1], 64)
+		mph := toMph(value, strings.ToLower(m[2]))
+		// Allow a relative tolerance for strong winds, where round numbers
+		// are naturally further apart.
+		allowed := math.Max(tol.SpeedMph, mph*0.15)
+		if !near(mph, refs, allowed) {
+			issues = append(issues, Issue{Kind: KindSpeed, Claim: strings.TrimSpace(m[0]), Want: want})
+		}
+	}
+	return issues
+}
+
+func toMph(value float64, unit string) float64 {
+	switch {
+	case strings.HasPrefix(unit, "m/s"):
+		return weather.SpeedToMph(value, "wmoUnit:m_s-1")
+	case strings.HasPrefix(unit, "knot"), unit == "kt":
+		return weather.SpeedToMph(value, "wmoUnit:kt")
+	case strings.HasPrefix(unit, "k"):
+		return weather.SpeedToMph(value, "wmoUnit:km_h-1")
+	default:
+		return value
+	}
+}
+
+func checkConditions(text string, d *weather.WeatherData) []Issue {
+	observed := strings.ToLower(d.Conditions)
+	if observed == "" {
+		return nil // nothing to contradict
+	}
+	lower := strings.ToLower(text)
+
+	var issues []Issue
+	for _, family := range conditionFamilies {
+		if mentions(observed, family.words) {
+			continue
+		}
+		for _, word := range family.words {
+			if claimed(lower, word) {
+				issues = append(issues, Issue{
+					Kind:  KindCondition,
+					Claim: word,
+					Want:  fmt.Sprintf("conditions are %q, no %s reported", d.Conditions, family.name),
+				})
+				break
+			}
+		}
+	}
+	return issues
+}
+
+func mentions(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// claimed reports whether word appears in text other than after a hedge
+// such as "no" or "chance of".
+func claimed(text, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], word)
+		if j < 0 {
+			return false
+		}
+		pos := i + j
+		if (pos == 0 || !unicode.IsLetter(rune(text[pos-1]))) && !hedged(text[:pos]) {
+			return true
+		}
+		i = pos + len(word)
+	}
+}
+
+func hedged(before string) bool {
+	start := len(before) - 24
+	if start < 0 {
+		start = 0
+	}
+	window := before[start:]
+	for _, h := range hedges {
+		if strings.Contains(window, h) {
+			return true
+		}
+	}
+	return false
+}
+
+func near(value float64, refs []float64, tolerance float64) bool {
+	for _, r := range refs {
+		if math.Abs(value-r) <= tolerance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package guard
+
+import (
+	"strings"
+	"testing"
+
+	"learn-go/weather"
+)
+
+var observed = &weather.WeatherData{
+	Temperature:         71.6,
+	FeelsLike:           73,
+	Conditions:          "Partly Cloudy",
+	Humidity:            64,
+	WindSpeed:           14.8, // 9.2 mph
+	WindSpeedUnit:       "wmoUnit:km_h-1",
+	WindGust:            29.6, // 18.4 mph
+	PrecipitationChance: 10,
+}
+
+func TestCheckAccepts(t *testing.T) {
+	for _, text := range []string{
+		"It's 72°F and partly cloudy in Miami, feeling like 73 degrees.",
+		"Temperatures near 22°C with 64% humidity.",
+		"A light breeze of 9 mph, gusting to 18 mph.",
+		"Winds around 15 km/h under partly cloudy skies.",
+		"There is no rain in sight, with only a 10 percent chance of showers.",
+		"Humidity sits around 64.",
+		"A pleasant afternoon without storms.",
+	} {
+		if issues := Check(text, observed); len(issues) != 0 {
+			t.Errorf("Check(%q) = %v, want no issues", text, issues)
+		}
+	}
+}
+
+func TestCheckRejects(t *testing.T) {
+	tests := []struct {
+		text  string
+		kind  string
+		claim string
+	}{
+		{"It's a warm 75°F today.", KindTemperature, "75°F"},
+		{"Temperatures around 75 this afternoon.", KindTemperature, "around 75"},
+		{"A cool 18 degrees Celsius.", KindTemperature, "18 degrees Celsius"},
+		{"Humidity is high at 90%.", KindPercentage, "90%"},
+		{"Strong winds at 30 mph.", KindSpeed, "30 mph"},
+		{"Expect rain throughout the afternoon.", KindCondition, "rain"},
+		{"Thunderstorms are rolling in.", KindCondition, "thunder"},
+	}
+
+	for _, tt := range tests {
+		issues := Check(tt.text, observed)
+		if len(issues) != 1 {
+			t.Errorf("Check(%q) = %v, want one %s issue", tt.text, issues, tt.kind)
+			continue
+		}
+		if issues[0].Kind != tt.kind || !strings.EqualFold(issues[0].Claim, tt.claim) {
+			t.Errorf("Check(%q) = %+v, want %s claim %q", tt.text, issues[0], tt.kind, tt.claim)
+		}
+	}
+}
+
+func TestCheckInventedWind(t *testing.T) {
+	calm := *observed
+	calm.WindSpeed, calm.WindGust = 0, 0
+
+	issues := Check("Breezy with winds of 12 mph.", &calm)
+	if len(issues) != 1 || issues[0].Kind != KindSpeed {
+		t.Errorf("issues = %v, want one speed issue", issues)
+	}
+}
+
+func TestCheckInventedTemperature(t *testing.T) {
+	missing := weather.WeatherData{TemperatureMissing: true, Conditions: "Cloudy"}
+
+	issues := Check("A chilly 32°F under cloudy skies.", &missing)
+	if len(issues) != 1 || issues[0].Kind != KindTemperature || issues[0].Want != "temperature is unavailable" {
+		t.Errorf("issues = %v, want one temperature issue", issues)
+	}
+}
+
+func TestCheckConditionsSupported(t *testing.T) {
+	rainy := *observed
+	rainy.Conditions = "Light Rain and Fog/Mist"
+
+	if issues := Check("Light rain with patches of fog.", &rainy); len(issues) != 0 {
+		t.Errorf("issues = %v, want none", issues)
+	}
+	if issues := Check("Conditions in the terrain are mild.", observed); len(issues) != 0 {
+		t.Errorf("issues = %v, want words containing \"rain\" to be ignored", issues)
+	}
+}
@@ -0,0 +1,128 @@
+// Package fakeserver implements the scripted HTTP server shared by the
+// weathertest and ollamatest packages.
+package fakeserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// Response is a canned reply served for a route.
+type Response struct {
+	Status int // defaults to 200
+	Body   string
+	Header http.Header
+	Delay  time.Duration // wait before replying, cut short if the client gives up
+}
+
+// JSON returns a 200 response with v encoded as the body.
+func JSON(v interface{}) Response {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return Response{Body: string(body)}
+}
+
+// Request is a recorded incoming request.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// Server serves scripted responses keyed by URL path.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	routes   map[string][]Response
+	hits     map[string]int
+	requests []Request
+}
+
+// New starts a server with no routes; unknown paths get a 404.
+func New() *Server {
+	s := &Server{
+		routes: make(map[string][]Response),
+		hits:   make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Handle scripts the responses for path. Responses are served in order and
+// the last one repeats once the script is exhausted.
+func (s *Server) Handle(path string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[path] = responses
+	s.hits[path] = 0
+}
+
+// Hits reports how many requests path has received since it was last scripted.
+func (s *Server) Hits(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+// Requests returns every request received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	script, ok := s.routes[r.URL.Path]
+	n := s.hits[r.URL.Path]
+	s.hits[r.URL.Path]++
+	s.mu.Unlock()
+
+	if !ok || len(script) == 0 {
+		http.Error(w, `{"title":"Not Found","status":404}`, http.StatusNotFound)
+		return
+	}
+	if n >= len(script) {
+		n = len(script) - 1
+	}
+	resp := script[n]
+
+	if resp.Delay > 0 {
+		select {
+		case <-time.After(resp.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, resp.Body)
+}
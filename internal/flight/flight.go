@@ -0,0 +1,51 @@
+// Package flight coalesces concurrent calls that share a key, so a burst of
+// identical lookups costs one upstream call.
+package flight
+
+import "sync"
+
+type call[T any] struct {
+	wg   sync.WaitGroup
+	val  T
+	err  error
+	dups int
+}
+
+// Group runs at most one call per key at a time. The zero value is ready to
+// use.
+type Group[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+// Do runs fn for key unless a call for key is already running, in which
+// case it waits for that call and returns its result. shared reports
+// whether the result was handed to more than one caller; shared values must
+// be treated as read-only.
+func (g *Group[T]) Do(key string, fn func() (T, error)) (v T, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := &call[T]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		shared = c.dups > 0
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
@@ -0,0 +1,67 @@
+package flight_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"learn-go/internal/flight"
+)
+
+func TestDoCoalesces(t *testing.T) {
+	var g flight.Group[int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]int, n)
+	shared := make([]bool, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _, shared[i] = g.Do("miami", func() (int, error) {
+				calls.Add(1)
+				<-release
+				return 77, nil
+			})
+		}(i)
+	}
+
+	// Give every goroutine time to join the first call before it returns.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("fn called %d times, want 1", got)
+	}
+	for i := range results {
+		if results[i] != 77 || !shared[i] {
+			t.Errorf("caller %d got %d shared=%v, want 77 shared", i, results[i], shared[i])
+		}
+	}
+}
+
+func TestDoSeparateKeysAndSequentialCalls(t *testing.T) {
+	var g flight.Group[string]
+	errDown := errors.New("down")
+
+	if _, err, shared := g.Do("a", func() (string, error) { return "", errDown }); err != errDown || shared {
+		t.Errorf("Do(a) = %v shared=%v, want errDown unshared", err, shared)
+	}
+
+	// A finished call isn't remembered: the next call runs fn again.
+	v, err, _ := g.Do("a", func() (string, error) { return "again", nil })
+	if err != nil || v != "again" {
+		t.Errorf("second Do(a) = %q, %v, want a fresh call", v, err)
+	}
+
+	v, _, _ = g.Do("b", func() (string, error) { return "b", nil })
+	if v != "b" {
+		t.Errorf("Do(b) = %q, want b", v)
+	}
+}
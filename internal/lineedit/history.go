@@ -0,0 +1,146 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultHistorySize is how many lines a History keeps when no size is
+// given.
+const DefaultHistorySize = 1000
+
+// History is a list of entered lines, optionally saved to a file so it
+// carries over between sessions. Its methods are safe on a nil History,
+// which records nothing.
+type History struct {
+	path    string
+	size    int
+	entries []string
+}
+
+// NewHistory returns an empty in-memory history of at most size lines.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &History{size: size}
+}
+
+// LoadHistory reads the history saved at path, if any, and appends new
+// lines to it as they are added. A file longer than size lines is trimmed
+// when loaded.
+func LoadHistory(path string, size int) (*History, error) {
+	h := NewHistory(size)
+	h.path = path
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+		if err := h.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Len returns the number of lines.
+func (h *History) Len() int {
+	if h == nil {
+		return 0
+	}
+	return len(h.entries)
+}
+
+// At returns line i, oldest first.
+func (h *History) At(i int) string {
+	return h.entries[i]
+}
+
+// Entries returns the lines, oldest first.
+func (h *History) Entries() []string {
+	if h == nil {
+		return nil
+	}
+	return append([]string(nil), h.entries...)
+}
+
+// Add appends line unless it is blank or repeats the previous line.
+// Saving is best effort: a history that can't be written shouldn't stop
+// the prompt.
+func (h *History) Add(line string) {
+	line = strings.TrimSpace(line)
+	if h == nil || line == "" || strings.ContainsAny(line, "\r\n") {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+	h.append(line)
+}
+
+// Clear forgets every line, including those saved.
+func (h *History) Clear() error {
+	if h == nil {
+		return nil
+	}
+	h.entries = nil
+	return h.rewrite()
+}
+
+func (h *History) append(line string) {
+	if h.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(line + "\n")
+}
+
+func (h *History) rewrite() error {
+	if h.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return fmt.Errorf("saving history: %w", err)
+	}
+	var b strings.Builder
+	for _, line := range h.entries {
+		b.WriteString(line + "\n")
+	}
+	if err := os.WriteFile(h.path, []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("saving history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+// Package lineedit reads lines from a terminal with emacs-style editing,
+// history recall and tab completion. When input isn't a terminal, lines
+// are read as they are, so scripts can pipe into the same prompt.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupt is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupt = errors.New("interrupted")
+
+// Editor reads lines, editing them in place on a terminal.
+type Editor struct {
+	// Prompt is written before each line.
+	Prompt string
+	// History is recalled with the up and down arrows and gets every
+	// non-empty line read. Nil means no history.
+	History *History
+	// Complete returns the completions of line[:pos] and the index in line
+	// where they start, for the Tab key.
+	Complete func(line string, pos int) (candidates []string, start int)
+	// Width measures text in terminal cells; the default counts runes.
+	Width func(string) int
+
+	in  *bufio.Reader
+	out io.Writer
+	fd  int // terminal put in raw mode while reading, or -1
+	raw bool
+
+	line   []rune
+	pos    int
+	recall int    // history entry shown, len(History) for the new line
+	draft  []rune // the new line, kept while browsing history
+}
+
+// New returns an editor reading from in and echoing to out. Editing is
+// enabled when in is a terminal.
+func New(in io.Reader, out io.Writer) *Editor {
+	e := &Editor{in: bufio.NewReader(in), out: out, fd: -1}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		e.fd = int(f.Fd())
+		e.raw = true
+	}
+	return e
+}
+
+// NewRaw returns an editor that interprets in as keystrokes from a
+// terminal already in raw mode.
+func NewRaw(in io.Reader, out io.Writer) *Editor {
+	e := New(in, out)
+	e.fd = -1
+	e.raw = true
+	return e
+}
+
+// Interactive reports whether lines are edited key by key.
+func (e *Editor) Interactive() bool {
+	return e.raw
+}
+
+// ReadLine writes the prompt and returns the next line without its line
+// ending. It returns io.EOF at the end of input or on Ctrl-D on an empty
+// line, and ErrInterrupt on Ctrl-C.
+func (e *Editor) ReadLine() (string, error) {
+	if !e.raw {
+		return e.readCooked()
+	}
+	if e.fd >= 0 {
+		state, err := term.MakeRaw(e.fd)
+		if err != nil {
+			return "", fmt.Errorf("entering raw mode: %w", err)
+		}
+		defer term.Restore(e.fd, state)
+	}
+
+	e.line, e.pos, e.draft = nil, 0, nil
+	e.recall = e.History.Len()
+	e.redraw()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(e.line) > 0 {
+				return e.finish(), nil
+			}
+			return "", err
+		}
+		done, err := e.key(r)
+		if err != nil {
+			io.WriteString(e.out, "\r\n")
+			return "", err
+		}
+		if done {
+			return e.finish(), nil
+		}
+	}
+}
+
+func (e *Editor) readCooked() (string, error) {
+	io.WriteString(e.out, e.Prompt)
+	line, err := e.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	e.History.Add(line)
+	return line, nil
+}
+
+func (e *Editor) finish() string {
+	io.WriteString(e.out, "\r\n")
+	line := string(e.line)
+	e.History.Add(line)
+	return line
+}
+
+// Control keys.
+const (
+	keyCtrlA     = 0x01
+	keyCtrlB     = 0x02
+	keyCtrlC     = 0x03
+	keyCtrlD     = 0x04
+	keyCtrlE     = 0x05
+	keyCtrlF     = 0x06
+	keyBackspace = 0x08
+	keyTab       = 0x09
+	keyLF        = 0x0a
+	keyCtrlK     = 0x0b
+	keyCtrlL     = 0x0c
+	keyCR        = 0x0d
+	keyCtrlN     = 0x0e
+	keyCtrlP     = 0x10
+	keyCtrlU     = 0x15
+	keyCtrlW     = 0x17
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+)
+
+// key applies one keystroke and reports whether the line is complete.
+func (e *Editor) key(r rune) (bool, error) {
+	switch r {
+	case keyCR, keyLF:
+		return true, nil
+	case keyCtrlC:
+		return false, ErrInterrupt
+	case keyCtrlD:
+		if len(e.line) == 0 {
+			return false, io.EOF
+		}
+		e.deleteAt(e.pos)
+	case keyCtrlA:
+		e.pos = 0
+	case keyCtrlE:
+		e.pos = len(e.line)
+	case keyCtrlB:
+		e.pos = max(e.pos-1, 0)
+	case keyCtrlF:
+		e.pos = min(e.pos+1, len(e.line))
+	case keyBackspace, keyDelete:
+		if e.pos > 0 {
+			e.pos--
+			e.deleteAt(e.pos)
+		}
+	case keyCtrlK:
+		e.line = e.line[:e.pos]
+	case keyCtrlU:
+		e.line = append([]rune{}, e.line[e.pos:]...)
+		e.pos = 0
+	case keyCtrlW:
+		start := e.pos
+		for start > 0 && unicode.IsSpace(e.line[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(e.line[start-1]) {
+			start--
+		}
+		e.line = append(e.line[:start], e.line[e.pos:]...)
+		e.pos = start
+	case keyCtrlL:
+		io.WriteString(e.out, "\x1b[H\x1b[2J")
+	case keyCtrlP:
+		e.browse(-1)
+	case keyCtrlN:
+		e.browse(1)
+	case keyTab:
+		e.complete()
+	case keyEscape:
+		e.escape()
+	default:
+		if unicode.IsPrint(r) {
+			e.line = append(e.line[:e.pos], append([]rune{r}, e.line[e.pos:]...)...)
+			e.pos++
+		}
+	}
+	e.redraw()
+	return false, nil
+}
+
+// escape handles the arrow, Home, End and Delete key sequences.
+func (e *Editor) escape() {
+	next, _, err := e.in.ReadRune()
+	if err != nil || next != '[' && next != 'O' {
+		return
+	}
+	code, _, err := e.in.ReadRune()
+	if err != nil {
+		return
+	}
+	if code >= '0' && code <= '9' {
+		// ESC [ n ~
+		if tilde, _, err := e.in.ReadRune(); err != nil || tilde != '~' {
+			return
+		}
+		switch code {
+		case '1', '7':
+			e.pos = 0
+		case '4', '8':
+			e.pos = len(e.line)
+		case '3':
+			e.deleteAt(e.pos)
+		}
+		return
+	}
+	switch code {
+	case 'A':
+		e.browse(-1)
+	case 'B':
+		e.browse(1)
+	case 'C':
+		e.pos = min(e.pos+1, len(e.line))
+	case 'D':
+		e.pos = max(e.pos-1, 0)
+	case 'H':
+		e.pos = 0
+	case 'F':
+		e.pos = len(e.line)
+	}
+}
+
+func (e *Editor) deleteAt(i int) {
+	if i < len(e.line) {
+		e.line = append(e.line[:i], e.line[i+1:]...)
+	}
+}
+
+// browse moves through history by delta entries, keeping the line being
+// typed so coming back down restores it.
+func (e *Editor) browse(delta int) {
+	n := e.History.Len()
+	to := e.recall + delta
+	if to < 0 || to > n {
+		return
+	}
+	if e.recall == n {
+		e.draft = append([]rune{}, e.line...)
+	}
+	e.recall = to
+	if to == n {
+		e.line = append([]rune{}, e.draft...)
+	} else {
+		e.line = []rune(e.History.At(to))
+	}
+	e.pos = len(e.line)
+}
+
+// complete replaces the text before the cursor with its completion, or
+// with the longest common prefix of several and lists them.
+func (e *Editor) complete() {
+	if e.Complete == nil {
+		return
+	}
+	prefix := string(e.line[:e.pos])
+	candidates, start := e.Complete(prefix, len(prefix))
+	if len(candidates) == 0 || start < 0 || start > len(prefix) {
+		return
+	}
+
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		common = commonPrefix(common, c)
+	}
+	if len(candidates) == 1 {
+		common += " "
+	}
+	if typed := prefix[start:]; len(common) <= len(typed) {
+		if len(candidates) > 1 {
+			io.WriteString(e.out, "\r\n"+strings.Join(candidates, "  ")+"\r\n")
+		}
+		return
+	}
+
+	head := []rune(prefix[:start] + common)
+	e.line = append(head, e.line[e.pos:]...)
+	e.pos = len(head)
+}
+
+// commonPrefix returns the longest prefix of a and b, ignoring case, in
+// a's spelling.
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) {
+		ra, na := utf8.DecodeRuneInString(a[i:])
+		rb, _ := utf8.DecodeRuneInString(b[i:])
+		if unicode.ToLower(ra) != unicode.ToLower(rb) {
+			break
+		}
+		i += na
+	}
+	return a[:i]
+}
+
+func (e *Editor) width(s string) int {
+	if e.Width != nil {
+		return e.Width(s)
+	}
+	return utf8.RuneCountInString(s)
+}
+
+// redraw rewrites the prompt and line and puts the cursor in place.
+func (e *Editor) redraw() {
+	var b strings.Builder
+	b.WriteString("\r" + e.Prompt + string(e.line) + "\x1b[K")
+	if back := e.width(string(e.line[e.pos:])); back > 0 {
+		fmt.Fprintf(&b, "\x1b[%dD", back)
+	}
+	io.WriteString(e.out, b.String())
+}
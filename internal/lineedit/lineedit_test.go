@@ -0,0 +1,164 @@
+package lineedit_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"learn-go/internal/lineedit"
+)
+
+func readLine(t *testing.T, e *lineedit.Editor) string {
+	t.Helper()
+	line, err := e.ReadLine()
+	if err != nil {
+		t.Fatalf("ReadLine: %v", err)
+	}
+	return line
+}
+
+func TestEditing(t *testing.T) {
+	tests := []struct {
+		name string
+		keys string
+		want string
+	}{
+		{"plain", "weather in Miami\r", "weather in Miami"},
+		{"backspace", "Miamj\x7fi\r", "Miami"},
+		{"insert after moving left", "Mami\x1b[D\x1b[D\x1b[Di\r", "Miami"},
+		{"home and end", "iami\x01M\x05!\r", "Miami!"},
+		{"kill to end", "Miami, FL\x01\x1b[C\x1b[C\x1b[C\x1b[C\x1b[C\x0b\r", "Miami"},
+		{"kill to start", "junk Miami\x01\x1b[3~\x1b[3~\x1b[3~\x1b[3~\x1b[3~\r", "Miami"},
+		{"delete word", "weather in Denver\x17Miami\r", "weather in Miami"},
+		{"ignore control", "Mi\x07ami\r", "Miami"},
+		{"unicode", "東京\x7f都\r", "東都"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := lineedit.NewRaw(strings.NewReader(tt.keys), io.Discard)
+			if got := readLine(t, e); got != tt.want {
+				t.Errorf("line = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestControlKeys(t *testing.T) {
+	e := lineedit.NewRaw(strings.NewReader("Mia\x03"), io.Discard)
+	if _, err := e.ReadLine(); !errors.Is(err, lineedit.ErrInterrupt) {
+		t.Errorf("Ctrl-C: error = %v, want ErrInterrupt", err)
+	}
+
+	e = lineedit.NewRaw(strings.NewReader("\x04"), io.Discard)
+	if _, err := e.ReadLine(); err != io.EOF {
+		t.Errorf("Ctrl-D: error = %v, want io.EOF", err)
+	}
+}
+
+func TestHistoryRecall(t *testing.T) {
+	e := lineedit.NewRaw(strings.NewReader("first\rsecond\r\x1b[A\x1b[A\r\x1b[A\x1b[Bdraft\x1b[A\x1b[B\r"), io.Discard)
+	e.History = lineedit.NewHistory(10)
+
+	for _, want := range []string{"first", "second", "first", "draft"} {
+		if got := readLine(t, e); got != want {
+			t.Errorf("line = %q, want %q", got, want)
+		}
+	}
+	if got := strings.Join(e.History.Entries(), ","); got != "first,second,first,draft" {
+		t.Errorf("history = %s", got)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	places := []string{"San Francisco, CA", "San Diego, CA", "Seattle, WA"}
+	complete := func(line string, pos int) ([]string, int) {
+		start := strings.LastIndex(line[:pos], " in ") + len(" in ")
+		var out []string
+		for _, p := range places {
+			if strings.HasPrefix(strings.ToLower(p), strings.ToLower(line[start:pos])) {
+				out = append(out, p)
+			}
+		}
+		return out, start
+	}
+
+	tests := []struct {
+		keys string
+		want string
+	}{
+		{"weather in se\t\r", "weather in Seattle, WA "},
+		{"weather in sa\t\r", "weather in San "},
+		{"weather in san f\t\r", "weather in San Francisco, CA "},
+		{"weather in x\t\r", "weather in x"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		e := lineedit.NewRaw(strings.NewReader(tt.keys), &out)
+		e.Complete = complete
+		if got := readLine(t, e); got != tt.want {
+			t.Errorf("%q: line = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+
+	// A second Tab with nothing left to add lists the candidates.
+	var out strings.Builder
+	e := lineedit.NewRaw(strings.NewReader("weather in San \t\r"), &out)
+	e.Complete = complete
+	readLine(t, e)
+	if !strings.Contains(out.String(), "San Francisco, CA  San Diego, CA") {
+		t.Errorf("candidates not listed in %q", out.String())
+	}
+}
+
+func TestCookedInput(t *testing.T) {
+	e := lineedit.New(strings.NewReader("weather in Miami\r\nquit"), io.Discard)
+	if e.Interactive() {
+		t.Fatal("a reader isn't a terminal")
+	}
+	if got := readLine(t, e); got != "weather in Miami" {
+		t.Errorf("line = %q", got)
+	}
+	if got := readLine(t, e); got != "quit" {
+		t.Errorf("last line = %q", got)
+	}
+	if _, err := e.ReadLine(); err != io.EOF {
+		t.Errorf("error = %v, want io.EOF", err)
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather", "history")
+
+	h, err := lineedit.LoadHistory(path, 3)
+	if err != nil {
+		t.Fatalf("LoadHistory: %v", err)
+	}
+	for _, line := range []string{"a", "b", "b", " ", "c", "d"} {
+		h.Add(line)
+	}
+	if got := strings.Join(h.Entries(), ","); got != "b,c,d" {
+		t.Errorf("entries = %s, want the last 3 without repeats", got)
+	}
+
+	h, err = lineedit.LoadHistory(path, 3)
+	if err != nil {
+		t.Fatalf("reloading: %v", err)
+	}
+	if got := strings.Join(h.Entries(), ","); got != "b,c,d" {
+		t.Errorf("reloaded entries = %s", got)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "b\nc\nd\n" {
+		t.Errorf("file = %q, want trimmed to 3 lines", data)
+	}
+
+	if err := h.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if h, _ = lineedit.LoadHistory(path, 3); h.Len() != 0 {
+		t.Errorf("history has %d lines after Clear", h.Len())
+	}
+}
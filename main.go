@@ -1,136 +1,151 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
 	"os"
-	"strings"
-	"text/tabwriter"
-	"unicode/utf8"
+	"time"
 
+	"learn-go/breaker"
+	"learn-go/cassette"
+	"learn-go/config"
 	"learn-go/ollama"
+	"learn-go/profile"
 	"learn-go/weather"
 
 	"github.com/joho/godotenv"
 )
 
-func wrapText(text string, width int) string {
-	if width <= 0 {
-		return text
-	}
-	words := strings.Fields(text)
-	if len(words) == 0 {
-		return text
-	}
+// Exit codes, so scripts can tell a bad query from an outage.
+const (
+	exitOK          = 0
+	exitError       = 1 // anything not covered below
+	exitUsage       = 2 // bad command line or setting
+	exitNotFound    = 3 // no location in the query, or not one NWS covers
+	exitUnavailable = 4 // an upstream is down, overloaded or too slow
+	exitPartial     = 5 // some queries of a batch failed
+)
 
-	var lines []string
-	currentLine := words[0]
+var (
+	errTimeout     = errors.New("timed out")
+	errBatchFailed = errors.New("some batch queries failed")
+	errUnhealthy   = errors.New("upstream checks failed")
+)
 
-	for _, word := range words[1:] {
-		if utf8.RuneCountInString(currentLine)+utf8.RuneCountInString(word)+1 <= width {
-			currentLine += " " + word
-		} else {
-			lines = append(lines, currentLine)
-			currentLine = word
-		}
-	}
-	lines = append(lines, currentLine)
+// usageError is a mistake on the command line.
+type usageError struct {
+	msg string
+}
 
-	return strings.Join(lines, "\n")
+func (e *usageError) Error() string {
+	return e.msg
 }
 
-func printWeather(w *tabwriter.Writer, weatherData *ollama.WeatherResponse) {
-	fmt.Fprintf(w, "\n%s\n", strings.Repeat("─", 50))
-	fmt.Fprintf(w, "🌡️  Weather Data\n")
-	fmt.Fprintf(w, "%s\n", strings.Repeat("─", 50))
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
 
-	fmt.Fprintf(w, "Temperature:\t%.1f°F\n", weatherData.Weather.Temperature)
-	if weatherData.Weather.FeelsLike > 0 {
-		fmt.Fprintf(w, "Feels Like:\t%.1f°F\n", weatherData.Weather.FeelsLike)
+// exitCode maps the error a command returned to the process exit status.
+func exitCode(err error) int {
+	var usage *usageError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, ollama.ErrNoLocation),
+		errors.Is(err, weather.ErrLocationNotFound),
+		errors.Is(err, weather.ErrOutsideCoverage):
+		return exitNotFound
+	case errors.Is(err, breaker.ErrOpen),
+		errors.Is(err, ollama.ErrOverloaded),
+		errors.Is(err, errTimeout),
+		errors.Is(err, errUnhealthy),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr):
+		return exitUnavailable
+	case errors.Is(err, errBatchFailed):
+		return exitPartial
 	}
-	fmt.Fprintf(w, "Conditions:\t%s\n", weatherData.Weather.Conditions)
-	fmt.Fprintf(w, "Humidity:\t%d%%\n", weatherData.Weather.Humidity)
+	return exitError
+}
 
-	if weatherData.Weather.WindSpeed > 0 {
-		fmt.Fprintf(w, "Wind Speed:\t%.1f %s\n", weatherData.Weather.WindSpeed, weatherData.Weather.WindSpeedUnit)
+// withTimeout runs fn, giving up after d. The clients don't take a context,
+// so an abandoned call finishes in the background; for a one-shot command
+// the process exits right after anyway.
+func withTimeout[T any](d time.Duration, fn func() (T, error)) (T, error) {
+	if d <= 0 {
+		return fn()
 	}
-	if weatherData.Weather.WindDirection != "" {
-		fmt.Fprintf(w, "Wind Direction:\t%s\n", weatherData.Weather.WindDirection)
+
+	type result struct {
+		v   T
+		err error
 	}
-	if weatherData.Weather.WindGust > 0 {
-		fmt.Fprintf(w, "Wind Gust:\t%.1f %s\n", weatherData.Weather.WindGust, weatherData.Weather.WindSpeedUnit)
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-timer.C:
+		var zero T
+		return zero, fmt.Errorf("%w after %v", errTimeout, d)
 	}
+}
 
-	if weatherData.Weather.Visibility > 0 {
-		fmt.Fprintf(w, "Visibility:\t%.1f miles\n", weatherData.Weather.Visibility)
+func newRecorder(path, modeFlag string) (*cassette.Recorder, error) {
+	mode, err := cassette.ParseMode(modeFlag)
+	if err != nil {
+		return nil, err
 	}
-	if weatherData.Weather.Pressure > 0 {
-		fmt.Fprintf(w, "Pressure:\t%.1f mb\n", weatherData.Weather.Pressure)
+	if mode == cassette.ModeOff {
+		mode = cassette.ModeReplay
 	}
-	if weatherData.Weather.DewPoint > 0 {
-		fmt.Fprintf(w, "Dew Point:\t%.1f°F\n", weatherData.Weather.DewPoint)
+	return cassette.New(path, mode, http.DefaultTransport)
+}
+
+// run executes the command in args and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	// A .env file is optional: settings can come from the environment or
+	// from flags alone.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(stderr, "Warning: reading .env: %v\n", err)
 	}
-	if weatherData.Weather.UVIndex > 0 {
-		fmt.Fprintf(w, "UV Index:\t%.1f\n", weatherData.Weather.UVIndex)
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading config: %v\n", err)
+		return exitUsage
 	}
-	if weatherData.Weather.CloudCover > 0 {
-		fmt.Fprintf(w, "Cloud Cover:\t%d%%\n", weatherData.Weather.CloudCover)
+
+	a := &app{cfg: cfg, stdin: stdin, stdout: stdout, stderr: stderr}
+	if a.profile, err = profile.Load(a.stateFile(profile.FileName)); err != nil {
+		fmt.Fprintf(stderr, "Warning: %v\n", err)
+		a.profile = &profile.Profile{}
 	}
-	if weatherData.Weather.PrecipitationChance > 0 {
-		fmt.Fprintf(w, "Precipitation:\t%d%%\n", weatherData.Weather.PrecipitationChance)
+	err = a.dispatch(args)
+	if err != nil && !errors.Is(err, errBatchFailed) {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
 	}
-
-	fmt.Fprintf(w, "Last Updated:\t%s\n", weatherData.Weather.Timestamp)
-	w.Flush()
-
-	fmt.Printf("\n%s\n", strings.Repeat("─", 50))
-	fmt.Println("🤖 AI Description")
-	fmt.Printf("%s\n", strings.Repeat("─", 50))
-	fmt.Println(wrapText(weatherData.Description, 50))
+	var usage *usageError
+	if errors.As(err, &usage) {
+		fmt.Fprintln(stderr, "Run 'weather help' for usage.")
+	}
+	return exitCode(err)
 }
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
-	}
-
-	weatherSvc := weather.NewNWSService()
-	client := ollama.NewClient(weatherSvc)
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-
-	fmt.Printf("\n%s\n", strings.Repeat("═", 50))
-	fmt.Println("🌤️  Weather Assistant")
-	fmt.Printf("%s\n", strings.Repeat("─", 50))
-	fmt.Println("Type 'quit' to exit")
-	fmt.Println("Ask me about the weather anywhere in the US!")
-	fmt.Println("Example: 'What's the weather like in Miami?'")
-	fmt.Printf("%s\n", strings.Repeat("═", 50))
-
-	scanner := bufio.NewScanner(os.Stdin)
-	for {
-		fmt.Print("\n> ")
-		if !scanner.Scan() {
-			break
-		}
-
-		query := strings.TrimSpace(scanner.Text())
-		if query == "" {
-			continue
-		}
-
-		if strings.ToLower(query) == "quit" {
-			fmt.Println("\nGoodbye! 👋")
-			break
-		}
-
-		weatherData, err := client.GetWeather(query, 3)
-		if err != nil {
-			fmt.Printf("\n❌ Error: %v\n", err)
-			continue
-		}
-
-		printWeather(w, weatherData)
-	}
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
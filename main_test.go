@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/breaker"
+	"learn-go/ollama"
+	"learn-go/weather"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{errors.New("boom"), exitError},
+		{usagef("missing location"), exitUsage},
+		{ollama.ErrNoLocation, exitNotFound},
+		{fmt.Errorf("geocoding location: %w", weather.ErrLocationNotFound), exitNotFound},
+		{&weather.LocationError{Location: "Paris"}, exitNotFound},
+		{&breaker.OpenError{Name: "nws", RetryIn: time.Second}, exitUnavailable},
+		{&ollama.OverloadedError{Reason: "queue full"}, exitUnavailable},
+		{fmt.Errorf("%w after 1s", errTimeout), exitUnavailable},
+		{errBatchFailed, exitPartial},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRunBadSetting(t *testing.T) {
+	t.Setenv("WEATHER_TIMEOUT", "10")
+	t.Setenv("BATCH_WORKERS", "four")
+	var stderr strings.Builder
+	if code := run([]string{"help"}, strings.NewReader(""), io.Discard, &stderr); code != exitUsage {
+		t.Errorf("run with bad settings = %d, want %d", code, exitUsage)
+	}
+	for _, name := range []string{"WEATHER_TIMEOUT", "BATCH_WORKERS"} {
+		if !strings.Contains(stderr.String(), name) {
+			t.Errorf("error %q doesn't name %s", stderr.String(), name)
+		}
+	}
+}
+
+func TestParseInterleaved(t *testing.T) {
+	fs := flag.NewFlagSet("forecast", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	days := fs.Int("days", 1, "")
+	hourly := fs.Bool("hourly", false, "")
+
+	got, err := parse(fs, []string{"New", "York", "-days", "3", "NY", "-hourly", "--", "-x"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if strings.Join(got, " ") != "New York NY -x" || *days != 3 || !*hourly {
+		t.Errorf("got args %q, days %d, hourly %v", got, *days, *hourly)
+	}
+
+	if _, err := parse(fs, []string{"-nope"}); exitCode(err) != exitUsage {
+		t.Errorf("unknown flag gave %v, want a usage error", err)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	_, err := withTimeout(10*time.Millisecond, func() (int, error) {
+		time.Sleep(time.Second)
+		return 1, nil
+	})
+	if !errors.Is(err, errTimeout) {
+		t.Errorf("error = %v, want errTimeout", err)
+	}
+
+	got, err := withTimeout(time.Second, func() (int, error) { return 1, nil })
+	if err != nil || got != 1 {
+		t.Errorf("withTimeout = %d, %v", got, err)
+	}
+}
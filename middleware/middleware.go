@@ -2,8 +2,10 @@ package middleware
 
 import (
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -21,16 +23,24 @@ func Chain(f http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
 
 // RateLimit limits requests per client IP
 func RateLimit(rps float64) Middleware {
+	var mu sync.Mutex
 	limiters := make(map[string]*rate.Limiter)
 
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			ip := r.RemoteAddr
+			// Key on the host alone: each connection from a client has its
+			// own port.
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+			mu.Lock()
 			limiter, exists := limiters[ip]
 			if !exists {
 				limiter = rate.NewLimiter(rate.Limit(rps), 3) // Allow burst of 3
 				limiters[ip] = limiter
 			}
+			mu.Unlock()
 
 			if !limiter.Allow() {
 				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func serve(h http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h(rec, r)
+	return rec
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next(w, r)
+			}
+		}
+	}
+
+	h := Chain(ok, mark("first"), mark("second"), mark("third"))
+	serve(h, httptest.NewRequest("GET", "/weather", nil))
+
+	// Each middleware wraps the previous result, so the last one runs first.
+	if got := strings.Join(order, ","); got != "third,second,first" {
+		t.Errorf("order = %s, want third,second,first", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := Chain(ok, RateLimit(0.001))
+
+	for i := 0; i < 3; i++ {
+		if rec := serve(h, httptest.NewRequest("GET", "/weather", nil)); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200 within burst", i, rec.Code)
+		}
+	}
+	if rec := serve(h, httptest.NewRequest("GET", "/weather", nil)); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status %d after burst, want 429", rec.Code)
+	}
+
+	other := httptest.NewRequest("GET", "/weather", nil)
+	other.RemoteAddr = "10.0.0.2:1234"
+	if rec := serve(h, other); rec.Code != http.StatusOK {
+		t.Errorf("status %d for a different client, want 200", rec.Code)
+	}
+
+	// A new connection from the same client shares its limit.
+	again := httptest.NewRequest("GET", "/weather", nil)
+	again.RemoteAddr = "192.0.2.1:5678"
+	if rec := serve(h, again); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status %d from a new port, want 429", rec.Code)
+	}
+}
+
+func TestRateLimitConcurrent(t *testing.T) {
+	h := Chain(ok, RateLimit(0.001))
+
+	var mu sync.Mutex
+	codes := make(map[int]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := httptest.NewRequest("GET", "/weather", nil)
+			r.RemoteAddr = fmt.Sprintf("10.0.0.%d:%d", i%5, 40000+i)
+			code := serve(h, r).Code
+			mu.Lock()
+			codes[code]++
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	// Five clients with a burst of three each.
+	if codes[http.StatusOK] != 15 || codes[http.StatusTooManyRequests] != 35 {
+		t.Errorf("status counts = %v, want 15 allowed and 35 limited", codes)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	t.Setenv("API_KEY", "secret")
+	h := Chain(ok, Auth())
+
+	r := httptest.NewRequest("GET", "/weather", nil)
+	if rec := serve(h, r); rec.Code != http.StatusUnauthorized {
+		t.Errorf("missing key: status %d, want 401", rec.Code)
+	}
+
+	r.Header.Set("X-API-Key", "wrong")
+	if rec := serve(h, r); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong key: status %d, want 401", rec.Code)
+	}
+
+	r.Header.Set("X-API-Key", "secret")
+	if rec := serve(h, r); rec.Code != http.StatusOK {
+		t.Errorf("valid key: status %d, want 200", rec.Code)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	called := false
+	h := Chain(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}, CORS())
+
+	rec := serve(h, httptest.NewRequest("OPTIONS", "/weather", nil))
+	if rec.Code != http.StatusOK || called {
+		t.Errorf("preflight: status %d, handler called %v; want 200 without calling handler", rec.Code, called)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "X-API-Key") {
+		t.Errorf("Allow-Headers = %q, want X-API-Key", got)
+	}
+
+	serve(h, httptest.NewRequest("GET", "/weather", nil))
+	if !called {
+		t.Error("GET did not reach handler")
+	}
+}
+
+func TestChainAuthBeforeRateLimit(t *testing.T) {
+	t.Setenv("API_KEY", "secret")
+	// Auth is outermost, so rejected requests never consume rate limit tokens.
+	h := Chain(ok, RateLimit(0.001), Auth(), Logger())
+
+	for i := 0; i < 5; i++ {
+		serve(h, httptest.NewRequest("GET", "/weather", nil))
+	}
+
+	r := httptest.NewRequest("GET", "/weather", nil)
+	r.Header.Set("X-API-Key", "secret")
+	if rec := serve(h, r); rec.Code != http.StatusOK {
+		t.Errorf("status %d, want 200: unauthorized requests should not drain the limiter", rec.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"learn-go/notify"
+	"learn-go/ollama"
+	"learn-go/weather"
+)
+
+// defaultNotifyEvery is how often the notifier polls; NWS asks clients not
+// to poll alerts more than about once a minute.
+const defaultNotifyEvery = 5 * time.Minute
+
+var errNotifyFailed = errors.New("some alerts could not be fetched or sent")
+
+// notify sends new alerts for the configured areas to webhooks, polling
+// until interrupted, or once with -once.
+func (a *app) notify(args []string) error {
+	fs, o := a.flags("notify")
+	configPath := fs.String("config", a.stateFile(notify.FileName), "notifier configuration file")
+	every := fs.Duration("every", defaultNotifyEvery, "poll the alerts this often")
+	once := fs.Bool("once", false, "poll once and exit, for cron")
+	// Summaries wait behind interactive queries for the model.
+	_, weatherSvc, client, err := a.setup(fs, o, args, ollama.PriorityBatch)
+	if err != nil {
+		return helpOK(err)
+	}
+	if *configPath == "" {
+		return usagef("notify: no configuration directory; pass -config")
+	}
+	if *every < time.Minute {
+		return usagef("notify: -every must be at least 1m")
+	}
+	cfg, err := notify.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	opts := []notify.Option{notify.WithStateFile(notifyStatePath(*configPath))}
+	if cfg.Summarize {
+		opts = append(opts, notify.WithSummarizer(func(alert weather.Alert) (string, error) {
+			return withTimeout(o.timeout, func() (string, error) {
+				return client.SummarizeAlert(alert, maxRetries)
+			})
+		}))
+	}
+	n, err := notify.New(*cfg, weatherSvc, opts...)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *once {
+		report := n.Poll(ctx)
+		a.printReport(report)
+		if report.Failed() {
+			return errNotifyFailed
+		}
+		return nil
+	}
+	fmt.Fprintf(a.stderr, "Watching alerts for %s every %v (Ctrl-C to stop)\n", strings.Join(cfg.Areas, "; "), *every)
+	return n.Run(ctx, *every, a.printReport)
+}
+
+// notifyStatePath is where the alerts sent for the configuration at
+// configPath are remembered: next to it, so a notifier started from cron
+// with -config and no configuration directory still sends each alert once.
+func notifyStatePath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), notify.StateFileName)
+}
+
+// printReport prints a line for each alert sent and each failure.
+func (a *app) printReport(r notify.Report) {
+	stamp := r.Time.Format("3:04 PM")
+	for _, d := range r.Deliveries {
+		if len(d.Sent) > 0 {
+			fmt.Fprintf(a.stdout, "%s  %s: sent %s %s to %d webhook(s)\n", stamp, d.Area, d.Alert.Severity, d.Alert.Event, len(d.Sent))
+		}
+		if d.SummaryError != nil {
+			fmt.Fprintf(a.stderr, "Warning: summarizing %s: %v\n", d.Alert.Event, d.SummaryError)
+		}
+		for _, err := range d.Errors {
+			fmt.Fprintf(a.stderr, "Warning: %s: %v\n", d.Alert.Event, err)
+		}
+	}
+	for _, err := range r.Errors {
+		fmt.Fprintf(a.stderr, "Warning: %v\n", err)
+	}
+}
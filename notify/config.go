@@ -0,0 +1,135 @@
+// Package notify watches NWS alerts for a list of places and zones and
+// sends each new alert once to webhooks: as JSON or a CloudEvent, signed
+// with HMAC-SHA256, retried on failure, and optionally with a summary in
+// plain language written by the model. Alerts already sent are remembered
+// in a state file, so a restart doesn't send them again.
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// FileName is the configuration file looked for in the configuration
+// directory.
+const FileName = "notify.json"
+
+// Config says what to watch and where to send it.
+type Config struct {
+	// Areas are place names ("Miami, FL") or NWS zone IDs ("FLZ072").
+	Areas []string `json:"areas"`
+	// MinSeverity drops alerts less severe than this: minor, moderate,
+	// severe or extreme. Empty sends every alert.
+	MinSeverity string    `json:"min_severity,omitempty"`
+	Webhooks    []Webhook `json:"webhooks"`
+	// Summarize adds a plain-language summary written by the model.
+	Summarize bool `json:"summarize,omitempty"`
+}
+
+// Webhook is an endpoint alerts are posted to.
+type Webhook struct {
+	URL string `json:"url"`
+	// Secret signs each request; SecretEnv names an environment variable
+	// holding it instead, to keep it out of the file. Neither means
+	// unsigned requests.
+	Secret    string `json:"secret,omitempty"`
+	SecretEnv string `json:"secret_env,omitempty"`
+	// CloudEvents wraps the payload in a CloudEvents 1.0 event in
+	// structured mode.
+	CloudEvents bool `json:"cloudevents,omitempty"`
+}
+
+// secret returns the signing key, if any.
+func (w Webhook) secret() string {
+	if w.SecretEnv != "" {
+		return os.Getenv(w.SecretEnv)
+	}
+	return w.Secret
+}
+
+// LoadConfig reads and checks the configuration at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading notifier config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("reading notifier config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("notifier config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks that there is something to watch and somewhere valid to
+// send it.
+func (c *Config) Validate() error {
+	if len(c.Areas) == 0 {
+		return errors.New("no areas to watch")
+	}
+	for _, area := range c.Areas {
+		if strings.TrimSpace(area) == "" {
+			return errors.New("empty area")
+		}
+	}
+	if len(c.Webhooks) == 0 {
+		return errors.New("no webhooks to notify")
+	}
+	for _, w := range c.Webhooks {
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook URL %q is not an http or https URL", w.URL)
+		}
+		if w.SecretEnv != "" && os.Getenv(w.SecretEnv) == "" {
+			return fmt.Errorf("webhook %s: %s is not set", w.URL, w.SecretEnv)
+		}
+	}
+	if _, err := ParseSeverity(c.MinSeverity); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Severity ranks NWS alert severities, Unknown lowest.
+type Severity int
+
+const (
+	Unknown Severity = iota
+	Minor
+	Moderate
+	Severe
+	Extreme
+)
+
+var severities = map[string]Severity{
+	"unknown":  Unknown,
+	"minor":    Minor,
+	"moderate": Moderate,
+	"severe":   Severe,
+	"extreme":  Extreme,
+}
+
+// ParseSeverity reads a severity name, ignoring case. Empty is Unknown,
+// which lets every alert through.
+func ParseSeverity(s string) (Severity, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Unknown, nil
+	}
+	if sev, ok := severities[s]; ok {
+		return sev, nil
+	}
+	return Unknown, fmt.Errorf("unknown severity %q (want minor, moderate, severe or extreme)", s)
+}
+
+// severityOf ranks an alert's severity; values NWS doesn't document count
+// as Unknown.
+func severityOf(s string) Severity {
+	return severities[strings.ToLower(s)]
+}
@@ -0,0 +1,257 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"learn-go/retry"
+	"learn-go/weather"
+)
+
+// Source looks up active alerts; *weather.NWSService is one.
+type Source interface {
+	GetAlerts(location string) ([]weather.Alert, error)
+	GetZoneAlerts(zone string) ([]weather.Alert, error)
+}
+
+// SummarizeFunc writes a plain-language summary of an alert.
+type SummarizeFunc func(alert weather.Alert) (string, error)
+
+// DefaultRetry is the retry policy for webhook posts: a receiver that is
+// briefly down gets a few tries before the alert waits for the next poll.
+var DefaultRetry = retry.Policy{
+	MaxAttempts: 4,
+	BaseDelay:   time.Second,
+	MaxDelay:    15 * time.Second,
+	Budget:      time.Minute,
+}
+
+// Notifier sends new alerts for the configured areas to the webhooks.
+type Notifier struct {
+	cfg       Config
+	source    Source
+	min       Severity
+	client    *http.Client
+	retry     retry.Policy
+	summarize SummarizeFunc
+	statePath string
+	now       func() time.Time
+
+	state *state
+	// summaries holds the summary of each alert not yet delivered to every
+	// webhook, so a failing webhook doesn't cost a model call per poll.
+	summaries map[string]string
+}
+
+// Option configures a Notifier.
+type Option func(*Notifier)
+
+// WithHTTPClient replaces the client webhooks are posted with.
+func WithHTTPClient(client *http.Client) Option {
+	return func(n *Notifier) {
+		n.client = client
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetry.
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(n *Notifier) {
+		n.retry = p
+	}
+}
+
+// WithSummarizer is used to summarize alerts when the config asks for
+// summaries.
+func WithSummarizer(fn SummarizeFunc) Option {
+	return func(n *Notifier) {
+		n.summarize = fn
+	}
+}
+
+// WithStateFile remembers sent alerts in path. Without it they are only
+// remembered until the process exits.
+func WithStateFile(path string) Option {
+	return func(n *Notifier) {
+		n.statePath = path
+	}
+}
+
+// WithClock replaces time.Now, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(n *Notifier) {
+		n.now = now
+	}
+}
+
+// New returns a notifier for cfg, which must be valid, reading the state
+// file if one is set.
+func New(cfg Config, source Source, opts ...Option) (*Notifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	n := &Notifier{
+		cfg:       cfg,
+		source:    source,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		retry:     DefaultRetry,
+		now:       time.Now,
+		summaries: make(map[string]string),
+	}
+	n.min, _ = ParseSeverity(cfg.MinSeverity)
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	var err error
+	if n.state, err = loadState(n.statePath); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Delivery is the outcome of sending one alert.
+type Delivery struct {
+	Area  string
+	Alert weather.Alert
+	// Sent lists the webhooks that got the alert this time, and Errors
+	// the failures of the rest, which are retried on the next poll, and
+	// of saving the state after a delivery.
+	Sent   []string
+	Errors []error
+	// SummaryError is set when a summary was wanted but the model failed;
+	// the alert is sent without one.
+	SummaryError error
+}
+
+// Report is the outcome of one poll.
+type Report struct {
+	Time       time.Time
+	Deliveries []Delivery
+	// Errors holds areas whose alerts couldn't be fetched, and failures to
+	// save the state.
+	Errors []error
+}
+
+// Failed reports whether anything went wrong.
+func (r *Report) Failed() bool {
+	if len(r.Errors) > 0 {
+		return true
+	}
+	for _, d := range r.Deliveries {
+		if len(d.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Poll fetches the alerts of every area once and sends those not yet
+// delivered to each webhook. An alert covering several areas is sent once,
+// for the first area listed.
+func (n *Notifier) Poll(ctx context.Context) Report {
+	report := Report{Time: n.now()}
+	seen := make(map[string]bool)
+	for _, area := range n.cfg.Areas {
+		alerts, err := n.fetch(area)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Errorf("%s: %w", area, err))
+			continue
+		}
+		for _, alert := range alerts {
+			if seen[alert.ID] || severityOf(alert.Severity) < n.min {
+				continue
+			}
+			seen[alert.ID] = true
+			if d, ok := n.send(ctx, area, alert); ok {
+				report.Deliveries = append(report.Deliveries, d)
+			}
+		}
+	}
+
+	for id := range n.summaries {
+		if !seen[id] {
+			delete(n.summaries, id)
+		}
+	}
+	n.state.prune(report.Time)
+	if err := n.state.save(); err != nil {
+		report.Errors = append(report.Errors, err)
+	}
+	return report
+}
+
+func (n *Notifier) fetch(area string) ([]weather.Alert, error) {
+	if zone := strings.ToUpper(strings.TrimSpace(area)); weather.IsZone(zone) {
+		return n.source.GetZoneAlerts(zone)
+	}
+	return n.source.GetAlerts(area)
+}
+
+// send delivers alert to the webhooks that haven't had it. It reports
+// false when there was nothing left to send.
+func (n *Notifier) send(ctx context.Context, area string, alert weather.Alert) (Delivery, bool) {
+	var pending []Webhook
+	for _, w := range n.cfg.Webhooks {
+		if !n.state.delivered(alert.ID, w.URL) {
+			pending = append(pending, w)
+		}
+	}
+	if len(pending) == 0 {
+		return Delivery{}, false
+	}
+
+	d := Delivery{Area: area, Alert: alert}
+	p := Payload{Area: area, Alert: alert, SentAt: n.now()}
+	if n.cfg.Summarize && n.summarize != nil {
+		p.Summary, d.SummaryError = n.summary(alert)
+	}
+	for _, w := range pending {
+		if err := deliver(ctx, n.client, n.retry, w, p); err != nil {
+			d.Errors = append(d.Errors, err)
+			continue
+		}
+		n.state.record(alert.ID, w.URL, p.SentAt)
+		d.Sent = append(d.Sent, w.URL)
+		// Save at once so a crash later in the poll doesn't send this
+		// alert to the webhook again.
+		if err := n.state.save(); err != nil {
+			d.Errors = append(d.Errors, err)
+		}
+	}
+	if len(d.Sent) == len(pending) {
+		delete(n.summaries, alert.ID)
+	}
+	return d, true
+}
+
+// summary returns the summary of alert, asking the model only the first
+// time. Failures aren't cached, so they are retried on the next poll.
+func (n *Notifier) summary(alert weather.Alert) (string, error) {
+	if s, ok := n.summaries[alert.ID]; ok {
+		return s, nil
+	}
+	s, err := n.summarize(alert)
+	if err != nil {
+		return "", err
+	}
+	n.summaries[alert.ID] = s
+	return s, nil
+}
+
+// Run polls now and then every interval until ctx is done, passing each
+// report to handle.
+func (n *Notifier) Run(ctx context.Context, every time.Duration, handle func(Report)) error {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		handle(n.Poll(ctx))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
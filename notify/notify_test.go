@@ -0,0 +1,309 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"learn-go/notify"
+	"learn-go/retry"
+	"learn-go/weather"
+)
+
+var now = time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
+
+var (
+	flood = weather.Alert{ID: "urn:flood", Event: "Flood Watch", Severity: "Severe"}
+	rip   = weather.Alert{ID: "urn:rip", Event: "Rip Current Statement", Severity: "Moderate"}
+	fog   = weather.Alert{ID: "urn:fog", Event: "Dense Fog Advisory", Severity: "Minor"}
+)
+
+// fakeSource serves alerts by place name or zone.
+type fakeSource map[string][]weather.Alert
+
+func (f fakeSource) GetAlerts(location string) ([]weather.Alert, error) {
+	alerts, ok := f[location]
+	if !ok {
+		return nil, weather.ErrLocationNotFound
+	}
+	return alerts, nil
+}
+
+func (f fakeSource) GetZoneAlerts(zone string) ([]weather.Alert, error) {
+	return f.GetAlerts("zone:" + zone)
+}
+
+// receiver records what a webhook is sent, failing the first fail
+// requests with 503.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	fail     int
+}
+
+func newReceiver(t *testing.T, fail int) *receiver {
+	r := &receiver{fail: fail}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.fail > 0 {
+			r.fail--
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+func newNotifier(t *testing.T, cfg notify.Config, src notify.Source, opts ...notify.Option) *notify.Notifier {
+	t.Helper()
+	opts = append([]notify.Option{
+		notify.WithClock(func() time.Time { return now }),
+		notify.WithRetryPolicy(retry.Policy{MaxAttempts: 3}),
+	}, opts...)
+	n, err := notify.New(cfg, src, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return n
+}
+
+func TestPollFiltersAndDedupes(t *testing.T) {
+	hook := newReceiver(t, 0)
+	src := fakeSource{
+		"Miami, FL":   {flood, rip, fog},
+		"zone:FLZ072": {flood},
+	}
+	cfg := notify.Config{
+		Areas:       []string{"Miami, FL", "flz072", "Atlantis"},
+		MinSeverity: "moderate",
+		Webhooks:    []notify.Webhook{{URL: hook.URL}},
+	}
+	n := newNotifier(t, cfg, src)
+
+	report := n.Poll(context.Background())
+	if len(report.Deliveries) != 2 || report.Deliveries[0].Alert.ID != "urn:flood" || report.Deliveries[1].Alert.ID != "urn:rip" {
+		t.Errorf("deliveries = %+v, want the flood watch and rip current statement once each", report.Deliveries)
+	}
+	if len(report.Errors) != 1 || !errors.Is(report.Errors[0], weather.ErrLocationNotFound) {
+		t.Errorf("errors = %v, want Atlantis not found", report.Errors)
+	}
+	if hook.received() != 2 {
+		t.Errorf("webhook got %d posts, want 2", hook.received())
+	}
+
+	var p notify.Payload
+	if err := json.Unmarshal(hook.bodies[0], &p); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if p.Area != "Miami, FL" || p.Alert.Event != "Flood Watch" || !p.SentAt.Equal(now) {
+		t.Errorf("payload = %+v", p)
+	}
+	if ct := hook.requests[0].Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if hook.requests[0].Header.Get(notify.SignatureHeader) != "" {
+		t.Error("request signed without a secret")
+	}
+
+	if report := n.Poll(context.Background()); len(report.Deliveries) != 0 || hook.received() != 2 {
+		t.Errorf("second poll sent %+v again", report.Deliveries)
+	}
+}
+
+func TestPollRemembersAcrossRestarts(t *testing.T) {
+	hook := newReceiver(t, 0)
+	path := filepath.Join(t.TempDir(), notify.StateFileName)
+	cfg := notify.Config{Areas: []string{"Miami, FL"}, Webhooks: []notify.Webhook{{URL: hook.URL}}}
+	src := fakeSource{"Miami, FL": {flood}}
+
+	newNotifier(t, cfg, src, notify.WithStateFile(path)).Poll(context.Background())
+	restarted := newNotifier(t, cfg, src, notify.WithStateFile(path))
+	if report := restarted.Poll(context.Background()); len(report.Deliveries) != 0 {
+		t.Errorf("restarted notifier sent %+v again", report.Deliveries)
+	}
+
+	src["Miami, FL"] = []weather.Alert{flood, rip}
+	if report := restarted.Poll(context.Background()); len(report.Deliveries) != 1 || report.Deliveries[0].Alert.ID != "urn:rip" {
+		t.Errorf("deliveries = %+v, want only the new alert", report.Deliveries)
+	}
+	if hook.received() != 2 {
+		t.Errorf("webhook got %d posts, want 2", hook.received())
+	}
+}
+
+func TestPollRetries(t *testing.T) {
+	flaky := newReceiver(t, 1)
+	down := newReceiver(t, 100)
+	cfg := notify.Config{
+		Areas:    []string{"Miami, FL"},
+		Webhooks: []notify.Webhook{{URL: flaky.URL}, {URL: down.URL}},
+	}
+	n := newNotifier(t, cfg, fakeSource{"Miami, FL": {flood}})
+
+	report := n.Poll(context.Background())
+	d := report.Deliveries[0]
+	if len(d.Sent) != 1 || d.Sent[0] != flaky.URL || len(d.Errors) != 1 || !report.Failed() {
+		t.Errorf("delivery = %+v, want the flaky webhook to succeed on retry and the other to fail", d)
+	}
+	if !strings.Contains(d.Errors[0].Error(), "503") {
+		t.Errorf("error = %v, want the status", d.Errors[0])
+	}
+
+	// Only the webhook that missed the alert gets it on the next poll.
+	down.mu.Lock()
+	down.fail = 0
+	down.mu.Unlock()
+	report = n.Poll(context.Background())
+	if len(report.Deliveries) != 1 || len(report.Deliveries[0].Sent) != 1 || report.Deliveries[0].Sent[0] != down.URL {
+		t.Errorf("deliveries = %+v, want the alert resent to the recovered webhook only", report.Deliveries)
+	}
+	if flaky.received() != 1 || down.received() != 1 {
+		t.Errorf("webhooks got %d and %d posts, want one each", flaky.received(), down.received())
+	}
+}
+
+func TestPollSavesAfterEachDelivery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), notify.StateFileName)
+	var saved []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := os.ReadFile(path)
+		saved = append(saved, string(data))
+	}))
+	defer hook.Close()
+	cfg := notify.Config{Areas: []string{"Miami, FL"}, Webhooks: []notify.Webhook{{URL: hook.URL}}}
+	n := newNotifier(t, cfg, fakeSource{"Miami, FL": {flood, rip}}, notify.WithStateFile(path))
+
+	n.Poll(context.Background())
+	if len(saved) != 2 || !strings.Contains(saved[1], "urn:flood") {
+		t.Errorf("state when sending the second alert = %q, want the first recorded", saved)
+	}
+}
+
+func TestSummaryCachedWhileWebhookDown(t *testing.T) {
+	down := newReceiver(t, 100)
+	cfg := notify.Config{Areas: []string{"Miami, FL"}, Webhooks: []notify.Webhook{{URL: down.URL}}, Summarize: true}
+	calls := 0
+	summarize := func(a weather.Alert) (string, error) {
+		calls++
+		return "Flooding is possible.", nil
+	}
+	n := newNotifier(t, cfg, fakeSource{"Miami, FL": {flood}}, notify.WithSummarizer(summarize),
+		notify.WithRetryPolicy(retry.Policy{MaxAttempts: 1}))
+
+	for i := 0; i < 3; i++ {
+		if report := n.Poll(context.Background()); !report.Failed() {
+			t.Fatalf("poll %d succeeded with the webhook down", i)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("summarizer called %d times, want once", calls)
+	}
+}
+
+func TestSignedCloudEvent(t *testing.T) {
+	hook := newReceiver(t, 0)
+	t.Setenv("HOOK_SECRET", "s3cret")
+	cfg := notify.Config{
+		Areas:     []string{"Miami, FL"},
+		Webhooks:  []notify.Webhook{{URL: hook.URL, SecretEnv: "HOOK_SECRET", CloudEvents: true}},
+		Summarize: true,
+	}
+	summarize := func(a weather.Alert) (string, error) { return "Flooding is possible.", nil }
+	n := newNotifier(t, cfg, fakeSource{"Miami, FL": {flood}}, notify.WithSummarizer(summarize))
+	n.Poll(context.Background())
+
+	req, body := hook.requests[0], hook.bodies[0]
+	if ct := req.Header.Get("Content-Type"); ct != "application/cloudevents+json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	ts, err := strconv.ParseInt(req.Header.Get(notify.TimestampHeader), 10, 64)
+	if err != nil || ts != now.Unix() {
+		t.Errorf("timestamp header = %q", req.Header.Get(notify.TimestampHeader))
+	}
+	if got, want := req.Header.Get(notify.SignatureHeader), notify.Sign("s3cret", ts, body); got != want || !strings.HasPrefix(got, "sha256=") {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+
+	var ev struct {
+		SpecVersion string         `json:"specversion"`
+		Type        string         `json:"type"`
+		ID          string         `json:"id"`
+		Subject     string         `json:"subject"`
+		Data        notify.Payload `json:"data"`
+	}
+	if err := json.Unmarshal(body, &ev); err != nil {
+		t.Fatalf("event: %v", err)
+	}
+	if ev.SpecVersion != "1.0" || ev.Type != notify.EventType || ev.ID != "urn:flood" || ev.Subject != "Miami, FL" {
+		t.Errorf("event = %+v", ev)
+	}
+	if ev.Data.Summary != "Flooding is possible." {
+		t.Errorf("summary = %q", ev.Data.Summary)
+	}
+}
+
+func TestSummaryFailureStillSends(t *testing.T) {
+	hook := newReceiver(t, 0)
+	cfg := notify.Config{Areas: []string{"Miami, FL"}, Webhooks: []notify.Webhook{{URL: hook.URL}}, Summarize: true}
+	summarize := func(a weather.Alert) (string, error) { return "", errors.New("model down") }
+	n := newNotifier(t, cfg, fakeSource{"Miami, FL": {flood}}, notify.WithSummarizer(summarize))
+
+	report := n.Poll(context.Background())
+	if d := report.Deliveries[0]; d.SummaryError == nil || len(d.Sent) != 1 {
+		t.Errorf("delivery = %+v, want sent without a summary", d)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(s string) string {
+		path := filepath.Join(dir, notify.FileName)
+		if err := os.WriteFile(path, []byte(s), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cfg, err := notify.LoadConfig(write(`{"areas": ["Miami, FL", "FLZ072"], "min_severity": "Severe", "webhooks": [{"url": "https://example.com/hook", "cloudevents": true}]}`))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.Areas) != 2 || !cfg.Webhooks[0].CloudEvents {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	for _, bad := range []string{
+		`{"webhooks": [{"url": "https://example.com/hook"}]}`,
+		`{"areas": ["Miami, FL"]}`,
+		`{"areas": ["Miami, FL"], "webhooks": [{"url": "ftp://example.com"}]}`,
+		`{"areas": ["Miami, FL"], "min_severity": "scary", "webhooks": [{"url": "https://example.com/hook"}]}`,
+		`{"areas": ["Miami, FL"], "webhooks": [{"url": "https://example.com/hook", "secret_env": "NOTIFY_TEST_UNSET"}]}`,
+	} {
+		if _, err := notify.LoadConfig(write(bad)); err == nil {
+			t.Errorf("LoadConfig(%s) succeeded", bad)
+		}
+	}
+}
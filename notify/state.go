@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// StateFileName is the file sent alerts are remembered in, in the same
+// directory as the configuration file.
+const StateFileName = "notify-state.json"
+
+// forgetAfter is how long a sent alert is remembered. NWS alerts rarely
+// last more than a few days; updates to one come with a new ID.
+const forgetAfter = 30 * 24 * time.Hour
+
+// state records which webhooks each alert has been delivered to, so an
+// alert is sent to each once even across restarts, and a webhook that was
+// down gets it on a later poll.
+type state struct {
+	path   string
+	Alerts map[string]*sent `json:"alerts"`
+}
+
+type sent struct {
+	FirstSeen time.Time `json:"first_seen"`
+	Webhooks  []string  `json:"webhooks"`
+}
+
+// loadState reads the state saved at path. A missing file, or an empty
+// path, is an empty state; the latter is never saved.
+func loadState(path string) (*state, error) {
+	s := &state{path: path, Alerts: map[string]*sent{}}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading notifier state: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("reading notifier state %s: %w", path, err)
+	}
+	if s.Alerts == nil {
+		s.Alerts = map[string]*sent{}
+	}
+	return s, nil
+}
+
+// delivered reports whether alert id has been sent to url.
+func (s *state) delivered(id, url string) bool {
+	a, ok := s.Alerts[id]
+	return ok && slices.Contains(a.Webhooks, url)
+}
+
+// record notes that alert id was sent to url.
+func (s *state) record(id, url string, now time.Time) {
+	a, ok := s.Alerts[id]
+	if !ok {
+		a = &sent{FirstSeen: now}
+		s.Alerts[id] = a
+	}
+	if !slices.Contains(a.Webhooks, url) {
+		a.Webhooks = append(a.Webhooks, url)
+	}
+}
+
+// prune forgets alerts first seen more than forgetAfter ago.
+func (s *state) prune(now time.Time) {
+	for id, a := range s.Alerts {
+		if now.Sub(a.FirstSeen) > forgetAfter {
+			delete(s.Alerts, id)
+		}
+	}
+}
+
+// save writes the state back, replacing the file in one step so an
+// interrupted write can't lose what was already sent.
+func (s *state) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("saving notifier state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("saving notifier state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".notify-state-*.json")
+	if err != nil {
+		return fmt.Errorf("saving notifier state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving notifier state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving notifier state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("saving notifier state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"learn-go/retry"
+	"learn-go/weather"
+)
+
+// Headers set on signed requests. The signature is "sha256=" and the hex
+// HMAC-SHA256, keyed with the webhook's secret, of the timestamp, a dot and
+// the body; receivers should reject old timestamps to stop replays.
+const (
+	SignatureHeader = "X-Weather-Signature"
+	TimestampHeader = "X-Weather-Timestamp"
+)
+
+// CloudEvents attributes of the events sent.
+const (
+	EventType   = "weather.alert.issued"
+	EventSource = "/weather/notify"
+)
+
+// Payload is the JSON posted for one alert.
+type Payload struct {
+	// Area is the configured place or zone the alert was found for.
+	Area  string        `json:"area"`
+	Alert weather.Alert `json:"alert"`
+	// Summary is the model's plain-language summary, when enabled and
+	// available.
+	Summary string    `json:"summary,omitempty"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// cloudEvent is a CloudEvents 1.0 event in structured JSON mode.
+type cloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	Type            string    `json:"type"`
+	Source          string    `json:"source"`
+	ID              string    `json:"id"`
+	Time            time.Time `json:"time"`
+	Subject         string    `json:"subject,omitempty"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            Payload   `json:"data"`
+}
+
+// Sign returns the signature header value for body sent at timestamp, in
+// Unix seconds.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// encode builds the request body and content type for w.
+func (w Webhook) encode(p Payload) ([]byte, string, error) {
+	var v interface{} = p
+	contentType := "application/json"
+	if w.CloudEvents {
+		v = cloudEvent{
+			SpecVersion:     "1.0",
+			Type:            EventType,
+			Source:          EventSource,
+			ID:              p.Alert.ID,
+			Time:            p.SentAt,
+			Subject:         p.Area,
+			DataContentType: "application/json",
+			Data:            p,
+		}
+		contentType = "application/cloudevents+json"
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, "", fmt.Errorf("encoding alert: %w", err)
+	}
+	return body, contentType, nil
+}
+
+// deliver posts p to w, retrying network failures, timeouts, 429 and 5xx
+// replies under policy.
+func deliver(ctx context.Context, client *http.Client, policy retry.Policy, w Webhook, p Payload) error {
+	body, contentType, err := w.encode(p)
+	if err != nil {
+		return err
+	}
+	timestamp := p.SentAt.Unix()
+	signature := ""
+	if secret := w.secret(); secret != "" {
+		signature = Sign(secret, timestamp, body)
+	}
+
+	err = policy.Do(ctx, func(ctx context.Context, attempt int) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		if signature != "" {
+			req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+			req.Header.Set(SignatureHeader, signature)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return retry.FromResponse(resp)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("posting to %s: %w", w.URL, err)
+	}
+	return nil
+}
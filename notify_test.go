@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"learn-go/notify"
+)
+
+func TestNotifyConfig(t *testing.T) {
+	a, _ := newTestApp(t)
+	if err := a.notify([]string{"-once"}); err == nil || !strings.Contains(err.Error(), "reading notifier config") {
+		t.Errorf("notify without a config gave %v", err)
+	}
+
+	path := filepath.Join(a.cfg.ConfigDir, notify.FileName)
+	if err := os.WriteFile(path, []byte(`{"areas": ["Miami, FL"], "webhooks": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.notify([]string{"-once"}); err == nil || !strings.Contains(err.Error(), "no webhooks") {
+		t.Errorf("notify with no webhooks gave %v", err)
+	}
+	if err := a.notify([]string{"-every", "10s"}); exitCode(err) != exitUsage {
+		t.Errorf("notify -every 10s gave %v, want a usage error", err)
+	}
+
+	a.cfg.ConfigDir = ""
+	if err := a.notify([]string{"-once"}); exitCode(err) != exitUsage {
+		t.Errorf("notify with no configuration directory or -config gave %v, want a usage error", err)
+	}
+}
+
+func TestNotifyStatePath(t *testing.T) {
+	got := notifyStatePath(filepath.Join("etc", "weather", "alerts.json"))
+	if want := filepath.Join("etc", "weather", notify.StateFileName); got != want {
+		t.Errorf("state path = %q, want %q next to the config", got, want)
+	}
+}
@@ -0,0 +1,228 @@
+package ollama
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Priority orders requests waiting for the model. Interactive requests are
+// always admitted before batch ones.
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota
+	PriorityBatch
+)
+
+func (p Priority) String() string {
+	if p == PriorityBatch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+// AdmissionSettings bounds the load put on Ollama.
+type AdmissionSettings struct {
+	// MaxInFlight is the number of generations allowed to run at once.
+	MaxInFlight int
+	// MaxQueue is the number of requests allowed to wait for a slot; more
+	// are rejected straight away.
+	MaxQueue int
+	// MaxWait is how long a request may wait for a slot before giving up.
+	MaxWait time.Duration
+}
+
+// DefaultAdmissionSettings suits a single CPU-bound Ollama instance.
+var DefaultAdmissionSettings = AdmissionSettings{
+	MaxInFlight: 2,
+	MaxQueue:    16,
+	MaxWait:     20 * time.Second,
+}
+
+// ErrOverloaded matches, via errors.Is, every request shed by an Admission.
+var ErrOverloaded = errors.New("ollama overloaded")
+
+// OverloadedError is returned when a request is shed because the queue is
+// full or it waited longer than MaxWait.
+type OverloadedError struct {
+	Reason string
+	// RetryAfter estimates when a slot is likely to be free.
+	RetryAfter time.Duration
+}
+
+func (e *OverloadedError) Error() string {
+	return fmt.Sprintf("ollama overloaded: %s, retry in %v", e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+func (e *OverloadedError) Is(target error) bool {
+	return target == ErrOverloaded
+}
+
+// AdmissionStats is a snapshot of an Admission for metrics.
+type AdmissionStats struct {
+	InFlight int    `json:"in_flight"`
+	Queued   int    `json:"queued"`
+	Admitted uint64 `json:"admitted"`
+	Shed     uint64 `json:"shed"`
+}
+
+// Admission limits concurrent model calls, queueing the excess by priority
+// and shedding what doesn't fit. One Admission may be shared by several
+// clients talking to the same Ollama instance.
+type Admission struct {
+	settings AdmissionSettings
+
+	mu       sync.Mutex
+	inFlight int
+	queue    waitQueue
+	seq      uint64
+	admitted uint64
+	shed     uint64
+	// avgHold is a moving average of how long a slot is held, used to
+	// estimate Retry-After.
+	avgHold time.Duration
+}
+
+// NewAdmission returns an admission controller. A zero MaxInFlight or
+// MaxWait takes its value from DefaultAdmissionSettings; a zero MaxQueue
+// turns away anything that can't run straight away.
+func NewAdmission(settings AdmissionSettings) *Admission {
+	if settings.MaxInFlight <= 0 {
+		settings.MaxInFlight = DefaultAdmissionSettings.MaxInFlight
+	}
+	if settings.MaxQueue < 0 {
+		settings.MaxQueue = 0
+	}
+	if settings.MaxWait <= 0 {
+		settings.MaxWait = DefaultAdmissionSettings.MaxWait
+	}
+	return &Admission{settings: settings, avgHold: 5 * time.Second}
+}
+
+type waiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// waitQueue is a heap ordered by priority, then arrival.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority < q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	*q = old[:len(old)-1]
+	w.index = -1
+	return w
+}
+
+// Acquire waits for a slot and returns the function that gives it back.
+// It fails with an *OverloadedError when the queue is full or the wait
+// exceeds MaxWait, and with ctx's error if ctx ends first.
+func (a *Admission) Acquire(ctx context.Context, priority Priority) (release func(), err error) {
+	a.mu.Lock()
+	if a.inFlight < a.settings.MaxInFlight && a.queue.Len() == 0 {
+		a.inFlight++
+		a.admitted++
+		a.mu.Unlock()
+		return a.releaser(), nil
+	}
+	if a.queue.Len() >= a.settings.MaxQueue {
+		err := a.overloaded("queue full")
+		a.mu.Unlock()
+		return nil, err
+	}
+	a.seq++
+	w := &waiter{priority: priority, seq: a.seq, ready: make(chan struct{})}
+	heap.Push(&a.queue, w)
+	a.mu.Unlock()
+
+	timer := time.NewTimer(a.settings.MaxWait)
+	defer timer.Stop()
+
+	select {
+	case <-w.ready:
+		return a.releaser(), nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if w.index < 0 {
+		// The slot was handed over just as we gave up; take it.
+		return a.releaser(), nil
+	}
+	heap.Remove(&a.queue, w.index)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, a.overloaded("timed out waiting for a slot")
+}
+
+// overloaded records a shed request. a.mu must be held.
+func (a *Admission) overloaded(reason string) error {
+	a.shed++
+	waves := (a.queue.Len() + a.settings.MaxInFlight) / a.settings.MaxInFlight
+	return &OverloadedError{Reason: reason, RetryAfter: time.Duration(waves) * a.avgHold}
+}
+
+func (a *Admission) releaser() func() {
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			a.avgHold = (4*a.avgHold + time.Since(start)) / 5
+			a.next()
+		})
+	}
+}
+
+// next frees a slot and hands it to the first waiter, if any. a.mu must be
+// held.
+func (a *Admission) next() {
+	a.inFlight--
+	if a.queue.Len() == 0 {
+		return
+	}
+	w := heap.Pop(&a.queue).(*waiter)
+	a.inFlight++
+	a.admitted++
+	close(w.ready)
+}
+
+// Stats returns a snapshot of the controller.
+func (a *Admission) Stats() AdmissionStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return AdmissionStats{
+		InFlight: a.inFlight,
+		Queued:   a.queue.Len(),
+		Admitted: a.admitted,
+		Shed:     a.shed,
+	}
+}
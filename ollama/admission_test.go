@@ -0,0 +1,111 @@
+package ollama_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"learn-go/ollama"
+)
+
+func TestAdmissionShedsWhenQueueFull(t *testing.T) {
+	a := ollama.NewAdmission(ollama.AdmissionSettings{MaxInFlight: 1, MaxQueue: 0, MaxWait: time.Second})
+
+	release, err := a.Acquire(context.Background(), ollama.PriorityInteractive)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	_, err = a.Acquire(context.Background(), ollama.PriorityInteractive)
+	var overloaded *ollama.OverloadedError
+	if !errors.Is(err, ollama.ErrOverloaded) || !errors.As(err, &overloaded) {
+		t.Fatalf("error = %v, want OverloadedError", err)
+	}
+	if overloaded.Reason != "queue full" || overloaded.RetryAfter <= 0 {
+		t.Errorf("error = %+v, want queue full with a Retry-After", overloaded)
+	}
+
+	release()
+	release() // releasing twice is harmless
+	if _, err := a.Acquire(context.Background(), ollama.PriorityInteractive); err != nil {
+		t.Errorf("Acquire after release: %v", err)
+	}
+
+	if got := a.Stats(); got.InFlight != 1 || got.Admitted != 2 || got.Shed != 1 {
+		t.Errorf("stats = %+v, want 1 in flight, 2 admitted, 1 shed", got)
+	}
+}
+
+func TestAdmissionTimesOut(t *testing.T) {
+	a := ollama.NewAdmission(ollama.AdmissionSettings{MaxInFlight: 1, MaxQueue: 1, MaxWait: 20 * time.Millisecond})
+	if _, err := a.Acquire(context.Background(), ollama.PriorityInteractive); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	_, err := a.Acquire(context.Background(), ollama.PriorityInteractive)
+	var overloaded *ollama.OverloadedError
+	if !errors.As(err, &overloaded) || overloaded.Reason != "timed out waiting for a slot" {
+		t.Fatalf("error = %v, want a queue timeout", err)
+	}
+	if got := a.Stats().Queued; got != 0 {
+		t.Errorf("%d still queued after timeout", got)
+	}
+}
+
+func TestAdmissionCancel(t *testing.T) {
+	a := ollama.NewAdmission(ollama.AdmissionSettings{MaxInFlight: 1, MaxQueue: 1, MaxWait: time.Minute})
+	if _, err := a.Acquire(context.Background(), ollama.PriorityInteractive); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := a.Acquire(ctx, ollama.PriorityInteractive); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
+
+func TestAdmissionPrefersInteractive(t *testing.T) {
+	a := ollama.NewAdmission(ollama.AdmissionSettings{MaxInFlight: 1, MaxQueue: 4, MaxWait: time.Second})
+	release, err := a.Acquire(context.Background(), ollama.PriorityInteractive)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	enqueue := func(name string, p ollama.Priority, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := a.Acquire(context.Background(), p)
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				return
+			}
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			release()
+		}()
+		for a.Stats().Queued < queued {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	enqueue("batch1", ollama.PriorityBatch, 1)
+	enqueue("batch2", ollama.PriorityBatch, 2)
+	enqueue("interactive", ollama.PriorityInteractive, 3)
+	release()
+	wg.Wait()
+
+	want := []string{"interactive", "batch1", "batch2"}
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("admission order = %v, want %v", order, want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package ollama
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Answer is a direct reply to the user's question, grounded in the data
+// that was fetched for it.
+type Answer struct {
+	// Short is the answer itself: "Yes", "No" or the value asked for.
+	Short    string     `json:"short"`
+	Evidence []Evidence `json:"evidence"`
+}
+
+// Evidence is a data field the model cited, with the value it has in the
+// data sent to the model.
+type Evidence struct {
+	Field string      `json:"field"`
+	Value interface{} `json:"value"`
+}
+
+var answerSchema = Format(`{
+  "type": "object",
+  "properties": {
+    "answer": {"type": "string"},
+    "explanation": {"type": "string"},
+    "fields": {"type": "array", "items": {"type": "string"}, "minItems": 1}
+  },
+  "required": ["answer", "explanation", "fields"]
+}`)
+
+const answerPrompt = `You are a weather assistant answering a specific question from the data provided. Reply with a JSON object:
+- answer: the direct answer in a few words, "Yes", "No" or the value asked for (e.g. "72°F", "15 mph").
+- explanation: one or two sentences supporting the answer, using only the data.
+- fields: the path of every data field you relied on, e.g. "conditions", "humidity" or "periods.1.precipitation_chance".
+Never state a value that is not in the data.`
+
+type answerReply struct {
+	Answer      string   `json:"answer"`
+	Explanation string   `json:"explanation"`
+	Fields      []string `json:"fields"`
+}
+
+// answer fills in resp.Answer and resp.Description by asking the model to
+// answer query from the data already in resp. Citations are checked against
+// that data, and replies citing fields that don't exist are re-prompted.
+// Like describe, it falls back to the template description on failure.
+func (c *Client) answer(resp *WeatherResponse, query string, maxRetries int) (*WeatherResponse, error) {
+	if !c.ai {
+		return degrade(resp, nil), nil
+	}
+
+	data, err := json.Marshal(resp.promptData())
+	if err != nil {
+		return nil, fmt.Errorf("marshaling weather data: %w", err)
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("marshaling weather data: %w", err)
+	}
+
+	req := OllamaRequest{
+		Model:  c.model,
+		Stream: false,
+		Format: answerSchema,
+		Options: &Options{
+			Temperature: 0.2,
+			Seed:        42,
+		},
+		Messages: []ChatMessage{
+			{Role: "system", Content: answerPrompt},
+			{Role: "user", Content: fmt.Sprintf("Question: %s\nWeather data for %s: %s", strings.TrimSpace(query), resp.Location, data)},
+		},
+	}
+
+	err = c.getStructuredResponse(req, maxRetries, func(content string) error {
+		var reply answerReply
+		if err := json.Unmarshal([]byte(content), &reply); err != nil {
+			return fmt.Errorf("not a JSON object: %w", err)
+		}
+		answer, err := reply.ground(tree)
+		if err != nil {
+			return err
+		}
+		resp.Answer = answer
+		resp.Description = strings.TrimSpace(reply.Explanation)
+		return nil
+	})
+	if errors.Is(err, ErrOverloaded) {
+		return nil, err
+	}
+	if err != nil {
+		return degrade(resp, fmt.Errorf("getting AI answer: %w", err)), nil
+	}
+
+	resp.Describer = DescriberAI
+	return resp, nil
+}
+
+// ground resolves every cited field against the data.
+func (r *answerReply) ground(data interface{}) (*Answer, error) {
+	short := strings.TrimSpace(r.Answer)
+	if short == "" {
+		return nil, errors.New("answer is empty")
+	}
+	if len(r.Fields) == 0 {
+		return nil, errors.New("no fields cited")
+	}
+
+	answer := &Answer{Short: short}
+	for _, field := range r.Fields {
+		value, err := lookupField(data, field)
+		if err != nil {
+			return nil, err
+		}
+		answer.Evidence = append(answer.Evidence, Evidence{Field: field, Value: value})
+	}
+	return answer, nil
+}
+
+// lookupField follows a dotted path such as "periods.1.temperature" (or
+// "periods[1].temperature") through decoded JSON.
+func lookupField(data interface{}, path string) (interface{}, error) {
+	normalized := strings.NewReplacer("[", ".", "]", "").Replace(strings.TrimSpace(path))
+	if normalized == "" {
+		return nil, errors.New("empty field path cited")
+	}
+
+	cur := data
+	for _, part := range strings.Split(normalized, ".") {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			next, ok := node[part]
+			if !ok {
+				return nil, fmt.Errorf("cited field %q is not in the data", path)
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("cited field %q is not in the data", path)
+			}
+			cur = node[i]
+		default:
+			return nil, fmt.Errorf("cited field %q is not in the data", path)
+		}
+	}
+	return cur, nil
+}
@@ -0,0 +1,95 @@
+package ollama_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+)
+
+func directQuestion() ollamatest.Response {
+	e := map[string]interface{}{}
+	for k, v := range miami {
+		e[k] = v
+	}
+	e["direct_question"] = true
+	return ollamatest.JSONReply(e)
+}
+
+func TestGetWeatherAnswersDirectQuestion(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(directQuestion(), answer("conditions", "humidity"))
+
+	got, err := client.GetWeather("Is it raining in Miami right now?", 0)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Answer == nil || got.Answer.Short != "No" {
+		t.Fatalf("Answer = %+v, want No", got.Answer)
+	}
+	if got.Description != "The data shows no rain." {
+		t.Errorf("Description = %q, want the explanation", got.Description)
+	}
+
+	want := []ollama.Evidence{{Field: "conditions", Value: "Partly Cloudy"}, {Field: "humidity", Value: float64(70)}}
+	if len(got.Answer.Evidence) != len(want) {
+		t.Fatalf("Evidence = %+v, want %+v", got.Answer.Evidence, want)
+	}
+	for i, e := range got.Answer.Evidence {
+		if e != want[i] {
+			t.Errorf("Evidence[%d] = %+v, want %+v", i, e, want[i])
+		}
+	}
+
+	req := ai.ChatRequests()[1]
+	if !strings.HasPrefix(req.Messages[1].Content, "Question: Is it raining in Miami right now?\n") {
+		t.Errorf("prompt = %q, want the original question first", req.Messages[1].Content)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(req.Format, &schema); err != nil || schema["required"] == nil {
+		t.Errorf("format = %s, want answer schema", req.Format)
+	}
+}
+
+func TestGetWeatherAnswerRejectsUnknownFields(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(directQuestion(), answer("rain_inches"), answer("periods[0].temperature"), answer("conditions"))
+
+	got, err := client.GetWeather("Is it raining in Miami?", 0)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Answer.Evidence[0].Field != "conditions" {
+		t.Errorf("Evidence = %+v, want the third reply's citation", got.Answer.Evidence)
+	}
+
+	reqs := ai.ChatRequests()
+	if len(reqs) != 4 {
+		t.Fatalf("sent %d requests, want extraction plus three answers", len(reqs))
+	}
+	msgs := reqs[2].Messages
+	if feedback := msgs[len(msgs)-1].Content; !strings.Contains(feedback, `cited field "rain_inches" is not in the data`) {
+		t.Errorf("re-prompt = %q", feedback)
+	}
+}
+
+func TestGetWeatherAnswerForecastPath(t *testing.T) {
+	client, ai, _ := newClient(t)
+	e := map[string]interface{}{}
+	for k, v := range miami {
+		e[k] = v
+	}
+	e["intent"] = "daily"
+	e["direct_question"] = true
+	ai.Chat(ollamatest.JSONReply(e), answer("periods[2].precipitation_chance"))
+
+	got, err := client.GetWeather("What's the chance of rain in Miami on Thursday?", 0)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if ev := got.Answer.Evidence[0]; ev.Value != float64(60) {
+		t.Errorf("Evidence = %+v, want Thursday's 60%%", ev)
+	}
+}
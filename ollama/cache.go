@@ -0,0 +1,161 @@
+package ollama
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// observationInterval is how often NWS publishes new observations and
+	// forecasts. A description is reused until the data it was written for
+	// is due to be replaced.
+	observationInterval = time.Hour
+	// defaultCacheTTL applies when the data carries no usable timestamp,
+	// such as an alert list.
+	defaultCacheTTL = 5 * time.Minute
+)
+
+// CacheStats is a snapshot of the description cache for metrics.
+type CacheStats struct {
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
+type cachedDescription struct {
+	description  string
+	verification *Verification
+}
+
+type cacheEntry struct {
+	key     string
+	value   cachedDescription
+	expires time.Time
+}
+
+// descriptionCache is a size-bounded LRU of model descriptions. A nil
+// cache stores nothing.
+type descriptionCache struct {
+	size   int
+	maxTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+	hits    uint64
+	misses  uint64
+}
+
+func newDescriptionCache(size int, maxTTL time.Duration) *descriptionCache {
+	if size <= 0 {
+		return nil
+	}
+	return &descriptionCache{
+		size:    size,
+		maxTTL:  maxTTL,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *descriptionCache) get(key string, now time.Time) (cachedDescription, bool) {
+	if c == nil {
+		return cachedDescription{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if ok && now.Before(el.Value.(*cacheEntry).expires) {
+		c.order.MoveToFront(el)
+		c.hits++
+		return el.Value.(*cacheEntry).value, true
+	}
+	if ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+	c.misses++
+	return cachedDescription{}, false
+}
+
+func (c *descriptionCache) put(key string, value cachedDescription, expires, now time.Time) {
+	if c == nil || !expires.After(now) {
+		return
+	}
+	if limit := now.Add(c.maxTTL); c.maxTTL > 0 && expires.After(limit) {
+		expires = limit
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value = &cacheEntry{key: key, value: value, expires: expires}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *descriptionCache) stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Entries: c.order.Len(), Hits: c.hits, Misses: c.misses}
+}
+
+// descriptionKey identifies a description request: the model, its options,
+// the prompt wording and the weather data all feed into the hash.
+func descriptionKey(req OllamaRequest) string {
+	data, _ := json.Marshal(req)
+	h := sha256.New()
+	h.Write([]byte(strconv.Itoa(promptVersion) + "\n"))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheExpiry is when a description of resp goes stale: when the next
+// observation or forecast update is due, or after defaultCacheTTL for data
+// without a timestamp.
+func cacheExpiry(resp *WeatherResponse, now time.Time) time.Time {
+	var stamps []string
+	switch {
+	case resp.Forecast != nil:
+		stamps = append(stamps, resp.Forecast.UpdatedAt)
+	case resp.Comparison != nil:
+		for _, p := range resp.Comparison {
+			stamps = append(stamps, p.Weather.Timestamp)
+		}
+	case resp.Weather != nil:
+		stamps = append(stamps, resp.Weather.Timestamp)
+	}
+	if len(stamps) == 0 {
+		return now.Add(defaultCacheTTL)
+	}
+
+	// The oldest piece of data decides, since it is replaced first.
+	var oldest time.Time
+	for _, s := range stamps {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return now.Add(defaultCacheTTL)
+		}
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+	return oldest.Add(observationInterval)
+}
@@ -0,0 +1,107 @@
+package ollama_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+)
+
+// observed is the timestamp of weathertest.ObservationJSON.
+var observed = time.Date(2025, 1, 8, 15, 53, 0, 0, time.UTC)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func TestDescriptionCache(t *testing.T) {
+	clock := &fakeClock{t: observed.Add(10 * time.Minute)}
+	client, ai, _ := newClient(t, ollama.WithClock(clock.now))
+	ai.Chat(
+		ollamatest.Reply("Partly cloudy and 77°F in Miami."),
+		ollamatest.Reply("Still partly cloudy and 77°F in Miami."),
+	)
+
+	first, err := client.GetWeatherData("Miami, FL", 0)
+	if err != nil {
+		t.Fatalf("GetWeatherData: %v", err)
+	}
+	if first.Cached {
+		t.Error("first description marked cached")
+	}
+
+	clock.t = observed.Add(50 * time.Minute)
+	second, err := client.GetWeatherData("Miami, FL", 0)
+	if err != nil {
+		t.Fatalf("GetWeatherData: %v", err)
+	}
+	if !second.Cached || second.Description != first.Description || second.Describer != ollama.DescriberAI {
+		t.Errorf("second = cached %v %q by %q, want the first description from the cache", second.Cached, second.Description, second.Describer)
+	}
+	if second.Verification == nil || second.Verification.Status != ollama.VerificationPassed {
+		t.Errorf("Verification = %+v, want the cached result", second.Verification)
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 1 {
+		t.Errorf("model called %d times, want 1", n)
+	}
+
+	// A new observation is due an hour after the last one.
+	clock.t = observed.Add(61 * time.Minute)
+	third, err := client.GetWeatherData("Miami, FL", 0)
+	if err != nil {
+		t.Fatalf("GetWeatherData: %v", err)
+	}
+	if third.Cached || third.Description != "Still partly cloudy and 77°F in Miami." {
+		t.Errorf("third = cached %v %q, want a fresh description", third.Cached, third.Description)
+	}
+
+	// The fake station still reports the old observation, which is already
+	// overdue, so the fresh description isn't stored.
+	if got := client.CacheStats(); got.Hits != 1 || got.Misses != 2 || got.Entries != 0 {
+		t.Errorf("stats = %+v, want 1 hit, 2 misses, no entries", got)
+	}
+}
+
+func TestDescriptionCacheKeys(t *testing.T) {
+	clock := &fakeClock{t: observed.Add(10 * time.Minute)}
+	client, ai, _ := newClient(t, ollama.WithClock(clock.now))
+	ai.Chat(ollamatest.Reply("Partly cloudy and 77°F."))
+
+	for _, loc := range []string{"Miami, FL", "Miami Beach, FL", "Miami, FL"} {
+		if _, err := client.GetWeatherData(loc, 0); err != nil {
+			t.Fatalf("GetWeatherData(%s): %v", loc, err)
+		}
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 2 {
+		t.Errorf("model called %d times, want one per distinct location", n)
+	}
+}
+
+func TestDescriptionCacheSkipsTemplates(t *testing.T) {
+	clock := &fakeClock{t: observed.Add(10 * time.Minute)}
+	client, ai, _ := newClient(t, ollama.WithClock(clock.now))
+	ai.Chat(ollamatest.Status(http.StatusNotFound, "no such model"), ollamatest.Reply("Partly cloudy and 77°F."))
+
+	first, _ := client.GetWeatherData("Miami, FL", 0)
+	second, _ := client.GetWeatherData("Miami, FL", 0)
+	if first.Describer != ollama.DescriberTemplate || second.Cached || second.Describer != ollama.DescriberAI {
+		t.Errorf("got %q then cached=%v %q, want the model asked again after a template", first.Describer, second.Cached, second.Describer)
+	}
+}
+
+func TestDescriptionCacheDisabled(t *testing.T) {
+	clock := &fakeClock{t: observed.Add(10 * time.Minute)}
+	client, ai, _ := newClient(t, ollama.WithClock(clock.now), ollama.WithDescriptionCache(0, 0))
+	ai.Chat(ollamatest.Reply("Partly cloudy and 77°F."))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetWeatherData("Miami, FL", 0); err != nil {
+			t.Fatalf("GetWeatherData: %v", err)
+		}
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 2 {
+		t.Errorf("model called %d times, want 2 with the cache off", n)
+	}
+}
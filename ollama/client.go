@@ -2,39 +2,248 @@ package ollama
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
+	"learn-go/breaker"
+	"learn-go/internal/flight"
+	"learn-go/retry"
 	"learn-go/weather"
 )
 
+const (
+	defaultBaseURL = "http://localhost:11434/api"
+	defaultModel   = "phi4"
+)
+
 type Client struct {
 	httpClient *http.Client
 	weatherSvc weather.Service
 	baseURL    string
+	model      string
+	retry      retry.Policy
+	breakers   breaker.Settings
+	breaker    *breaker.Breaker
+	admission  *Admission
+	priority   Priority
+	cache      *descriptionCache
+	semantic   *SemanticCache
+	embedModel string
+	// inflight shares one model call between concurrent identical prompts.
+	inflight flight.Group[string]
+	now      func() time.Time
+	guard    bool
+	ai       bool
+	strategy ExtractStrategy
+	places   []savedPlace
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the client at an alternative Ollama API root (e.g. "http://host:11434/api").
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithModel selects the Ollama model used for every request.
+func WithModel(model string) Option {
+	return func(c *Client) {
+		c.model = model
+	}
+}
+
+// WithHTTPClient replaces the HTTP client used to talk to Ollama.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithTransport keeps the default timeout but routes requests through rt,
+// e.g. a cassette.Recorder.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		client := *c.httpClient
+		client.Transport = rt
+		c.httpClient = &client
+	}
+}
+
+// WithRetryDelay sets the base delay of the exponential backoff between attempts.
+func WithRetryDelay(d time.Duration) Option {
+	return func(c *Client) {
+		c.retry.BaseDelay = d
+	}
+}
+
+// WithRetryPolicy replaces the backoff and time budget used for calls to
+// Ollama. The number of attempts still comes from each call's maxRetries.
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
+// WithClock replaces the clock used to resolve relative times like
+// "tonight" or "this weekend".
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// WithGuard turns checking descriptions against the weather data on or off.
+// It is on by default.
+func WithGuard(enabled bool) Option {
+	return func(c *Client) {
+		c.guard = enabled
+	}
+}
+
+// WithAI turns the model on or off. With it off, locations are found by
+// the rules alone and every description comes from a template. It is on by
+// default.
+func WithAI(enabled bool) Option {
+	return func(c *Client) {
+		c.ai = enabled
+	}
 }
 
-func NewClient(weatherSvc weather.Service) *Client {
-	return &Client{
+// WithExtractStrategy chooses between rule-based and model-based location
+// extraction. The default is ExtractAuto.
+func WithExtractStrategy(strategy ExtractStrategy) Option {
+	return func(c *Client) {
+		c.strategy = strategy
+	}
+}
+
+// WithBreakerSettings configures the circuit breaker guarding Ollama. While
+// it is open, descriptions come from templates without waiting on the model.
+func WithBreakerSettings(settings breaker.Settings) Option {
+	return func(c *Client) {
+		c.breakers = settings
+	}
+}
+
+// WithAdmission shares an admission controller between clients that talk
+// to the same Ollama instance. By default each client has its own, with
+// DefaultAdmissionSettings.
+func WithAdmission(a *Admission) Option {
+	return func(c *Client) {
+		c.admission = a
+	}
+}
+
+// WithPriority sets the queue priority of the client's model calls. The
+// default is PriorityInteractive.
+func WithPriority(p Priority) Option {
+	return func(c *Client) {
+		c.priority = p
+	}
+}
+
+// WithDescriptionCache keeps up to size model descriptions, each reused
+// until its weather data is due to be replaced and for at most maxTTL. A
+// size of zero turns the cache off. The default is 256 entries for an hour.
+func WithDescriptionCache(size int, maxTTL time.Duration) Option {
+	return func(c *Client) {
+		c.cache = newDescriptionCache(size, maxTTL)
+	}
+}
+
+// WithSemanticCache reuses the location of earlier queries that embed
+// close to a new one, skipping extraction by the model.
+func WithSemanticCache(cache *SemanticCache) Option {
+	return func(c *Client) {
+		c.semantic = cache
+	}
+}
+
+// WithEmbedModel selects the model used to embed queries. The default is
+// DefaultEmbedModel.
+func WithEmbedModel(model string) Option {
+	return func(c *Client) {
+		c.embedModel = model
+	}
+}
+
+func NewClient(weatherSvc weather.Service, opts ...Option) *Client {
+	c := &Client{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 		weatherSvc: weatherSvc,
-		baseURL:    "http://localhost:11434/api",
+		baseURL:    defaultBaseURL,
+		model:      defaultModel,
+		retry: retry.Policy{
+			BaseDelay: time.Second,
+			MaxDelay:  10 * time.Second,
+			Budget:    2 * time.Minute,
+		},
+		now:        time.Now,
+		guard:      true,
+		ai:         true,
+		strategy:   ExtractAuto,
+		breakers:   breaker.DefaultSettings,
+		cache:      newDescriptionCache(256, time.Hour),
+		embedModel: DefaultEmbedModel,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	settings := c.breakers
+	if settings.IsFailure == nil {
+		settings.IsFailure = retry.IsRetryable
 	}
+	c.breaker = breaker.New("ollama", settings)
+	if c.admission == nil {
+		c.admission = NewAdmission(DefaultAdmissionSettings)
+	}
+	return c
+}
+
+// CacheStats reports on the description cache.
+func (c *Client) CacheStats() CacheStats {
+	return c.cache.stats()
+}
+
+// Admission returns the controller limiting the client's model calls.
+func (c *Client) Admission() *Admission {
+	return c.admission
+}
+
+// Breakers returns the circuit breaker guarding Ollama.
+func (c *Client) Breakers() []*breaker.Breaker {
+	return []*breaker.Breaker{c.breaker}
 }
 
 type OllamaRequest struct {
 	Model    string        `json:"model"`
 	Messages []ChatMessage `json:"messages"`
 	Stream   bool          `json:"stream"`
+	Format   Format        `json:"format,omitempty"`
 	Options  *Options      `json:"options,omitempty"`
 }
 
+// Format constrains the model's output. It is either the string "json" or a
+// JSON schema object, passed through to Ollama verbatim.
+type Format = json.RawMessage
+
+// FormatJSON asks Ollama for any well-formed JSON object.
+var FormatJSON = Format(`"json"`)
+
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
@@ -43,10 +252,37 @@ type ChatMessage struct {
 type WeatherResponse struct {
 	Weather     *weather.WeatherData `json:"weather"`
 	Description string               `json:"description"`
+	Intent      Intent               `json:"intent,omitempty"`
+	Location    string               `json:"location,omitempty"`
+	Window      *TimeWindow          `json:"window,omitempty"`
+	Forecast    *weather.Forecast    `json:"forecast,omitempty"`
+	Alerts      []weather.Alert      `json:"alerts,omitempty"`
+	Comparison  []PlaceWeather       `json:"comparison,omitempty"`
+	// Answer is set when the query asked a direct question; Description
+	// then holds the supporting explanation.
+	Answer *Answer `json:"answer,omitempty"`
+	// Verification is set when the description was checked against the
+	// data.
+	Verification *Verification `json:"verification,omitempty"`
+	// Describer is DescriberAI or DescriberTemplate. DescriberError explains
+	// why the template was used when the model failed.
+	Describer      string `json:"describer"`
+	DescriberError string `json:"describer_error,omitempty"`
+	// Cached is set when the description was reused from an earlier query
+	// about the same data.
+	Cached bool `json:"cached,omitempty"`
+}
+
+// PlaceWeather is the current weather at one place of a comparison.
+type PlaceWeather struct {
+	Location string               `json:"location"`
+	Weather  *weather.WeatherData `json:"weather"`
 }
 
 type Options struct {
-	Temperature float64 `json:"temperature,omitempty"`
+	// Temperature is always sent: zero is a valid setting, not a request
+	// for Ollama's default.
+	Temperature float64 `json:"temperature"`
 	Seed        int     `json:"seed,omitempty"`
 }
 
@@ -58,67 +294,153 @@ type OllamaResponse struct {
 	TotalDuration int64       `json:"total_duration"`
 }
 
-// Add method to extract location from natural query
+// ExtractLocation returns the place named in a natural language query as
+// "City, State" or "City, Country".
 func (c *Client) ExtractLocation(query string) (string, error) {
-	query = strings.TrimSpace(query)
-
-	req := OllamaRequest{
-		Model:  "phi4",
-		Stream: false,
-		Options: &Options{
-			Temperature: 0.1,
-			Seed:        42,
-		},
-		Messages: []ChatMessage{
-			{
-				Role: "system",
-				Content: `You are a location extractor. Extract only the city and state/country from the query. 
-                         Format: "City, State" or "City, Country". If no location is found, say "no location".`,
-			},
-			{
-				Role:    "user",
-				Content: query,
-			},
-		},
+	extraction, err := c.extract(query)
+	if err != nil {
+		return "", err
 	}
+	return extraction.Location(), nil
+}
 
-	location, err := c.getAIResponse(req, 3)
+// GetWeather answers a natural language query. The query is classified
+// first and then routed to current conditions, a forecast, alerts or a
+// comparison, each with its own prompt.
+func (c *Client) GetWeather(query string, maxRetries int) (*WeatherResponse, error) {
+	extraction, err := c.extract(query)
 	if err != nil {
-		return "", fmt.Errorf("extracting location: %w", err)
+		return nil, err
+	}
+
+	now := c.now()
+	window := parseTimeWindow(extraction.TimeReference, now)
+	intent := route(extraction.Intent, window, now)
+	location := extraction.Location()
+
+	resp := &WeatherResponse{
+		Intent:   intent,
+		Location: location,
+	}
+	if !window.IsNow(now) {
+		resp.Window = &window
 	}
 
-	if location == "no location" {
-		return "", fmt.Errorf("no location found in query")
+	switch intent {
+	case IntentHourly, IntentDaily:
+		forecast, err := c.weatherSvc.GetForecast(location, intent == IntentHourly)
+		if err != nil {
+			return nil, err
+		}
+		resp.Forecast = forecastWindow(forecast, window)
+	case IntentAlerts:
+		alerts, err := c.weatherSvc.GetAlerts(location)
+		if err != nil {
+			return nil, err
+		}
+		resp.Alerts = alerts
+	case IntentComparison:
+		places := []string{location}
+		for _, p := range extraction.Others {
+			if loc := p.Location(); loc != "" {
+				places = append(places, loc)
+			}
+		}
+		if len(places) < 2 {
+			resp.Intent = IntentCurrent
+			return c.describe(resp, maxRetries)
+		}
+		for _, place := range places {
+			data, err := c.weatherSvc.GetWeather(place)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", place, err)
+			}
+			resp.Comparison = append(resp.Comparison, PlaceWeather{Location: place, Weather: data})
+		}
+		resp.Weather = resp.Comparison[0].Weather
+	default:
+		data, err := c.weatherSvc.GetWeather(location)
+		if err != nil {
+			return nil, err
+		}
+		resp.Weather = data
 	}
 
-	return location, nil
+	// Questions get a direct answer, whichever handler fetched the data.
+	if extraction.Intent == IntentYesNo || extraction.DirectQuestion {
+		return c.answer(resp, query, maxRetries)
+	}
+	return c.describe(resp, maxRetries)
 }
 
-// Update GetWeather to handle natural language
-func (c *Client) GetWeather(query string, maxRetries int) (*WeatherResponse, error) {
-	// First extract location from query
-	location, err := c.ExtractLocation(query)
+// GetWeatherData describes the current conditions at location.
+func (c *Client) GetWeatherData(location string, maxRetries int) (*WeatherResponse, error) {
+	weatherData, err := c.weatherSvc.GetWeather(location)
 	if err != nil {
 		return nil, err
 	}
 
-	// Then get weather data using existing method
-	return c.GetWeatherData(location, maxRetries)
+	return c.describe(&WeatherResponse{
+		Weather:  weatherData,
+		Intent:   IntentCurrent,
+		Location: location,
+	}, maxRetries)
 }
 
-func (c *Client) GetWeatherData(location string, maxRetries int) (*WeatherResponse, error) {
-	weatherData, err := c.weatherSvc.GetWeather(location)
+// GetForecastData describes the forecast for location over the next days,
+// hour by hour when hourly is set.
+func (c *Client) GetForecastData(location string, days int, hourly bool, maxRetries int) (*WeatherResponse, error) {
+	if days < 1 {
+		days = 1
+	}
+	forecast, err := c.weatherSvc.GetForecast(location, hourly)
 	if err != nil {
 		return nil, err
 	}
 
-	weatherJSON, err := json.Marshal(weatherData)
+	intent := IntentDaily
+	if hourly {
+		intent = IntentHourly
+	}
+	now := c.now()
+	window := TimeWindow{Start: now, End: now.Add(time.Duration(days) * 24 * time.Hour)}
+	return c.describe(&WeatherResponse{
+		Intent:   intent,
+		Location: location,
+		Window:   &window,
+		Forecast: forecastWindow(forecast, window),
+	}, maxRetries)
+}
+
+// GetAlertsData explains the alerts in effect at location.
+func (c *Client) GetAlertsData(location string, maxRetries int) (*WeatherResponse, error) {
+	alerts, err := c.weatherSvc.GetAlerts(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.describe(&WeatherResponse{
+		Intent:   IntentAlerts,
+		Location: location,
+		Alerts:   alerts,
+	}, maxRetries)
+}
+
+// describe fills in resp.Description using the prompt for resp.Intent. If
+// the model is disabled or fails, the template description is used instead
+// so the fetched data is never thrown away.
+func (c *Client) describe(resp *WeatherResponse, maxRetries int) (*WeatherResponse, error) {
+	if !c.ai {
+		return degrade(resp, nil), nil
+	}
+
+	data, err := json.Marshal(resp.promptData())
 	if err != nil {
 		return nil, fmt.Errorf("marshaling weather data: %w", err)
 	}
 
 	req := OllamaRequest{
-		Model:  "phi4", // phi4 is the default model
+		Model:  c.model,
 		Stream: false,
 		Options: &Options{
 			Temperature: 0.7,
@@ -127,79 +449,159 @@ func (c *Client) GetWeatherData(location string, maxRetries int) (*WeatherRespon
 		Messages: []ChatMessage{
 			{
 				Role:    "system",
-				Content: "You are a weather assistant. Provide a natural, concise description of the weather conditions. Focus on temperature, conditions, and humidity.",
+				Content: systemPrompt(resp.Intent),
 			},
 			{
 				Role:    "user",
-				Content: fmt.Sprintf("Describe the weather in %s based on this data: %s", location, string(weatherJSON)),
+				Content: userPrompt(resp, string(data)),
 			},
 		},
 	}
 
+	key := descriptionKey(req)
+	if cached, ok := c.cache.get(key, c.now()); ok {
+		resp.Description = cached.description
+		resp.Verification = cached.verification
+		resp.Describer = DescriberAI
+		resp.Cached = true
+		return resp, nil
+	}
+
 	description, err := c.getAIResponse(req, maxRetries)
+	if errors.Is(err, ErrOverloaded) {
+		return nil, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("getting AI response: %w", err)
+		return degrade(resp, fmt.Errorf("getting AI response: %w", err)), nil
 	}
 
-	return &WeatherResponse{
-		Weather:     weatherData,
-		Description: description,
-	}, nil
-}
-
-func (c *Client) getAIResponse(req OllamaRequest, maxRetries int) (string, error) {
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if attempt > 0 {
-			time.Sleep(time.Second * time.Duration(1<<uint(attempt)))
+	resp.Describer = DescriberAI
+	if c.guard && resp.Intent == IntentCurrent && resp.Weather != nil {
+		description, resp.Verification = c.verify(req, description, resp, maxRetries)
+		if resp.Verification.Status == VerificationFallback {
+			resp.Describer = DescriberTemplate
 		}
+	}
 
-		jsonData, err := json.Marshal(req)
+	resp.Description = description
+	if resp.Describer == DescriberAI {
+		now := c.now()
+		c.cache.put(key, cachedDescription{description, resp.Verification}, cacheExpiry(resp, now), now)
+	}
+	return resp, nil
+}
+
+// maxSchemaRetries bounds how often the model is re-prompted after
+// returning output that fails validation.
+const maxSchemaRetries = 2
+
+// getStructuredResponse sends req and hands the reply to decode. Replies
+// that decode rejects are sent back to the model together with the error,
+// up to maxSchemaRetries times.
+func (c *Client) getStructuredResponse(req OllamaRequest, maxRetries int, decode func(content string) error) error {
+	var lastErr error
+	for attempt := 0; attempt <= maxSchemaRetries; attempt++ {
+		content, err := c.getAIResponse(req, maxRetries)
 		if err != nil {
-			return "", fmt.Errorf("marshaling request: %w", err)
+			return err
 		}
 
-		request, err := http.NewRequest("POST", c.baseURL+"/chat", bytes.NewBuffer(jsonData))
-		if err != nil {
-			return "", fmt.Errorf("creating request: %w", err)
+		if lastErr = decode(stripCodeFence(content)); lastErr == nil {
+			return nil
 		}
 
-		request.Header.Set("Content-Type", "application/json")
+		req.Messages = append(req.Messages,
+			ChatMessage{Role: "assistant", Content: content},
+			ChatMessage{Role: "user", Content: fmt.Sprintf("That reply was invalid: %v. Answer again with only a JSON object matching the schema.", lastErr)},
+		)
+	}
+
+	return fmt.Errorf("model output failed validation: %w", lastErr)
+}
+
+// getAIResponse sends req to the chat endpoint, retrying transient
+// failures (connection errors, 429 and 5xx replies, a model still loading,
+// incomplete or unreadable replies) up to maxRetries times. Concurrent calls
+// with an identical request share a single model call.
+func (c *Client) getAIResponse(req OllamaRequest, maxRetries int) (string, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+
+	sum := sha256.Sum256(jsonData)
+	content, err, _ := c.inflight.Do(hex.EncodeToString(sum[:]), func() (string, error) {
+		return c.sendChat(jsonData, maxRetries)
+	})
+	return content, err
+}
+
+// sendChat posts a marshaled chat request with retries.
+func (c *Client) sendChat(jsonData []byte, maxRetries int) (string, error) {
+	policy := c.retry
+	policy.MaxAttempts = maxRetries + 1
 
-		resp, err := c.httpClient.Do(request)
+	var content string
+	err := policy.Do(context.Background(), func(ctx context.Context, attempt int) error {
+		release, err := c.admission.Acquire(ctx, c.priority)
 		if err != nil {
-			if attempt == maxRetries {
-				return "", fmt.Errorf("failed to connect to ollama server (is it running?): %w", err)
-			}
-			continue
+			return err
 		}
-		defer resp.Body.Close()
+		defer release()
 
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return "", fmt.Errorf("ollama server error (status %d): %s", resp.StatusCode, string(body))
+		content, err = c.chat(ctx, jsonData)
+		return err
+	})
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return "", fmt.Errorf("failed to connect to ollama server (is it running?): %w", err)
 		}
+		return "", err
+	}
+	return content, nil
+}
 
-		var aiResp OllamaResponse
-		if err := json.NewDecoder(resp.Body).Decode(&aiResp); err != nil {
-			if attempt == maxRetries {
-				return "", fmt.Errorf("decoding response: %w", err)
-			}
-			continue
-		}
+// chat makes a single chat request.
+func (c *Client) chat(ctx context.Context, body []byte) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
 
-		if !aiResp.Done {
-			continue
-		}
+	request.Header.Set("Content-Type", "application/json")
 
-		if aiResp.Message.Content == "" {
-			if attempt == maxRetries {
-				return "", fmt.Errorf("empty response from model")
-			}
-			continue
+	// Only the exchange itself counts towards the breaker; a reply that
+	// fails to decode means the model misbehaved, not that Ollama is down.
+	var resp *http.Response
+	err = c.breaker.Do(func() error {
+		var err error
+		if resp, err = c.httpClient.Do(request); err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return fmt.Errorf("ollama server error: %w", retry.FromResponse(resp))
 		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var aiResp OllamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&aiResp); err != nil {
+		return "", retry.Retryable(fmt.Errorf("decoding response: %w", err))
+	}
+
+	if !aiResp.Done {
+		return "", retry.Retryable(errors.New("incomplete response from model"))
+	}
 
-		return aiResp.Message.Content, nil
+	if aiResp.Message.Content == "" {
+		return "", retry.Retryable(errors.New("empty response from model"))
 	}
 
-	return "", fmt.Errorf("max retries reached - please ensure ollama is running and the phi model is installed")
+	return aiResp.Message.Content, nil
 }
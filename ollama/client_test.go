@@ -0,0 +1,258 @@
+package ollama_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+	"learn-go/weather/weathertest"
+)
+
+var miami = map[string]interface{}{
+	"city": "Miami", "region": "FL", "country": "US",
+	"time_reference": "now", "units": "", "intent": "current", "confidence": 0.95,
+}
+
+func newClient(t *testing.T, opts ...ollama.Option) (*ollama.Client, *ollamatest.Server, *weathertest.Server) {
+	t.Helper()
+
+	ai := ollamatest.NewServer()
+	t.Cleanup(ai.Close)
+	nws := weathertest.NewServer()
+	t.Cleanup(nws.Close)
+
+	// Most tests script the model's extraction, so the rules fast path is
+	// off unless a test asks for it.
+	opts = append([]ollama.Option{
+		ollama.WithBaseURL(ai.BaseURL()),
+		ollama.WithRetryDelay(0),
+		ollama.WithExtractStrategy(ollama.ExtractLLM),
+	}, opts...)
+	return ollama.NewClient(nws.NewService(), opts...), ai, nws
+}
+
+func TestExtractLocation(t *testing.T) {
+	client, ai, _ := newClient(t, ollama.WithModel("test-model"))
+	ai.Chat(ollamatest.JSONReply(miami))
+
+	got, err := client.ExtractLocation("  What's the weather like in Miami?  ")
+	if err != nil {
+		t.Fatalf("ExtractLocation: %v", err)
+	}
+	if got != "Miami, FL" {
+		t.Errorf("ExtractLocation = %q, want %q", got, "Miami, FL")
+	}
+
+	reqs := ai.ChatRequests()
+	if len(reqs) != 1 {
+		t.Fatalf("sent %d chat requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+	if req.Model != "test-model" {
+		t.Errorf("model = %q, want test-model", req.Model)
+	}
+	if req.Stream {
+		t.Error("request asked for a stream")
+	}
+	if n := len(req.Messages); n != 2 || req.Messages[1].Content != "What's the weather like in Miami?" {
+		t.Errorf("messages = %+v, want system prompt plus trimmed query", req.Messages)
+	}
+}
+
+func TestExtractLocationNoLocation(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(ollamatest.JSONReply(map[string]interface{}{
+		"city": "", "region": "", "country": "",
+		"time_reference": "now", "units": "", "intent": "unknown", "confidence": 0.9,
+	}))
+
+	_, err := client.ExtractLocation("hello there")
+	if !errors.Is(err, ollama.ErrNoLocation) {
+		t.Fatalf("error = %v, want ErrNoLocation", err)
+	}
+}
+
+func TestGetAIResponseRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    []ollamatest.Response
+		want      string
+		wantErr   string
+		wantCalls int
+	}{
+		{
+			name:      "malformed then ok",
+			script:    []ollamatest.Response{ollamatest.Malformed(), ollamatest.JSONReply(miami)},
+			want:      "Miami, FL",
+			wantCalls: 2,
+		},
+		{
+			name:      "incomplete then ok",
+			script:    []ollamatest.Response{ollamatest.Incomplete("Mia"), ollamatest.JSONReply(miami)},
+			want:      "Miami, FL",
+			wantCalls: 2,
+		},
+		{
+			name: "tool call then ok",
+			script: []ollamatest.Response{
+				ollamatest.ToolCallReply("get_weather", map[string]interface{}{"location": "Miami, FL"}),
+				ollamatest.JSONReply(miami),
+			},
+			want:      "Miami, FL",
+			wantCalls: 2,
+		},
+		{
+			name:      "empty every time",
+			script:    []ollamatest.Response{ollamatest.Reply("")},
+			wantErr:   "empty response from model",
+			wantCalls: 4,
+		},
+		{
+			name:      "malformed every time",
+			script:    []ollamatest.Response{ollamatest.Malformed()},
+			wantErr:   "decoding response",
+			wantCalls: 4,
+		},
+		{
+			name:      "server error then ok",
+			script:    []ollamatest.Response{ollamatest.Status(http.StatusInternalServerError, "model crashed"), ollamatest.JSONReply(miami)},
+			want:      "Miami, FL",
+			wantCalls: 2,
+		},
+		{
+			name:      "server error every time",
+			script:    []ollamatest.Response{ollamatest.Status(http.StatusServiceUnavailable, "overloaded")},
+			wantErr:   "attempts exhausted after 4 attempts",
+			wantCalls: 4,
+		},
+		{
+			name:      "model still loading",
+			script:    []ollamatest.Response{ollamatest.Status(http.StatusBadRequest, "loading model"), ollamatest.JSONReply(miami)},
+			want:      "Miami, FL",
+			wantCalls: 2,
+		},
+		{
+			name:      "client error is not retried",
+			script:    []ollamatest.Response{ollamatest.Status(http.StatusNotFound, "model \"phi4\" not found")},
+			wantErr:   "ollama server error: unexpected status: 404",
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, ai, _ := newClient(t)
+			ai.Chat(tt.script...)
+
+			got, err := client.Extract("weather in Miami")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Extract: %v", err)
+			} else if got.Location() != tt.want {
+				t.Errorf("Extract = %q, want %q", got.Location(), tt.want)
+			}
+			if n := ai.Hits(ollamatest.ChatPath); n != tt.wantCalls {
+				t.Errorf("chat called %d times, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetAIResponseConnectionRefused(t *testing.T) {
+	ai := ollamatest.NewServer()
+	baseURL := ai.BaseURL()
+	ai.Close()
+
+	client := ollama.NewClient(nil, ollama.WithBaseURL(baseURL), ollama.WithRetryDelay(0))
+	_, err := client.Extract("weather in Miami")
+	if err == nil || !strings.Contains(err.Error(), "is it running?") {
+		t.Fatalf("error = %v, want connection failure", err)
+	}
+}
+
+func TestGetWeather(t *testing.T) {
+	client, ai, nws := newClient(t)
+	ai.Chat(
+		ollamatest.JSONReply(miami),
+		ollamatest.Reply("It's a warm, partly cloudy day in Miami."),
+	)
+
+	got, err := client.GetWeather("What's the weather like in Miami?", 3)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Weather.Temperature != 77 {
+		t.Errorf("Temperature = %v, want 77", got.Weather.Temperature)
+	}
+	if got.Description != "It's a warm, partly cloudy day in Miami." {
+		t.Errorf("Description = %q", got.Description)
+	}
+	if n := nws.Hits(weathertest.ObservationPath); n != 1 {
+		t.Errorf("observation fetched %d times, want 1", n)
+	}
+
+	reqs := ai.ChatRequests()
+	if len(reqs) != 2 {
+		t.Fatalf("sent %d chat requests, want 2", len(reqs))
+	}
+	prompt := reqs[1].Messages[1].Content
+	if !strings.Contains(prompt, "Miami, FL") || !strings.Contains(prompt, `"conditions":"Partly Cloudy"`) {
+		t.Errorf("describe prompt = %q, want location and weather JSON", prompt)
+	}
+}
+
+func TestConcurrentIdenticalPromptsShareOneCall(t *testing.T) {
+	client, ai, _ := newClient(t)
+	reply := ollamatest.JSONReply(miami)
+	reply.Delay = 100 * time.Millisecond
+	ai.Chat(reply)
+
+	const n = 5
+	var wg sync.WaitGroup
+	got := make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i], _ = client.ExtractLocation("weather in Miami")
+		}(i)
+	}
+	wg.Wait()
+
+	for i, loc := range got {
+		if loc != "Miami, FL" {
+			t.Errorf("caller %d got %q, want Miami, FL", i, loc)
+		}
+	}
+	if hits := ai.Hits(ollamatest.ChatPath); hits != 1 {
+		t.Errorf("chat called %d times, want 1 shared call", hits)
+	}
+
+	if _, err := client.ExtractLocation("weather in Austin"); err != nil {
+		t.Fatalf("ExtractLocation: %v", err)
+	}
+	if hits := ai.Hits(ollamatest.ChatPath); hits != 2 {
+		t.Errorf("chat called %d times, want a separate call for a different prompt", hits)
+	}
+}
+
+func TestGetWeatherUpstreamError(t *testing.T) {
+	client, ai, nws := newClient(t)
+	ai.Chat(ollamatest.JSONReply(miami))
+	nws.Handle(weathertest.PointsPath, weathertest.Status(http.StatusNotFound))
+
+	if _, err := client.GetWeather("weather in Miami", 3); err == nil {
+		t.Fatal("GetWeather succeeded, want error")
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 1 {
+		t.Errorf("chat called %d times, want only the extraction", n)
+	}
+}
@@ -0,0 +1,280 @@
+package ollama
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrNoLocation is returned when a query doesn't name a place.
+var ErrNoLocation = errors.New("no location found in query")
+
+// Place is a named location as written in a query.
+type Place struct {
+	City    string `json:"city"`
+	Region  string `json:"region"` // state or province
+	Country string `json:"country"`
+}
+
+// Extraction is the structured reading of a natural language weather query.
+type Extraction struct {
+	Place
+	// Lat and Lon are only set when the query itself contains coordinates.
+	Lat *float64 `json:"lat,omitempty"`
+	Lon *float64 `json:"lon,omitempty"`
+	// Others holds the additional places of a comparison question.
+	Others []Place `json:"others,omitempty"`
+	// TimeReference is the period asked about, e.g. "now", "tonight", "this weekend".
+	TimeReference string  `json:"time_reference"`
+	Units         string  `json:"units"`
+	Intent        Intent  `json:"intent"`
+	Confidence    float64 `json:"confidence"`
+	// DirectQuestion is set when the user wants a specific fact or a yes/no
+	// answer rather than a general description.
+	DirectQuestion bool `json:"direct_question,omitempty"`
+}
+
+var extractionUnits = []string{"", "imperial", "metric"}
+
+var extractionRequired = []string{"city", "region", "country", "time_reference", "units", "intent", "confidence"}
+
+var extractionSchema = Format(`{
+  "type": "object",
+  "properties": {
+    "city": {"type": "string"},
+    "region": {"type": "string"},
+    "country": {"type": "string"},
+    "others": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {"city": {"type": "string"}, "region": {"type": "string"}, "country": {"type": "string"}},
+        "required": ["city", "region", "country"]
+      }
+    },
+    "lat": {"type": "number", "minimum": -90, "maximum": 90},
+    "lon": {"type": "number", "minimum": -180, "maximum": 180},
+    "time_reference": {"type": "string"},
+    "units": {"type": "string", "enum": ["", "imperial", "metric"]},
+    "intent": {"type": "string", "enum": ["current", "hourly", "daily", "alerts", "comparison", "yes_no", "unknown"]},
+    "confidence": {"type": "number", "minimum": 0, "maximum": 1},
+    "direct_question": {"type": "boolean"}
+  },
+  "required": ["city", "region", "country", "time_reference", "units", "intent", "confidence"]
+}`)
+
+const extractionPrompt = `You extract structured data from weather questions. Reply with a single JSON object and nothing else.
+- city, region (state or province) and country of the place asked about; use "" for parts not mentioned and do not guess.
+- lat and lon only if the user typed coordinates.
+- others: for comparisons, every additional place in the same city/region/country shape; otherwise [].
+- time_reference: the period asked about in a few words ("now", "tonight", "this weekend"), "now" if none is given.
+- units: "metric" or "imperial" if the user asks for them, otherwise "".
+- intent: current (conditions now), hourly (the next few hours), daily (the coming days), alerts (warnings and advisories),
+  comparison (two or more places), yes_no (a yes/no question about the weather) or unknown.
+- confidence: 0 to 1, how sure you are about the place.
+- direct_question: true if the user wants a specific fact or a yes/no answer ("Is it raining?", "How windy is it?"),
+  false if they want a general description ("What's the weather like?").
+If there is no place in the question, set city, region and country to "".`
+
+// Location formats the place for geocoding.
+func (p Place) Location() string {
+	var parts []string
+	for _, part := range []string{p.City, p.Region, p.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	// "City, State" is enough for Nominatim; the country only helps when
+	// there is no region to disambiguate.
+	if len(parts) == 3 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Location formats the extracted place for geocoding, falling back to the
+// coordinates when no name was given.
+func (e *Extraction) Location() string {
+	if loc := e.Place.Location(); loc != "" {
+		return loc
+	}
+	if e.Lat != nil && e.Lon != nil {
+		return fmt.Sprintf("%.4f,%.4f", *e.Lat, *e.Lon)
+	}
+	return ""
+}
+
+// HasLocation reports whether the extraction names a place.
+func (e *Extraction) HasLocation() bool {
+	return e.Location() != ""
+}
+
+// extract reads query using the configured strategy.
+func (c *Client) extract(query string) (*Extraction, error) {
+	strategy := c.strategy
+	if !c.ai {
+		strategy = ExtractRules
+	}
+
+	rules := ParseQuery(query)
+	if c.resolveSaved(query, rules) {
+		return rules, nil
+	}
+	switch strategy {
+	case ExtractRules:
+		if rules.HasLocation() {
+			return rules, nil
+		}
+		if !c.ai {
+			// Without the model there is nobody else to ask, so the whole
+			// input is taken to be the place name.
+			rules.City = strings.TrimSpace(query)
+			if rules.HasLocation() {
+				return rules, nil
+			}
+		}
+		return nil, ErrNoLocation
+	case ExtractAuto:
+		if rules.HasLocation() && rules.Confidence >= rulesConfidence {
+			return rules, nil
+		}
+	}
+
+	extraction, err := c.extractCached(query, rules)
+	if err != nil && !errors.Is(err, ErrNoLocation) && !errors.Is(err, ErrOverloaded) && rules.HasLocation() {
+		// The model is unavailable or unusable; a guess from the rules
+		// beats no answer. A shed request is refused outright instead, so
+		// it doesn't spend weather lookups on an answer we won't describe.
+		return rules, nil
+	}
+	return extraction, err
+}
+
+// extractCached asks the model for the extraction unless the semantic
+// cache already knows where a similar query pointed. Comparisons aren't
+// cached since they name more than one place.
+func (c *Client) extractCached(query string, rules *Extraction) (*Extraction, error) {
+	if c.semantic == nil || rules.Intent == IntentComparison {
+		return c.Extract(query)
+	}
+
+	vec, embedErr := c.embed(normalizeQuery(query))
+	if embedErr == nil {
+		if hit, ok := c.semanticLookup(vec, rules); ok {
+			return hit, nil
+		}
+	}
+
+	extraction, err := c.Extract(query)
+	if err == nil && embedErr == nil && len(extraction.Others) == 0 {
+		// Failing to persist the cache only costs a future model call.
+		_ = c.semantic.add(query, vec, extraction)
+	}
+	return extraction, err
+}
+
+// Extract asks the model for a structured reading of query.
+func (c *Client) Extract(query string) (*Extraction, error) {
+	query = strings.TrimSpace(query)
+
+	req := OllamaRequest{
+		Model:  c.model,
+		Stream: false,
+		Format: extractionSchema,
+		Options: &Options{
+			Temperature: 0.1,
+			Seed:        42,
+		},
+		Messages: []ChatMessage{
+			{Role: "system", Content: extractionPrompt},
+			{Role: "user", Content: query},
+		},
+	}
+
+	var extraction *Extraction
+	err := c.getStructuredResponse(req, 3, func(content string) error {
+		var err error
+		extraction, err = decodeExtraction(content)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("extracting location: %w", err)
+	}
+
+	if !extraction.HasLocation() {
+		return nil, ErrNoLocation
+	}
+	return extraction, nil
+}
+
+func decodeExtraction(content string) (*Extraction, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &fields); err != nil {
+		return nil, fmt.Errorf("not a JSON object: %w", err)
+	}
+	for _, key := range extractionRequired {
+		if _, ok := fields[key]; !ok {
+			return nil, fmt.Errorf("missing field %q", key)
+		}
+	}
+
+	var e Extraction
+	if err := json.Unmarshal([]byte(content), &e); err != nil {
+		return nil, fmt.Errorf("wrong field type: %w", err)
+	}
+	e.City = strings.TrimSpace(e.City)
+	e.Region = strings.TrimSpace(e.Region)
+	e.Country = strings.TrimSpace(e.Country)
+	e.TimeReference = strings.TrimSpace(e.TimeReference)
+	e.Units = strings.ToLower(strings.TrimSpace(e.Units))
+	e.Intent = Intent(strings.ToLower(strings.TrimSpace(string(e.Intent))))
+	for i := range e.Others {
+		e.Others[i].City = strings.TrimSpace(e.Others[i].City)
+		e.Others[i].Region = strings.TrimSpace(e.Others[i].Region)
+		e.Others[i].Country = strings.TrimSpace(e.Others[i].Country)
+	}
+
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (e *Extraction) validate() error {
+	if !slices.Contains(extractionUnits, e.Units) {
+		return fmt.Errorf("units %q is not one of %q", e.Units, extractionUnits)
+	}
+	if !slices.Contains(intents, e.Intent) {
+		return fmt.Errorf("intent %q is not one of %q", e.Intent, intents)
+	}
+	if e.Confidence < 0 || e.Confidence > 1 {
+		return fmt.Errorf("confidence %v is outside [0, 1]", e.Confidence)
+	}
+	if (e.Lat == nil) != (e.Lon == nil) {
+		return errors.New("lat and lon must be given together")
+	}
+	if e.Lat != nil && (*e.Lat < -90 || *e.Lat > 90 || *e.Lon < -180 || *e.Lon > 180) {
+		return fmt.Errorf("coordinates %v,%v are out of range", *e.Lat, *e.Lon)
+	}
+	for _, s := range []string{e.City, e.Region, e.Country} {
+		if strings.ContainsAny(s, "\n{}") {
+			return fmt.Errorf("place name %q is not a plain name", s)
+		}
+	}
+	return nil
+}
+
+// stripCodeFence removes a ```json fence some models add despite the format
+// constraint.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimPrefix(s, "json")
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,164 @@
+package ollama_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+)
+
+func TestExtractSendsSchema(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(ollamatest.JSONReply(miami))
+
+	if _, err := client.Extract("weather in Miami"); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	var schema struct {
+		Type     string   `json:"type"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(ai.ChatRequests()[0].Format, &schema); err != nil {
+		t.Fatalf("format is not a schema object: %v", err)
+	}
+	if schema.Type != "object" || len(schema.Required) == 0 {
+		t.Errorf("schema = %+v, want object with required fields", schema)
+	}
+}
+
+func TestExtractFields(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(ollamatest.Reply("```json\n" + `{"city":"Denver","region":"CO","country":"USA",
+		"time_reference":"this weekend","units":"Metric","intent":"daily","confidence":0.8}` + "\n```"))
+
+	got, err := client.Extract("Will it snow this weekend in Denver? in celsius please")
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if got.Location() != "Denver, CO" {
+		t.Errorf("Location = %q, want Denver, CO", got.Location())
+	}
+	if got.TimeReference != "this weekend" || got.Units != "metric" || got.Intent != "daily" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestExtractCoordinates(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(ollamatest.JSONReply(map[string]interface{}{
+		"city": "", "region": "", "country": "", "lat": 47.6062, "lon": -122.3321,
+		"time_reference": "now", "units": "", "intent": "current", "confidence": 1,
+	}))
+
+	got, err := client.ExtractLocation("weather at 47.6062, -122.3321")
+	if err != nil {
+		t.Fatalf("ExtractLocation: %v", err)
+	}
+	if got != "47.6062,-122.3321" {
+		t.Errorf("ExtractLocation = %q, want coordinates", got)
+	}
+}
+
+func TestExtractRepromptsOnSchemaViolation(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(
+		ollamatest.Reply("The location is Miami, FL."),
+		ollamatest.JSONReply(map[string]interface{}{"city": "Miami"}),
+		ollamatest.JSONReply(miami),
+	)
+
+	got, err := client.ExtractLocation("weather in Miami")
+	if err != nil {
+		t.Fatalf("ExtractLocation: %v", err)
+	}
+	if got != "Miami, FL" {
+		t.Errorf("ExtractLocation = %q, want Miami, FL", got)
+	}
+
+	reqs := ai.ChatRequests()
+	if len(reqs) != 3 {
+		t.Fatalf("sent %d requests, want 3", len(reqs))
+	}
+	last := reqs[2].Messages
+	feedback := last[len(last)-1].Content
+	if !strings.Contains(feedback, `missing field "region"`) {
+		t.Errorf("re-prompt = %q, want validation error", feedback)
+	}
+	if last[len(last)-2].Role != "assistant" {
+		t.Errorf("re-prompt history = %+v, want the rejected answer echoed back", last)
+	}
+}
+
+func TestExtractGivesUpOnPersistentViolation(t *testing.T) {
+	client, ai, _ := newClient(t)
+	bad := map[string]interface{}{}
+	for k, v := range miami {
+		bad[k] = v
+	}
+	bad["confidence"] = 7
+	ai.Chat(ollamatest.JSONReply(bad))
+
+	_, err := client.Extract("weather in Miami")
+	if err == nil || !strings.Contains(err.Error(), "outside [0, 1]") {
+		t.Fatalf("error = %v, want confidence validation failure", err)
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 3 {
+		t.Errorf("chat called %d times, want 3", n)
+	}
+}
+
+func TestExtractSavedPlaces(t *testing.T) {
+	client, ai, _ := newClient(t, ollama.WithPlaces(map[string]string{
+		"home":        "Miami, FL",
+		"cabin":       "Lake Tahoe, CA",
+		"beach":       "Destin, FL",
+		"beach house": "Outer Banks, NC",
+	}))
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"weather at home", "Miami, FL"},
+		{"Cabin forecast for this weekend", "Lake Tahoe, CA"},
+		{"is it raining at the cabin?", "Lake Tahoe, CA"},
+		{"how windy is it at my beach house", "Outer Banks, NC"},
+		{"beach tomorrow", "Destin, FL"},
+	}
+	for _, tt := range tests {
+		got, err := client.ExtractLocation(tt.query)
+		if err != nil || got != tt.want {
+			t.Errorf("ExtractLocation(%q) = %q, %v; want %q", tt.query, got, err, tt.want)
+		}
+	}
+	if n := len(ai.ChatRequests()); n != 0 {
+		t.Errorf("model asked %d times, want saved names resolved locally", n)
+	}
+
+	// "home" that isn't a place name is left to the model.
+	ai.Chat(ollamatest.JSONReply(map[string]interface{}{
+		"city": "Denver", "region": "CO", "country": "US", "time_reference": "now",
+		"units": "", "intent": "current", "confidence": 0.9,
+	}))
+	if got, err := client.ExtractLocation("should I drive home from Denver"); err != nil || got != "Denver, CO" {
+		t.Errorf("ExtractLocation = %q, %v; want Denver, CO", got, err)
+	}
+}
+
+func TestGetWeatherComparesSavedPlaces(t *testing.T) {
+	client, ai, _ := newClient(t, ollama.WithPlaces(map[string]string{"home": "Miami, FL"}), ollama.WithAI(false))
+
+	resp, err := client.GetWeather("Is home warmer than Miami, FL?", 1)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if len(resp.Comparison) != 2 || resp.Comparison[0].Location != "Miami, FL" {
+		t.Errorf("comparison = %+v, want home and Miami", resp.Comparison)
+	}
+	if n := len(ai.ChatRequests()); n != 0 {
+		t.Errorf("model asked %d times", n)
+	}
+}
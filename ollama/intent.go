@@ -0,0 +1,135 @@
+package ollama
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Intent is the kind of question a query asks.
+type Intent string
+
+const (
+	IntentCurrent    Intent = "current"
+	IntentHourly     Intent = "hourly"
+	IntentDaily      Intent = "daily"
+	IntentAlerts     Intent = "alerts"
+	IntentComparison Intent = "comparison"
+	IntentYesNo      Intent = "yes_no"
+	IntentUnknown    Intent = "unknown"
+)
+
+var intents = []Intent{IntentCurrent, IntentHourly, IntentDaily, IntentAlerts, IntentComparison, IntentYesNo, IntentUnknown}
+
+// hourlyHorizon is how far ahead the hourly forecast is preferred over the
+// twelve-hour periods of the daily forecast.
+const hourlyHorizon = 36 * time.Hour
+
+// TimeWindow is the period a question is about. A window whose Start and
+// End are equal refers to a single instant, usually now.
+type TimeWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// IsNow reports whether the window is the present moment.
+func (w TimeWindow) IsNow(now time.Time) bool {
+	return w.Start.Equal(w.End) && !w.Start.After(now.Add(time.Hour))
+}
+
+var nextHoursPattern = regexp.MustCompile(`(?:next|coming)?\s*(\d+)\s*(hour|hr|day)s?`)
+
+// parseTimeWindow turns the model's free-form time reference into concrete
+// bounds relative to now. Anything it can't read is treated as now.
+func parseTimeWindow(ref string, now time.Time) TimeWindow {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	at := func(d time.Time, hour int) time.Time { return d.Add(time.Duration(hour) * time.Hour) }
+
+	if m := nextHoursPattern.FindStringSubmatch(ref); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := time.Hour
+		if m[2] == "day" {
+			unit = 24 * time.Hour
+		}
+		return TimeWindow{Start: now, End: now.Add(time.Duration(n) * unit)}
+	}
+
+	switch {
+	case ref == "" || ref == "now" || strings.Contains(ref, "right now") || strings.Contains(ref, "current"):
+		return TimeWindow{Start: now, End: now}
+	case strings.Contains(ref, "tonight") || strings.Contains(ref, "this evening"):
+		start := at(day, 18)
+		if now.After(start) {
+			start = now
+		}
+		return TimeWindow{Start: start, End: at(day, 30)}
+	case strings.Contains(ref, "tomorrow"):
+		tomorrow := day.AddDate(0, 0, 1)
+		switch {
+		case strings.Contains(ref, "morning"):
+			return TimeWindow{Start: at(tomorrow, 6), End: at(tomorrow, 12)}
+		case strings.Contains(ref, "afternoon"):
+			return TimeWindow{Start: at(tomorrow, 12), End: at(tomorrow, 18)}
+		case strings.Contains(ref, "night") || strings.Contains(ref, "evening"):
+			return TimeWindow{Start: at(tomorrow, 18), End: at(tomorrow, 30)}
+		}
+		return TimeWindow{Start: tomorrow, End: tomorrow.AddDate(0, 0, 1)}
+	case strings.Contains(ref, "weekend"):
+		// Saturday 00:00 until Monday 00:00; during a weekend, the rest of it.
+		offset := (int(time.Saturday) - int(now.Weekday()) + 7) % 7
+		if now.Weekday() == time.Sunday {
+			offset = -1
+		}
+		start := day.AddDate(0, 0, offset)
+		end := start.AddDate(0, 0, 2)
+		if now.After(start) {
+			start = now
+		}
+		return TimeWindow{Start: start, End: end}
+	case strings.Contains(ref, "week"):
+		return TimeWindow{Start: now, End: now.AddDate(0, 0, 7)}
+	case strings.Contains(ref, "today") || strings.Contains(ref, "this afternoon") || strings.Contains(ref, "this morning"):
+		return TimeWindow{Start: now, End: day.AddDate(0, 0, 1)}
+	}
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.Contains(ref, strings.ToLower(d.String())) {
+			offset := (int(d) - int(now.Weekday()) + 7) % 7
+			start := day.AddDate(0, 0, offset)
+			if offset == 0 {
+				start = now
+			}
+			return TimeWindow{Start: start, End: day.AddDate(0, 0, offset+1)}
+		}
+	}
+
+	return TimeWindow{Start: now, End: now}
+}
+
+// route decides which handler answers a question. The model's intent is
+// trusted for alerts and comparisons; for everything else the time window
+// wins, so "is it going to rain tomorrow" reaches the forecast even when
+// classified as a yes/no question about current conditions.
+func route(intent Intent, window TimeWindow, now time.Time) Intent {
+	switch intent {
+	case IntentAlerts, IntentComparison:
+		return intent
+	case IntentHourly, IntentDaily:
+		if window.IsNow(now) {
+			return intent
+		}
+	}
+
+	if window.IsNow(now) {
+		if intent == IntentYesNo {
+			return IntentYesNo
+		}
+		return IntentCurrent
+	}
+	if window.End.Sub(now) <= hourlyHorizon {
+		return IntentHourly
+	}
+	return IntentDaily
+}
@@ -0,0 +1,81 @@
+package ollama
+
+import (
+	"testing"
+	"time"
+)
+
+var est = time.FixedZone("EST", -5*60*60)
+
+// wednesday is 10:30 on Wednesday 8 January 2025.
+var wednesday = time.Date(2025, 1, 8, 10, 30, 0, 0, est)
+
+func at(day, hour, min int) time.Time {
+	return time.Date(2025, 1, day, hour, min, 0, 0, est)
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	tests := []struct {
+		ref        string
+		start, end time.Time
+	}{
+		{"", wednesday, wednesday},
+		{"now", wednesday, wednesday},
+		{"right now", wednesday, wednesday},
+		{"gibberish", wednesday, wednesday},
+		{"today", wednesday, at(9, 0, 0)},
+		{"tonight", at(8, 18, 0), at(9, 6, 0)},
+		{"tomorrow", at(9, 0, 0), at(10, 0, 0)},
+		{"tomorrow morning", at(9, 6, 0), at(9, 12, 0)},
+		{"this weekend", at(11, 0, 0), at(13, 0, 0)},
+		{"next 6 hours", wednesday, wednesday.Add(6 * time.Hour)},
+		{"3 days", wednesday, wednesday.Add(72 * time.Hour)},
+		{"Friday", at(10, 0, 0), at(11, 0, 0)},
+		{"wednesday", wednesday, at(9, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		got := parseTimeWindow(tt.ref, wednesday)
+		if !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) {
+			t.Errorf("parseTimeWindow(%q) = %v – %v, want %v – %v", tt.ref, got.Start, got.End, tt.start, tt.end)
+		}
+	}
+}
+
+func TestParseTimeWindowDuringWeekend(t *testing.T) {
+	sunday := at(12, 9, 0)
+	got := parseTimeWindow("this weekend", sunday)
+	if !got.Start.Equal(sunday) || !got.End.Equal(at(13, 0, 0)) {
+		t.Errorf("window = %v – %v, want rest of Sunday", got.Start, got.End)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	now := TimeWindow{Start: wednesday, End: wednesday}
+	tonight := parseTimeWindow("tonight", wednesday)
+	weekend := parseTimeWindow("this weekend", wednesday)
+
+	tests := []struct {
+		intent Intent
+		window TimeWindow
+		want   Intent
+	}{
+		{IntentCurrent, now, IntentCurrent},
+		{IntentUnknown, now, IntentCurrent},
+		{IntentYesNo, now, IntentYesNo},
+		{IntentHourly, now, IntentHourly},
+		{IntentDaily, now, IntentDaily},
+		{IntentAlerts, weekend, IntentAlerts},
+		{IntentComparison, now, IntentComparison},
+		{IntentCurrent, tonight, IntentHourly},
+		{IntentYesNo, tonight, IntentHourly},
+		{IntentYesNo, weekend, IntentDaily},
+		{IntentHourly, weekend, IntentDaily},
+	}
+
+	for _, tt := range tests {
+		if got := route(tt.intent, tt.window, wednesday); got != tt.want {
+			t.Errorf("route(%s, %v) = %s, want %s", tt.intent, tt.window.Start, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"learn-go/retry"
+)
+
+type tagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// Model returns the name of the model used for chat.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// EmbedModel returns the name of the model used to embed queries.
+func (c *Client) EmbedModel() string {
+	return c.embedModel
+}
+
+// Models lists the models installed on the Ollama server.
+func (c *Client) Models(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ollama server (is it running?): %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama server error: %w", retry.FromResponse(resp))
+	}
+	var tags tagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("decoding model list: %w", err)
+	}
+	names := make([]string, len(tags.Models))
+	for i, m := range tags.Models {
+		names[i] = m.Name
+	}
+	return names, nil
+}
+
+// HasModel reports whether name is among models, treating a name without a
+// tag as ":latest" the way Ollama does.
+func HasModel(models []string, name string) bool {
+	if !strings.Contains(name, ":") {
+		name += ":latest"
+	}
+	for _, m := range models {
+		if !strings.Contains(m, ":") {
+			m += ":latest"
+		}
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package ollama_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+)
+
+func TestModels(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Tags(ollamatest.Tags("phi4:latest", "nomic-embed-text:latest"))
+
+	models, err := client.Models(context.Background())
+	if err != nil {
+		t.Fatalf("Models: %v", err)
+	}
+	if strings.Join(models, ",") != "phi4:latest,nomic-embed-text:latest" {
+		t.Errorf("Models = %v", models)
+	}
+
+	for _, tt := range []struct {
+		name string
+		want bool
+	}{
+		{"phi4", true},
+		{"phi4:latest", true},
+		{"phi4:14b", false},
+		{"llama3", false},
+	} {
+		if got := ollama.HasModel(models, tt.name); got != tt.want {
+			t.Errorf("HasModel(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestModelsServerError(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Tags(ollamatest.Status(http.StatusInternalServerError, "boom"))
+
+	if _, err := client.Models(context.Background()); err == nil || !strings.Contains(err.Error(), "unexpected status: 500") {
+		t.Fatalf("error = %v, want status error", err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package ollamatest provides an in-process fake of the Ollama HTTP API.
+//
+// Chat replies are scripted per test with Chat; every request the client
+// sends is recorded and can be inspected with ChatRequests.
+package ollamatest
+
+import (
+	"encoding/json"
+
+	"learn-go/internal/fakeserver"
+)
+
+// Response is a canned reply for a single request.
+type Response = fakeserver.Response
+
+// ChatPath is the route the ollama client posts chat requests to.
+const ChatPath = "/api/chat"
+
+// EmbeddingsPath is the route the ollama client posts embedding requests to.
+const EmbeddingsPath = "/api/embeddings"
+
+// TagsPath is the route the ollama client lists installed models from.
+const TagsPath = "/api/tags"
+
+// Message is a chat message as seen on the wire.
+type Message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ToolCall is a function call emitted by the model.
+type ToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+// ChatRequest is a decoded request body received on ChatPath.
+type ChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []Message              `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Format   json.RawMessage        `json:"format,omitempty"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+type chatResponse struct {
+	Model         string  `json:"model"`
+	CreatedAt     string  `json:"created_at"`
+	Message       Message `json:"message"`
+	Done          bool    `json:"done"`
+	TotalDuration int64   `json:"total_duration"`
+}
+
+func chat(msg Message, done bool) Response {
+	return fakeserver.JSON(chatResponse{
+		Model:         "phi4",
+		CreatedAt:     "2025-01-08T15:53:00Z",
+		Message:       msg,
+		Done:          done,
+		TotalDuration: 1500000000,
+	})
+}
+
+// Reply returns a completed chat response whose message is content.
+func Reply(content string) Response {
+	return chat(Message{Role: "assistant", Content: content}, true)
+}
+
+// JSONReply returns a completed chat response whose content is v encoded as
+// JSON, as produced by a request with a format constraint.
+func JSONReply(v interface{}) Response {
+	content, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return Reply(string(content))
+}
+
+// Incomplete returns a chat response with done set to false.
+func Incomplete(content string) Response {
+	return chat(Message{Role: "assistant", Content: content}, false)
+}
+
+// ToolCallReply returns a completed chat response in which the model calls
+// the named tool instead of answering with text.
+func ToolCallReply(name string, args map[string]interface{}) Response {
+	var call ToolCall
+	call.Function.Name = name
+	call.Function.Arguments = args
+	return chat(Message{Role: "assistant", ToolCalls: []ToolCall{call}}, true)
+}
+
+// Status returns an error response in Ollama's {"error": ...} shape.
+func Status(code int, msg string) Response {
+	resp := fakeserver.JSON(map[string]string{"error": msg})
+	resp.Status = code
+	return resp
+}
+
+// Embedding returns an /api/embeddings reply carrying vec.
+func Embedding(vec ...float64) Response {
+	return fakeserver.JSON(map[string][]float64{"embedding": vec})
+}
+
+// Tags returns an /api/tags reply listing the named models.
+func Tags(names ...string) Response {
+	type model struct {
+		Name string `json:"name"`
+	}
+	models := make([]model, len(names))
+	for i, name := range names {
+		models[i].Name = name
+	}
+	return fakeserver.JSON(map[string][]model{"models": models})
+}
+
+// Malformed returns a 200 response whose body is not valid JSON.
+func Malformed() Response {
+	return Response{Body: `{"model":"phi4","message":{"role":`}
+}
+
+// Server is a fake Ollama server.
+type Server struct {
+	*fakeserver.Server
+}
+
+// NewServer starts a fake server that answers every chat with an empty
+// completion until scripted. Callers must Close it.
+func NewServer() *Server {
+	s := &Server{fakeserver.New()}
+	s.Chat(Reply(""))
+	return s
+}
+
+// BaseURL is the API root to hand to ollama.WithBaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + "/api"
+}
+
+// Chat scripts the replies for ChatPath; the last one repeats.
+func (s *Server) Chat(responses ...Response) {
+	s.Handle(ChatPath, responses...)
+}
+
+// Embeddings scripts the replies for EmbeddingsPath; the last one repeats.
+// Until scripted, embedding requests get a 404.
+func (s *Server) Embeddings(responses ...Response) {
+	s.Handle(EmbeddingsPath, responses...)
+}
+
+// Tags scripts the replies for TagsPath; the last one repeats.
+// Until scripted, model listings get a 404.
+func (s *Server) Tags(responses ...Response) {
+	s.Handle(TagsPath, responses...)
+}
+
+// ChatRequests decodes every request received on ChatPath, in order.
+func (s *Server) ChatRequests() []ChatRequest {
+	var out []ChatRequest
+	for _, r := range s.Requests() {
+		if r.Path != ChatPath {
+			continue
+		}
+		var req ChatRequest
+		if err := json.Unmarshal(r.Body, &req); err != nil {
+			req.Model = "<undecodable: " + err.Error() + ">"
+		}
+		out = append(out, req)
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package ollama
+
+import (
+	"sort"
+	"strings"
+)
+
+var states = map[string]string{
+	"alabama": "AL", "alaska": "AK", "arizona": "AZ", "arkansas": "AR", "california": "CA",
+	"colorado": "CO", "connecticut": "CT", "delaware": "DE", "florida": "FL", "georgia": "GA",
+	"hawaii": "HI", "idaho": "ID", "illinois": "IL", "indiana": "IN", "iowa": "IA",
+	"kansas": "KS", "kentucky": "KY", "louisiana": "LA", "maine": "ME", "maryland": "MD",
+	"massachusetts": "MA", "michigan": "MI", "minnesota": "MN", "mississippi": "MS", "missouri": "MO",
+	"montana": "MT", "nebraska": "NE", "nevada": "NV", "new hampshire": "NH", "new jersey": "NJ",
+	"new mexico": "NM", "new york": "NY", "north carolina": "NC", "north dakota": "ND", "ohio": "OH",
+	"oklahoma": "OK", "oregon": "OR", "pennsylvania": "PA", "rhode island": "RI", "south carolina": "SC",
+	"south dakota": "SD", "tennessee": "TN", "texas": "TX", "utah": "UT", "vermont": "VT",
+	"virginia": "VA", "washington": "WA", "west virginia": "WV", "wisconsin": "WI", "wyoming": "WY",
+	"district of columbia": "DC", "puerto rico": "PR",
+}
+
+var stateAbbrs = func() map[string]bool {
+	m := make(map[string]bool, len(states))
+	for _, abbr := range states {
+		m[abbr] = true
+	}
+	return m
+}()
+
+// stateAbbr resolves a state name or postal abbreviation. Abbreviations
+// must be upper case, since "in", "or" and "me" are also ordinary words.
+func stateAbbr(s string) (string, bool) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "."))
+	if stateAbbrs[s] {
+		return s, true
+	}
+	abbr, ok := states[strings.ToLower(s)]
+	return abbr, ok
+}
+
+type knownCity struct {
+	city, state string
+}
+
+// knownCities are large US cities whose name alone is unambiguous enough to
+// skip the model. Names that are shared by several large cities, like
+// Portland, are deliberately missing.
+var knownCities = map[string]knownCity{
+	"new york city": {"New York", "NY"}, "new york": {"New York", "NY"}, "nyc": {"New York", "NY"},
+	"los angeles": {"Los Angeles", "CA"}, "chicago": {"Chicago", "IL"}, "houston": {"Houston", "TX"},
+	"phoenix": {"Phoenix", "AZ"}, "philadelphia": {"Philadelphia", "PA"}, "san antonio": {"San Antonio", "TX"},
+	"san diego": {"San Diego", "CA"}, "dallas": {"Dallas", "TX"}, "austin": {"Austin", "TX"},
+	"jacksonville": {"Jacksonville", "FL"}, "san jose": {"San Jose", "CA"}, "fort worth": {"Fort Worth", "TX"},
+	"charlotte": {"Charlotte", "NC"}, "indianapolis": {"Indianapolis", "IN"}, "san francisco": {"San Francisco", "CA"},
+	"seattle": {"Seattle", "WA"}, "denver": {"Denver", "CO"}, "nashville": {"Nashville", "TN"},
+	"oklahoma city": {"Oklahoma City", "OK"}, "el paso": {"El Paso", "TX"}, "boston": {"Boston", "MA"},
+	"las vegas": {"Las Vegas", "NV"}, "detroit": {"Detroit", "MI"}, "memphis": {"Memphis", "TN"},
+	"louisville": {"Louisville", "KY"}, "baltimore": {"Baltimore", "MD"}, "milwaukee": {"Milwaukee", "WI"},
+	"albuquerque": {"Albuquerque", "NM"}, "tucson": {"Tucson", "AZ"}, "fresno": {"Fresno", "CA"},
+	"sacramento": {"Sacramento", "CA"}, "kansas city": {"Kansas City", "MO"}, "atlanta": {"Atlanta", "GA"},
+	"miami": {"Miami", "FL"}, "raleigh": {"Raleigh", "NC"}, "omaha": {"Omaha", "NE"},
+	"minneapolis": {"Minneapolis", "MN"}, "tulsa": {"Tulsa", "OK"}, "new orleans": {"New Orleans", "LA"},
+	"cleveland": {"Cleveland", "OH"}, "honolulu": {"Honolulu", "HI"}, "anchorage": {"Anchorage", "AK"},
+	"salt lake city": {"Salt Lake City", "UT"}, "pittsburgh": {"Pittsburgh", "PA"}, "cincinnati": {"Cincinnati", "OH"},
+	"st. louis": {"St. Louis", "MO"}, "st louis": {"St. Louis", "MO"}, "saint louis": {"St. Louis", "MO"},
+	"orlando": {"Orlando", "FL"}, "tampa": {"Tampa", "FL"}, "buffalo": {"Buffalo", "NY"},
+	"boise": {"Boise", "ID"}, "des moines": {"Des Moines", "IA"}, "washington dc": {"Washington", "DC"},
+}
+
+// knownCityNames lists knownCities longest first, so "new york city" wins
+// over "new york" and "kansas city" over "kansas".
+var knownCityNames = func() []string {
+	names := make([]string, 0, len(knownCities))
+	for name := range knownCities {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}()
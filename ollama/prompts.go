@@ -0,0 +1,86 @@
+package ollama
+
+import (
+	"fmt"
+	"time"
+
+	"learn-go/weather"
+)
+
+// promptVersion identifies the wording of the prompts below. Bump it when a
+// prompt changes so cached descriptions written for the old wording are not
+// reused.
+const promptVersion = 1
+
+var systemPrompts = map[Intent]string{
+	IntentCurrent: "You are a weather assistant. Provide a natural, concise description of the weather conditions. Focus on temperature, conditions, and humidity.",
+	IntentHourly:  "You are a weather assistant. Summarize this hourly forecast for the requested period in a few sentences: how the temperature changes, when rain is most likely, and any notable wind.",
+	IntentDaily:   "You are a weather assistant. Summarize this forecast for the requested days in a few sentences, giving highs, lows and the chance of rain or snow.",
+	IntentAlerts:  "You are a weather assistant. Explain the active weather alerts in plain language, most severe first, including what people should do. If the list is empty, say there are no active alerts.",
+	IntentComparison: "You are a weather assistant. Compare the current conditions in these places in a few sentences, " +
+		"saying which is warmer, wetter or windier where it matters.",
+}
+
+func systemPrompt(intent Intent) string {
+	if p, ok := systemPrompts[intent]; ok {
+		return p
+	}
+	return systemPrompts[IntentCurrent]
+}
+
+func userPrompt(resp *WeatherResponse, data string) string {
+	switch resp.Intent {
+	case IntentHourly, IntentDaily:
+		if resp.Window != nil {
+			return fmt.Sprintf("Summarize the forecast for %s from %s to %s based on this data: %s",
+				resp.Location, resp.Window.Start.Format(time.RFC1123), resp.Window.End.Format(time.RFC1123), data)
+		}
+		return fmt.Sprintf("Summarize the forecast for %s based on this data: %s", resp.Location, data)
+	case IntentAlerts:
+		return fmt.Sprintf("These are the active weather alerts for %s: %s", resp.Location, data)
+	case IntentComparison:
+		return fmt.Sprintf("Compare the weather in these places: %s", data)
+	default:
+		return fmt.Sprintf("Describe the weather in %s based on this data: %s", resp.Location, data)
+	}
+}
+
+// promptData is the part of the response the model needs to see.
+func (r *WeatherResponse) promptData() interface{} {
+	switch {
+	case r.Comparison != nil:
+		return r.Comparison
+	case r.Forecast != nil:
+		return r.Forecast
+	case r.Intent == IntentAlerts:
+		if r.Alerts == nil {
+			return []weather.Alert{}
+		}
+		return r.Alerts
+	default:
+		return r.Weather
+	}
+}
+
+// maxHourlyPeriods caps an open-ended hourly forecast at a day.
+const maxHourlyPeriods = 24
+
+// forecastWindow trims a forecast to the periods overlapping window. If
+// nothing overlaps, e.g. the question reaches past the forecast's end, the
+// whole forecast is kept.
+func forecastWindow(f *weather.Forecast, window TimeWindow) *weather.Forecast {
+	end := window.End
+	if window.Start.Equal(window.End) {
+		end = time.Time{}
+	}
+	periods := f.Between(window.Start, end)
+	if len(periods) == 0 {
+		return f
+	}
+	if end.IsZero() && f.Hourly && len(periods) > maxHourlyPeriods {
+		periods = periods[:maxHourlyPeriods]
+	}
+	trimmed := *f
+	trimmed.Periods = periods
+	return &trimmed
+}
@@ -0,0 +1,168 @@
+package ollama_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+	"learn-go/weather/weathertest"
+)
+
+// wednesday matches the weathertest forecast fixtures.
+var wednesday = time.Date(2025, 1, 8, 10, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+
+func extraction(intent, timeRef string, others ...map[string]interface{}) ollamatest.Response {
+	e := map[string]interface{}{}
+	for k, v := range miami {
+		e[k] = v
+	}
+	e["intent"] = intent
+	e["time_reference"] = timeRef
+	if others != nil {
+		e["others"] = others
+	}
+	return ollamatest.JSONReply(e)
+}
+
+var description = ollamatest.Reply("A description.")
+
+func answer(fields ...string) ollamatest.Response {
+	return ollamatest.JSONReply(map[string]interface{}{
+		"answer":      "No",
+		"explanation": "The data shows no rain.",
+		"fields":      fields,
+	})
+}
+
+func TestGetWeatherRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		extraction ollamatest.Response
+		wantIntent ollama.Intent
+		wantPath   string
+		wantPrompt string
+		reply      ollamatest.Response
+	}{
+		{"current", extraction("current", "now"), ollama.IntentCurrent, weathertest.ObservationPath, "Describe the weather in Miami, FL", description},
+		{"yes/no now", extraction("yes_no", "right now"), ollama.IntentYesNo, weathertest.ObservationPath, "Question: Is it raining in Miami?", answer("conditions")},
+		{"tonight", extraction("current", "tonight"), ollama.IntentHourly, weathertest.HourlyPath, "Summarize the forecast for Miami, FL from", description},
+		{"weekend", extraction("yes_no", "this weekend"), ollama.IntentDaily, weathertest.ForecastPath, "Question: Is it raining in Miami?", answer("periods.0.short_forecast")},
+		{"alerts", extraction("alerts", "now"), ollama.IntentAlerts, weathertest.AlertsPath, "active weather alerts for Miami, FL: []", description},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, ai, nws := newClient(t, ollama.WithClock(func() time.Time { return wednesday }))
+			ai.Chat(tt.extraction, tt.reply)
+
+			got, err := client.GetWeather("Is it raining in Miami?", 0)
+			if err != nil {
+				t.Fatalf("GetWeather: %v", err)
+			}
+			if got.Intent != tt.wantIntent {
+				t.Errorf("Intent = %s, want %s", got.Intent, tt.wantIntent)
+			}
+			if n := nws.Hits(tt.wantPath); n != 1 {
+				t.Errorf("%s hit %d times, want 1", tt.wantPath, n)
+			}
+			reqs := ai.ChatRequests()
+			if prompt := reqs[len(reqs)-1].Messages[1].Content; !strings.Contains(prompt, tt.wantPrompt) {
+				t.Errorf("prompt = %q, want it to contain %q", prompt, tt.wantPrompt)
+			}
+		})
+	}
+}
+
+func TestGetWeatherForecastWindow(t *testing.T) {
+	client, ai, _ := newClient(t, ollama.WithClock(func() time.Time { return wednesday }))
+	ai.Chat(extraction("daily", "tomorrow"), ollamatest.Reply("Showers likely."))
+
+	got, err := client.GetWeather("What's the forecast for Miami tomorrow?", 0)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Window == nil || got.Forecast == nil {
+		t.Fatalf("got window=%v forecast=%v", got.Window, got.Forecast)
+	}
+	var names []string
+	for _, p := range got.Forecast.Periods {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "Tonight,Thursday,Thursday Night" {
+		t.Errorf("periods = %v, want those overlapping Thursday", names)
+	}
+}
+
+func TestGetWeatherComparison(t *testing.T) {
+	client, ai, nws := newClient(t)
+	austin := map[string]interface{}{"city": "Austin", "region": "TX", "country": "US"}
+	ai.Chat(extraction("comparison", "now", austin), ollamatest.Reply("Miami is warmer."))
+	// The fake geocoder answers Miami for every query, which is all the
+	// comparison needs.
+
+	got, err := client.GetWeather("Is Miami warmer than Austin?", 0)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if len(got.Comparison) != 2 || got.Comparison[1].Location != "Austin, TX" {
+		t.Fatalf("Comparison = %+v, want Miami and Austin", got.Comparison)
+	}
+	if got.Weather != got.Comparison[0].Weather {
+		t.Error("Weather should be the first place's conditions")
+	}
+	if n := nws.Hits(weathertest.ObservationPath); n != 2 {
+		t.Errorf("observations fetched %d times, want 2", n)
+	}
+}
+
+func TestGetWeatherComparisonWithoutOthers(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(extraction("comparison", "now"), ollamatest.Reply("Warm."))
+
+	got, err := client.GetWeather("Is Miami warmer than usual?", 0)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Intent != ollama.IntentCurrent || got.Comparison != nil {
+		t.Errorf("got intent %s with comparison %v, want plain current conditions", got.Intent, got.Comparison)
+	}
+}
+
+func TestGetForecastData(t *testing.T) {
+	client, ai, nws := newClient(t, ollama.WithClock(func() time.Time { return wednesday }))
+	ai.Chat(ollamatest.Reply("Showers likely."))
+
+	got, err := client.GetForecastData("Miami, FL", 1, false, 0)
+	if err != nil {
+		t.Fatalf("GetForecastData: %v", err)
+	}
+	if got.Intent != ollama.IntentDaily || got.Description != "Showers likely." {
+		t.Errorf("got intent %s description %q", got.Intent, got.Description)
+	}
+	var names []string
+	for _, p := range got.Forecast.Periods {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "This Afternoon,Tonight,Thursday" {
+		t.Errorf("periods = %v, want the next 24 hours", names)
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 1 || nws.Hits(weathertest.ForecastPath) != 1 {
+		t.Errorf("model called %d times, want only the description", n)
+	}
+}
+
+func TestGetAlertsData(t *testing.T) {
+	client, ai, nws := newClient(t)
+	ai.Chat(ollamatest.Reply("A flood watch is in effect."))
+	nws.Handle(weathertest.AlertsPath, weathertest.Body(weathertest.AlertsJSON))
+
+	got, err := client.GetAlertsData("Miami, FL", 0)
+	if err != nil {
+		t.Fatalf("GetAlertsData: %v", err)
+	}
+	if got.Intent != ollama.IntentAlerts || len(got.Alerts) == 0 {
+		t.Errorf("got intent %s with %d alerts", got.Intent, len(got.Alerts))
+	}
+}
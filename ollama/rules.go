@@ -0,0 +1,291 @@
+package ollama
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ExtractStrategy selects how queries are turned into an Extraction.
+type ExtractStrategy string
+
+const (
+	// ExtractAuto uses the rules when they are confident and the model
+	// otherwise, falling back to the rules if the model is unavailable.
+	ExtractAuto ExtractStrategy = "auto"
+	// ExtractRules never calls the model.
+	ExtractRules ExtractStrategy = "rules"
+	// ExtractLLM always asks the model, using the rules only when the model
+	// is unavailable.
+	ExtractLLM ExtractStrategy = "llm"
+)
+
+// ParseExtractStrategy converts a flag or environment value into an
+// ExtractStrategy; an empty string means ExtractAuto.
+func ParseExtractStrategy(s string) (ExtractStrategy, error) {
+	switch st := ExtractStrategy(strings.ToLower(strings.TrimSpace(s))); st {
+	case "":
+		return ExtractAuto, nil
+	case ExtractAuto, ExtractRules, ExtractLLM:
+		return st, nil
+	default:
+		return "", fmt.Errorf("unknown extraction strategy %q (want auto, rules or llm)", s)
+	}
+}
+
+// rulesConfidence is the confidence at which ExtractAuto trusts the rules
+// without asking the model.
+const rulesConfidence = 0.8
+
+var (
+	coordsPattern = regexp.MustCompile(`(-?\d{1,2}\.\d+)\s*,\s*(-?\d{1,3}\.\d+)`)
+	// "Springfield, IL" or "Portland, Oregon"
+	cityRegionPattern = regexp.MustCompile(`\b([A-Za-z][A-Za-z.'-]*(?:\s+[A-Za-z][A-Za-z.'-]*){0,3}),\s*([A-Za-z][A-Za-z ]*[A-Za-z]|[A-Za-z]{2})\b`)
+	// "in Seattle", "for new york", "at Lake Tahoe"
+	prepositionPattern = regexp.MustCompile(`(?i)\b(?:in|at|for|near|around|of)\s+(\pL[\pL.'-]*(?:\s+\pL[\pL.'-]*){0,3})`)
+	// Split comparisons: "Is Miami warmer than Austin?"
+	comparisonPattern = regexp.MustCompile(`(?i)\s+(?:than|vs\.?|versus|compared to|or)\s+`)
+)
+
+// stopWords end a place name picked up after a preposition.
+var stopWords = map[string]bool{
+	"right": true, "now": true, "today": true, "tonight": true, "tomorrow": true, "this": true,
+	"next": true, "the": true, "weekend": true, "week": true, "morning": true, "afternoon": true,
+	"evening": true, "currently": true, "please": true, "like": true, "be": true, "going": true,
+	"warmer": true, "colder": true, "than": true, "and": true, "or": true, "vs": true, "later": true,
+	"on": true, "at": true, "in": true, "is": true, "will": true, "celsius": true, "fahrenheit": true,
+	"weather": true, "forecast": true, "temperature": true, "hourly": true,
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true, "friday": true, "saturday": true, "sunday": true,
+}
+
+// ParseQuery reads a query with fixed rules: coordinates, "City, ST",
+// well-known city names and "in <Place>" phrases. Confidence reflects how
+// unambiguous the match was; it is zero when no place was found.
+func ParseQuery(query string) *Extraction {
+	query = strings.TrimSpace(query)
+	lower := strings.ToLower(query)
+
+	e := &Extraction{
+		TimeReference:  timeReference(lower),
+		Units:          units(lower),
+		Intent:         ruleIntent(lower),
+		DirectQuestion: directQuestion(lower),
+	}
+
+	if m := coordsPattern.FindStringSubmatch(query); m != nil {
+		lat, _ := strconv.ParseFloat(m[1], 64)
+		lon, _ := strconv.ParseFloat(m[2], 64)
+		if lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180 {
+			e.Lat, e.Lon = &lat, &lon
+			e.Confidence = 1
+			return e
+		}
+	}
+
+	if e.Intent == IntentComparison {
+		parts := comparisonPattern.Split(query, -1)
+		var places []Place
+		var conf float64 = 1
+		for _, part := range parts {
+			p, c := findPlace(part)
+			if p.City == "" && p.Region == "" {
+				continue
+			}
+			places = append(places, p)
+			conf = min(conf, c)
+		}
+		if len(places) >= 2 {
+			e.Place, e.Others = places[0], places[1:]
+			e.Confidence = conf
+			return e
+		}
+		e.Intent = IntentCurrent
+	}
+
+	e.Place, e.Confidence = findPlace(query)
+	return e
+}
+
+// findPlace returns the best place match in s and how sure it is.
+func findPlace(s string) (Place, float64) {
+	// "City, ST" with a real state is the least ambiguous form.
+	for _, m := range cityRegionPattern.FindAllStringSubmatch(s, -1) {
+		// The region group runs on into the rest of the sentence ("IL right
+		// now"), so try its first three, two and one words.
+		words := strings.Fields(m[2])
+		for n := min(3, len(words)); n > 0; n-- {
+			if abbr, ok := stateAbbr(strings.Join(words[:n], " ")); ok {
+				return Place{City: trimLeadingFiller(m[1]), Region: abbr, Country: "US"}, 0.95
+			}
+		}
+	}
+
+	lower := strings.ToLower(s)
+	for _, name := range knownCityNames {
+		if containsWord(lower, name) {
+			city := knownCities[name]
+			return Place{City: city.city, Region: city.state, Country: "US"}, 0.9
+		}
+	}
+
+	if m := prepositionPattern.FindStringSubmatch(s); m != nil {
+		words := placeWords(m[1])
+		if len(words) > 0 {
+			// "in Austin Texas": a trailing state name becomes the region.
+			for i := 1; i < len(words); i++ {
+				if abbr, ok := stateAbbr(strings.Join(words[i:], " ")); ok {
+					return Place{City: titleCase(strings.Join(words[:i], " ")), Region: abbr, Country: "US"}, 0.85
+				}
+			}
+			name := strings.Join(words, " ")
+			if abbr, ok := stateAbbr(name); ok {
+				return Place{Region: abbr, Country: "US"}, 0.6
+			}
+			return Place{City: titleCase(name)}, 0.6
+		}
+	}
+
+	// A bare capitalized name such as "Boise" typed on its own.
+	words := strings.Fields(strings.Trim(s, " ?!."))
+	if len(words) > 0 && len(words) <= 3 && isCapitalized(words[0]) {
+		name := strings.Join(words, " ")
+		if abbr, ok := stateAbbr(name); ok {
+			return Place{Region: abbr, Country: "US"}, 0.5
+		}
+		return Place{City: name}, 0.5
+	}
+
+	return Place{}, 0
+}
+
+func placeWords(s string) []string {
+	var words []string
+	for _, w := range strings.Fields(s) {
+		w = strings.Trim(w, "?!.,")
+		if w == "" || stopWords[strings.ToLower(w)] {
+			break
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+var leadingFiller = regexp.MustCompile(`(?i)^(?:.*\b(?:in|at|for|near|around|of|is|weather))\s+`)
+
+// trimLeadingFiller drops words before the city that the city/region
+// pattern swallowed, e.g. "weather in Springfield" -> "Springfield".
+func trimLeadingFiller(s string) string {
+	return strings.TrimSpace(leadingFiller.ReplaceAllString(s, ""))
+}
+
+func timeReference(lower string) string {
+	for _, ref := range []string{
+		"right now", "tonight", "this evening", "tomorrow morning", "tomorrow afternoon", "tomorrow night",
+		"tomorrow", "this weekend", "weekend", "this week", "today", "this afternoon", "this morning",
+		"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday",
+	} {
+		if containsWord(lower, ref) {
+			return ref
+		}
+	}
+	if m := nextHoursPattern.FindString(lower); m != "" {
+		return strings.TrimSpace(m)
+	}
+	return "now"
+}
+
+func units(lower string) string {
+	switch {
+	case containsWord(lower, "celsius") || containsWord(lower, "metric") || strings.Contains(lower, "°c"):
+		return "metric"
+	case containsWord(lower, "fahrenheit") || containsWord(lower, "imperial") || strings.Contains(lower, "°f"):
+		return "imperial"
+	}
+	return ""
+}
+
+func ruleIntent(lower string) Intent {
+	switch {
+	case containsAnyWord(lower, "alert", "alerts", "warning", "warnings", "advisory", "advisories", "watches") || alertWatchPattern.MatchString(lower):
+		return IntentAlerts
+	case containsAnyWord(lower, "than", "vs", "versus", "compare", "compared"):
+		return IntentComparison
+	case containsAnyWord(lower, "hourly", "hour", "hours"):
+		return IntentHourly
+	case containsAnyWord(lower, "forecast", "week", "weekend", "days"):
+		return IntentDaily
+	case startsWithAny(lower, "is ", "will ", "does ", "do ", "are ", "should ", "can ", "was "):
+		return IntentYesNo
+	}
+	return IntentCurrent
+}
+
+// alertWatchPattern matches the watches NWS issues, such as "flood watch";
+// "watch" on its own is usually the verb, as in "watch out for rain".
+var alertWatchPattern = regexp.MustCompile(`\b(?:tornado|flood|flash flood|thunderstorm|storm|winter storm|blizzard|hurricane|tropical storm|storm surge|freeze|frost|fire weather|heat|wind|high wind|avalanche)\s+watch\b`)
+
+func directQuestion(lower string) bool {
+	return startsWithAny(lower, "is ", "will ", "does ", "do ", "are ", "should ", "can ",
+		"how hot", "how cold", "how warm", "how windy", "how humid", "how much rain",
+		"what's the temperature", "what is the temperature", "what's the humidity", "what is the humidity")
+}
+
+func containsWord(s, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		before := start == 0 || !isWordByte(s[start-1])
+		after := end == len(s) || !isWordByte(s[end])
+		if before && after {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b == '\'' || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
+
+func containsAnyWord(s string, words ...string) bool {
+	for _, w := range words {
+		if containsWord(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func startsWithAny(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCapitalized(w string) bool {
+	for _, r := range w {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		if isCapitalized(w) {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,125 @@
+package ollama_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		location string
+		minConf  float64
+		intent   ollama.Intent
+		timeRef  string
+	}{
+		{"weather in Miami, FL", "Miami, FL", 0.9, ollama.IntentCurrent, "now"},
+		{"What's the weather like in Springfield, IL right now?", "Springfield, IL", 0.9, ollama.IntentCurrent, "right now"},
+		{"Portland, Oregon forecast for tomorrow", "Portland, OR", 0.9, ollama.IntentDaily, "tomorrow"},
+		{"how's nyc today", "New York, NY", 0.9, ollama.IntentCurrent, "today"},
+		{"Is it raining in Seattle right now?", "Seattle, WA", 0.9, ollama.IntentYesNo, "right now"},
+		{"Will it snow this weekend in Denver?", "Denver, CO", 0.9, ollama.IntentDaily, "this weekend"},
+		{"any weather alerts for Houston", "Houston, TX", 0.9, ollama.IntentAlerts, "now"},
+		{"weather in Bozeman Montana tonight", "Bozeman, MT", 0.8, ollama.IntentCurrent, "tonight"},
+		{"weather in smallville", "Smallville", 0.5, ollama.IntentCurrent, "now"},
+		{"Boise", "Boise, ID", 0.9, ollama.IntentCurrent, "now"},
+		{"Twin Falls", "Twin Falls", 0.5, ollama.IntentCurrent, "now"},
+		{"weather at 47.6062, -122.3321", "47.6062,-122.3321", 1, ollama.IntentCurrent, "now"},
+		{"weather in ålesund", "Ålesund", 0.5, ollama.IntentCurrent, "now"},
+		{"should I watch out for rain in Denver", "Denver, CO", 0.9, ollama.IntentYesNo, "now"},
+		{"is there a flood watch in Denver", "Denver, CO", 0.9, ollama.IntentAlerts, "now"},
+		{"any watches for Denver", "Denver, CO", 0.9, ollama.IntentAlerts, "now"},
+	}
+
+	for _, tt := range tests {
+		got := ollama.ParseQuery(tt.query)
+		if got.Location() != tt.location {
+			t.Errorf("ParseQuery(%q) location = %q, want %q", tt.query, got.Location(), tt.location)
+		}
+		if got.Confidence < tt.minConf {
+			t.Errorf("ParseQuery(%q) confidence = %v, want at least %v", tt.query, got.Confidence, tt.minConf)
+		}
+		if got.Intent != tt.intent {
+			t.Errorf("ParseQuery(%q) intent = %s, want %s", tt.query, got.Intent, tt.intent)
+		}
+		if got.TimeReference != tt.timeRef {
+			t.Errorf("ParseQuery(%q) time = %q, want %q", tt.query, got.TimeReference, tt.timeRef)
+		}
+	}
+}
+
+func TestParseQueryComparison(t *testing.T) {
+	got := ollama.ParseQuery("Is Miami warmer than Austin?")
+	if got.Intent != ollama.IntentComparison || got.Location() != "Miami, FL" {
+		t.Fatalf("got intent %s at %q, want comparison from Miami", got.Intent, got.Location())
+	}
+	if len(got.Others) != 1 || got.Others[0].Location() != "Austin, TX" {
+		t.Errorf("Others = %+v, want Austin", got.Others)
+	}
+}
+
+func TestParseQueryUnitsAndQuestion(t *testing.T) {
+	got := ollama.ParseQuery("How cold is it in Chicago in celsius?")
+	if got.Units != "metric" || !got.DirectQuestion {
+		t.Errorf("got units=%q direct=%v, want metric direct question", got.Units, got.DirectQuestion)
+	}
+	if got := ollama.ParseQuery("hello there"); got.HasLocation() {
+		t.Errorf("ParseQuery(hello there) found %q", got.Location())
+	}
+}
+
+func TestExtractStrategies(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  ollama.ExtractStrategy
+		query     string
+		script    ollamatest.Response
+		want      string
+		wantErr   error
+		wantCalls int
+	}{
+		{"auto fast path", ollama.ExtractAuto, "weather in Miami, FL", ollamatest.JSONReply(miami), "Miami, FL", nil, 0},
+		{"auto asks model when unsure", ollama.ExtractAuto, "weather in kendall", ollamatest.JSONReply(miami), "Miami, FL", nil, 1},
+		{"auto falls back to rules", ollama.ExtractAuto, "weather in kendall", ollamatest.Status(http.StatusNotFound, "no such model"), "Kendall", nil, 1},
+		{"llm always asks", ollama.ExtractLLM, "weather in Miami, FL", ollamatest.JSONReply(miami), "Miami, FL", nil, 1},
+		{"rules never ask", ollama.ExtractRules, "weather in Miami, FL", ollamatest.JSONReply(miami), "Miami, FL", nil, 0},
+		{"rules with nothing found", ollama.ExtractRules, "hello there", ollamatest.JSONReply(miami), "", ollama.ErrNoLocation, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, ai, _ := newClient(t, ollama.WithExtractStrategy(tt.strategy))
+			ai.Chat(tt.script)
+
+			got, err := client.ExtractLocation(tt.query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractLocation = %q, want %q", got, tt.want)
+			}
+			if n := ai.Hits(ollamatest.ChatPath); n != tt.wantCalls {
+				t.Errorf("model called %d times, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNoAIUsesRules(t *testing.T) {
+	client, ai, _ := newClient(t, ollama.WithAI(false))
+
+	got, err := client.GetWeather("Will it rain in Miami tomorrow?", 0)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Location != "Miami, FL" || got.Forecast == nil {
+		t.Errorf("got %q with forecast %v, want Miami's forecast", got.Location, got.Forecast != nil)
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 0 {
+		t.Errorf("model called %d times with AI disabled", n)
+	}
+}
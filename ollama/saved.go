@@ -0,0 +1,96 @@
+package ollama
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// savedPlace is a name from the user's profile, such as "home" or
+// "cabin", and the location it stands for.
+type savedPlace struct {
+	name     string
+	location string
+	pattern  *regexp.Regexp
+}
+
+// WithPlaces lets queries use saved names for places: "weather at home" or
+// "cabin forecast". places maps each name to a location the weather
+// service knows, such as "Lake Tahoe, CA"; a saved name wins over the
+// rules and the model.
+func WithPlaces(places map[string]string) Option {
+	return func(c *Client) {
+		c.places = nil
+		for name, location := range places {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" || location == "" {
+				continue
+			}
+			// The name must open the query or follow a preposition, "is" or a
+			// possessive, so "drive home from Denver" isn't about home.
+			pattern := regexp.MustCompile(`(?i)(?:^\s*|\b(?:at|in|for|near|around|by|to|is|my|the|our)\s+)(?:(?:my|the|our)\s+)?` +
+				regexp.QuoteMeta(name) + `\b`)
+			c.places = append(c.places, savedPlace{name: name, location: location, pattern: pattern})
+		}
+		// Longer names first, so "beach house" wins over "beach".
+		sort.Slice(c.places, func(i, j int) bool {
+			if len(c.places[i].name) != len(c.places[j].name) {
+				return len(c.places[i].name) > len(c.places[j].name)
+			}
+			return c.places[i].name < c.places[j].name
+		})
+	}
+}
+
+// savedLocation returns the location of a saved name used in s.
+func (c *Client) savedLocation(s string) (Place, bool) {
+	for _, p := range c.places {
+		if p.pattern.MatchString(s) {
+			// The location is passed through whole, so the weather service
+			// finds it among its saved coordinates.
+			return Place{City: p.location}, true
+		}
+	}
+	return Place{}, false
+}
+
+// resolveSaved fills in e from saved names in query, including each side
+// of a comparison such as "is home warmer than the cabin". It reports
+// whether any were found.
+func (c *Client) resolveSaved(query string, e *Extraction) bool {
+	if len(c.places) == 0 {
+		return false
+	}
+
+	if ruleIntent(strings.ToLower(query)) == IntentComparison {
+		var places []Place
+		saved := false
+		for _, part := range comparisonPattern.Split(query, -1) {
+			if p, ok := c.savedLocation(part); ok {
+				places = append(places, p)
+				saved = true
+			} else if p, _ := findPlace(part); p.City != "" || p.Region != "" {
+				places = append(places, p)
+			}
+		}
+		if saved && len(places) >= 2 {
+			e.Place, e.Others = places[0], places[1:]
+			e.Lat, e.Lon = nil, nil
+			e.Intent = IntentComparison
+			e.Confidence = 1
+			return true
+		}
+	}
+
+	p, ok := c.savedLocation(query)
+	if !ok {
+		return false
+	}
+	e.Place, e.Others = p, nil
+	e.Lat, e.Lon = nil, nil
+	if e.Intent == IntentComparison {
+		e.Intent = IntentCurrent
+	}
+	e.Confidence = 1
+	return true
+}
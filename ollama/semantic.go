@@ -0,0 +1,228 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"learn-go/retry"
+)
+
+const (
+	// DefaultEmbedModel is the Ollama model used to embed queries.
+	DefaultEmbedModel = "nomic-embed-text"
+	// DefaultSemanticThreshold is the cosine similarity above which two
+	// queries are taken to name the same place.
+	DefaultSemanticThreshold = 0.92
+	// DefaultSemanticCacheSize is how many queries the cache keeps before
+	// forgetting the least recently used.
+	DefaultSemanticCacheSize = 1000
+)
+
+// SemanticCache remembers where earlier queries pointed, keyed by their
+// embedding, so a rephrased question ("how's New York today" after
+// "weather in NYC") skips location extraction. Only the place is reused;
+// the time, units and intent of each query are read from its own wording.
+type SemanticCache struct {
+	path      string
+	threshold float64
+	size      int
+
+	mu      sync.Mutex
+	entries []semanticEntry // least recently used first
+}
+
+type semanticEntry struct {
+	Query  string    `json:"query"`
+	Vector []float64 `json:"vector"`
+	Place  Place     `json:"place"`
+	Lat    *float64  `json:"lat,omitempty"`
+	Lon    *float64  `json:"lon,omitempty"`
+}
+
+// NewSemanticCache opens the cache stored at path, creating it on first
+// write. An empty path keeps the cache in memory. It holds at most size
+// queries, or DefaultSemanticCacheSize when size isn't positive.
+func NewSemanticCache(path string, threshold float64, size int) (*SemanticCache, error) {
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultSemanticThreshold
+	}
+	if size <= 0 {
+		size = DefaultSemanticCacheSize
+	}
+	c := &SemanticCache{path: path, threshold: threshold, size: size}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading semantic cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("decoding semantic cache %s: %w", path, err)
+	}
+	c.evict()
+	return c, nil
+}
+
+// Len reports how many queries the cache holds.
+func (c *SemanticCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// lookup returns the most similar stored entry if it clears the threshold,
+// marking it as recently used.
+func (c *SemanticCache) lookup(vec []float64) (semanticEntry, float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	best, bestSim := -1, 0.0
+	for i, e := range c.entries {
+		if sim := cosine(vec, e.Vector); sim > bestSim {
+			best, bestSim = i, sim
+		}
+	}
+	if best < 0 || bestSim < c.threshold {
+		return semanticEntry{}, bestSim, false
+	}
+	hit := c.entries[best]
+	c.entries = append(append(c.entries[:best], c.entries[best+1:]...), hit)
+	return hit, bestSim, true
+}
+
+// add stores an extraction, forgetting the least recently used query when
+// the cache is full, and writes the cache out if it has a path. The order
+// is saved too, so what was used recently survives a restart.
+func (c *SemanticCache) add(query string, vec []float64, e *Extraction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = append(c.entries, semanticEntry{Query: query, Vector: vec, Place: e.Place, Lat: e.Lat, Lon: e.Lon})
+	c.evict()
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("encoding semantic cache: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("writing semantic cache: %w", err)
+	}
+	return nil
+}
+
+// evict drops the least recently used entries beyond the size limit.
+func (c *SemanticCache) evict() {
+	if over := len(c.entries) - c.size; over > 0 {
+		c.entries = append([]semanticEntry(nil), c.entries[over:]...)
+	}
+}
+
+// cosine is the cosine similarity of a and b, or 0 when they can't be
+// compared.
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+type embeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type embeddingResponse struct {
+	Embedding []float64 `json:"embedding"`
+}
+
+// embed returns the embedding of text from Ollama's /embeddings endpoint.
+// Embedding shares the model server with chat, so it waits for a slot from
+// the same admission controller.
+func (c *Client) embed(text string) ([]float64, error) {
+	body, err := json.Marshal(embeddingRequest{Model: c.embedModel, Prompt: text})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling embedding request: %w", err)
+	}
+
+	ctx := context.Background()
+	release, err := c.admission.Acquire(ctx, c.priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var vec []float64
+	err = c.breaker.Do(func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/embeddings", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("ollama embeddings error: %w", retry.FromResponse(resp))
+		}
+		var out embeddingResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return fmt.Errorf("decoding embedding: %w", err)
+		}
+		vec = out.Embedding
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(vec) == 0 {
+		return nil, errors.New("empty embedding")
+	}
+	return vec, nil
+}
+
+// semanticLookup returns the place of a stored query similar to query,
+// with the rest of the extraction taken from rules.
+func (c *Client) semanticLookup(vec []float64, rules *Extraction) (*Extraction, bool) {
+	entry, _, ok := c.semantic.lookup(vec)
+	if !ok {
+		return nil, false
+	}
+	hit := *rules
+	hit.Place, hit.Lat, hit.Lon = entry.Place, entry.Lat, entry.Lon
+	hit.Others = nil
+	hit.Confidence = 1
+	return &hit, true
+}
+
+// normalizeQuery trims and lowercases a query before it is embedded.
+func normalizeQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
@@ -0,0 +1,78 @@
+package ollama
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabeledQuery is a query paired with the location it should resolve to,
+// for evaluating the semantic cache.
+type LabeledQuery struct {
+	Query    string `json:"query"`
+	Location string `json:"location"`
+}
+
+// SemanticEval summarises how the semantic cache would have behaved at one
+// threshold.
+type SemanticEval struct {
+	Threshold float64 `json:"threshold"`
+	Queries   int     `json:"queries"`
+	// Hits counts queries answered from the cache; FalseHits those whose
+	// reused location differed from the label.
+	Hits      int `json:"hits"`
+	FalseHits int `json:"false_hits"`
+}
+
+// HitRate is the share of queries answered from the cache.
+func (e SemanticEval) HitRate() float64 {
+	if e.Queries == 0 {
+		return 0
+	}
+	return float64(e.Hits) / float64(e.Queries)
+}
+
+// FalseHitRate is the share of cache hits that reused the wrong location.
+func (e SemanticEval) FalseHitRate() float64 {
+	if e.Hits == 0 {
+		return 0
+	}
+	return float64(e.FalseHits) / float64(e.Hits)
+}
+
+// EvaluateSemanticCache replays queries in order as if each had been
+// answered and cached with its labelled location, and reports for each
+// threshold how many later queries would have hit the cache and how many
+// of those hits would have been wrong. Embeddings come from the client's
+// embedding model.
+func (c *Client) EvaluateSemanticCache(queries []LabeledQuery, thresholds []float64) ([]SemanticEval, error) {
+	vectors := make([][]float64, len(queries))
+	for i, q := range queries {
+		vec, err := c.embed(normalizeQuery(q.Query))
+		if err != nil {
+			return nil, fmt.Errorf("embedding %q: %w", q.Query, err)
+		}
+		vectors[i] = vec
+	}
+
+	results := make([]SemanticEval, len(thresholds))
+	for t, threshold := range thresholds {
+		r := SemanticEval{Threshold: threshold, Queries: len(queries)}
+		for j := range queries {
+			best, bestSim := -1, 0.0
+			for i := 0; i < j; i++ {
+				if sim := cosine(vectors[i], vectors[j]); sim > bestSim {
+					best, bestSim = i, sim
+				}
+			}
+			if best < 0 || bestSim < threshold {
+				continue
+			}
+			r.Hits++
+			if !strings.EqualFold(queries[best].Location, queries[j].Location) {
+				r.FalseHits++
+			}
+		}
+		results[t] = r
+	}
+	return results, nil
+}
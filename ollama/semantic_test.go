@@ -0,0 +1,196 @@
+package ollama_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+)
+
+var newYork = map[string]interface{}{
+	"city": "New York", "region": "NY", "country": "US",
+	"time_reference": "now", "units": "", "intent": "current", "confidence": 0.9,
+}
+
+func newSemanticClient(t *testing.T, path string) (*ollama.Client, *ollamatest.Server, *ollama.SemanticCache) {
+	t.Helper()
+	cache, err := ollama.NewSemanticCache(path, 0.9, 0)
+	if err != nil {
+		t.Fatalf("NewSemanticCache: %v", err)
+	}
+	client, ai, _ := newClient(t, ollama.WithSemanticCache(cache))
+	return client, ai, cache
+}
+
+func TestSemanticCacheReusesSimilarQuery(t *testing.T) {
+	client, ai, cache := newSemanticClient(t, "")
+	ai.Chat(ollamatest.JSONReply(newYork))
+	ai.Embeddings(
+		ollamatest.Embedding(1, 0, 0),
+		ollamatest.Embedding(0.98, 0.1, 0),
+	)
+
+	first, err := client.ExtractLocation("weather in NYC")
+	if err != nil {
+		t.Fatalf("ExtractLocation: %v", err)
+	}
+	if first != "New York, NY" || cache.Len() != 1 {
+		t.Fatalf("first = %q with %d cached, want New York stored", first, cache.Len())
+	}
+
+	got, err := client.ExtractLocation("how's new york tomorrow")
+	if err != nil {
+		t.Fatalf("ExtractLocation: %v", err)
+	}
+	if got != "New York, NY" {
+		t.Errorf("ExtractLocation = %q, want the cached place", got)
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 1 {
+		t.Errorf("model called %d times, want 1", n)
+	}
+
+	reqs := ai.Requests()
+	if len(reqs) == 0 || reqs[0].Path != ollamatest.EmbeddingsPath {
+		t.Fatalf("first request = %+v, want an embedding", reqs)
+	}
+	if body := string(reqs[0].Body); body != `{"model":"nomic-embed-text","prompt":"weather in nyc"}` {
+		t.Errorf("embedding request = %s", body)
+	}
+}
+
+func TestSemanticCacheMissBelowThreshold(t *testing.T) {
+	client, ai, cache := newSemanticClient(t, "")
+	ai.Chat(ollamatest.JSONReply(newYork), ollamatest.JSONReply(miami))
+	ai.Embeddings(ollamatest.Embedding(1, 0, 0), ollamatest.Embedding(0, 1, 0))
+
+	client.ExtractLocation("weather in NYC")
+	got, err := client.ExtractLocation("weather in Miami")
+	if err != nil {
+		t.Fatalf("ExtractLocation: %v", err)
+	}
+	if got != "Miami, FL" || cache.Len() != 2 {
+		t.Errorf("ExtractLocation = %q with %d cached, want Miami from the model", got, cache.Len())
+	}
+}
+
+func TestSemanticCacheWithoutEmbeddings(t *testing.T) {
+	// The fake answers embedding requests with a 404 until scripted.
+	client, ai, cache := newSemanticClient(t, "")
+	ai.Chat(ollamatest.JSONReply(newYork))
+
+	got, err := client.ExtractLocation("weather in NYC")
+	if err != nil || got != "New York, NY" {
+		t.Fatalf("ExtractLocation = %q, %v, want the model's answer", got, err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("cached %d entries without an embedding", cache.Len())
+	}
+}
+
+func TestSemanticCachePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "semantic.json")
+	client, ai, _ := newSemanticClient(t, path)
+	ai.Chat(ollamatest.JSONReply(newYork))
+	ai.Embeddings(ollamatest.Embedding(1, 0, 0))
+
+	if _, err := client.ExtractLocation("weather in NYC"); err != nil {
+		t.Fatalf("ExtractLocation: %v", err)
+	}
+
+	reopened, err := ollama.NewSemanticCache(path, 0.9, 0)
+	if err != nil {
+		t.Fatalf("NewSemanticCache: %v", err)
+	}
+	if reopened.Len() != 1 {
+		t.Errorf("reopened cache has %d entries, want 1", reopened.Len())
+	}
+}
+
+func TestSemanticCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, err := ollama.NewSemanticCache("", 0.9, 2)
+	if err != nil {
+		t.Fatalf("NewSemanticCache: %v", err)
+	}
+	client, ai, _ := newClient(t, ollama.WithSemanticCache(cache))
+	ai.Chat(ollamatest.JSONReply(newYork), ollamatest.JSONReply(miami), ollamatest.JSONReply(newYork), ollamatest.JSONReply(miami))
+	ai.Embeddings(
+		ollamatest.Embedding(1, 0, 0),      // weather in NYC
+		ollamatest.Embedding(0, 1, 0),      // weather in Miami
+		ollamatest.Embedding(0.99, 0.1, 0), // how's New York: a hit, so NYC is kept
+		ollamatest.Embedding(0, 0, 1),      // weather in Boston: evicts Miami
+		ollamatest.Embedding(0, 1, 0),      // weather in Miami again
+	)
+
+	for _, q := range []string{"weather in NYC", "weather in Miami", "how's New York", "weather in Boston", "weather in Miami"} {
+		if _, err := client.ExtractLocation(q); err != nil {
+			t.Fatalf("ExtractLocation(%q): %v", q, err)
+		}
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 4 {
+		t.Errorf("model called %d times, want 4 with Miami evicted", n)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("cache holds %d entries, want 2", cache.Len())
+	}
+}
+
+func TestSemanticCacheEmbeddingWaitsForAdmission(t *testing.T) {
+	cache, err := ollama.NewSemanticCache("", 0.9, 0)
+	if err != nil {
+		t.Fatalf("NewSemanticCache: %v", err)
+	}
+	admission := ollama.NewAdmission(ollama.AdmissionSettings{MaxInFlight: 1, MaxQueue: 0})
+	client, ai, _ := newClient(t, ollama.WithSemanticCache(cache), ollama.WithAdmission(admission))
+	ai.Chat(ollamatest.JSONReply(newYork))
+	ai.Embeddings(ollamatest.Embedding(1, 0, 0))
+
+	release, err := admission.Acquire(context.Background(), ollama.PriorityInteractive)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, err := client.ExtractLocation("weather in NYC"); !errors.Is(err, ollama.ErrOverloaded) {
+		t.Errorf("ExtractLocation with Ollama busy = %v, want overloaded", err)
+	}
+	if n := ai.Hits(ollamatest.EmbeddingsPath); n != 0 {
+		t.Errorf("embedding requested %d times past a full admission queue", n)
+	}
+	release()
+
+	if _, err := client.ExtractLocation("weather in NYC"); err != nil {
+		t.Fatalf("ExtractLocation: %v", err)
+	}
+	if n := ai.Hits(ollamatest.EmbeddingsPath); n != 1 {
+		t.Errorf("embedding requested %d times, want 1", n)
+	}
+}
+
+func TestEvaluateSemanticCache(t *testing.T) {
+	client, ai, _ := newSemanticClient(t, "")
+	ai.Embeddings(
+		ollamatest.Embedding(1, 0, 0),      // weather in NYC
+		ollamatest.Embedding(0.99, 0.1, 0), // how's New York today
+		ollamatest.Embedding(0.9, 0.4, 0),  // weather in Newark
+		ollamatest.Embedding(0, 0, 1),      // Miami weather
+	)
+
+	results, err := client.EvaluateSemanticCache([]ollama.LabeledQuery{
+		{Query: "weather in NYC", Location: "New York, NY"},
+		{Query: "how's New York today", Location: "new york, ny"},
+		{Query: "weather in Newark", Location: "Newark, NJ"},
+		{Query: "Miami weather", Location: "Miami, FL"},
+	}, []float64{0.9, 0.99})
+	if err != nil {
+		t.Fatalf("EvaluateSemanticCache: %v", err)
+	}
+
+	loose, strict := results[0], results[1]
+	if loose.Hits != 2 || loose.FalseHits != 1 || loose.FalseHitRate() != 0.5 {
+		t.Errorf("at 0.9: %+v, want 2 hits with Newark a false hit", loose)
+	}
+	if strict.Hits != 1 || strict.FalseHits != 0 || strict.HitRate() != 0.25 {
+		t.Errorf("at 0.99: %+v, want only the New York rephrasing to hit", strict)
+	}
+}
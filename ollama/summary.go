@@ -0,0 +1,47 @@
+package ollama
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"learn-go/weather"
+)
+
+// ErrAIDisabled is returned by calls that need the model when it has been
+// turned off with WithAI(false).
+var ErrAIDisabled = errors.New("model disabled")
+
+const alertSummaryPrompt = `You explain National Weather Service alerts to the public. In two or three short sentences of plain language,
+say what is happening, where and until when, and what people should do. Use only the alert provided, and no headings or lists.`
+
+// SummarizeAlert asks the model for a plain-language summary of one alert,
+// for notifications read by people who won't open the full text.
+func (c *Client) SummarizeAlert(alert weather.Alert, maxRetries int) (string, error) {
+	if !c.ai {
+		return "", ErrAIDisabled
+	}
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return "", fmt.Errorf("marshaling alert: %w", err)
+	}
+
+	req := OllamaRequest{
+		Model:  c.model,
+		Stream: false,
+		Options: &Options{
+			Temperature: 0.3,
+			Seed:        42,
+		},
+		Messages: []ChatMessage{
+			{Role: "system", Content: alertSummaryPrompt},
+			{Role: "user", Content: "Summarize this alert: " + string(data)},
+		},
+	}
+	summary, err := c.getAIResponse(req, maxRetries)
+	if err != nil {
+		return "", fmt.Errorf("summarizing alert: %w", err)
+	}
+	return strings.TrimSpace(summary), nil
+}
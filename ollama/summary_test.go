@@ -0,0 +1,33 @@
+package ollama_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+	"learn-go/weather"
+)
+
+func TestSummarizeAlert(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(ollamatest.Reply("  Flooding is possible in Miami until Thursday morning. Avoid driving through water.\n"))
+
+	alert := weather.Alert{ID: "urn:1", Event: "Flood Watch", Severity: "Severe", Area: "Miami-Dade"}
+	got, err := client.SummarizeAlert(alert, 0)
+	if err != nil {
+		t.Fatalf("SummarizeAlert: %v", err)
+	}
+	if got != "Flooding is possible in Miami until Thursday morning. Avoid driving through water." {
+		t.Errorf("summary = %q", got)
+	}
+	if prompt := ai.ChatRequests()[0].Messages[1].Content; !strings.Contains(prompt, `"event":"Flood Watch"`) {
+		t.Errorf("prompt = %q, want the alert", prompt)
+	}
+
+	noAI, _, _ := newClient(t, ollama.WithAI(false))
+	if _, err := noAI.SummarizeAlert(alert, 0); !errors.Is(err, ollama.ErrAIDisabled) {
+		t.Errorf("SummarizeAlert without the model = %v, want ErrAIDisabled", err)
+	}
+}
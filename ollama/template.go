@@ -0,0 +1,63 @@
+package ollama
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"learn-go/weather"
+)
+
+// Describers record what produced a WeatherResponse's description.
+const (
+	DescriberAI       = "ai"
+	DescriberTemplate = "template"
+)
+
+// templateDescription describes resp without the model. It is used when
+// the model is disabled or unavailable.
+func templateDescription(resp *WeatherResponse) string {
+	switch {
+	case resp.Comparison != nil:
+		return describeComparison(resp.Comparison)
+	case resp.Forecast != nil:
+		return weather.DescribeForecast(resp.Location, resp.Forecast)
+	case resp.Intent == IntentAlerts:
+		return weather.DescribeAlerts(resp.Location, resp.Alerts)
+	case resp.Weather != nil:
+		return weather.Describe(resp.Location, resp.Weather)
+	default:
+		return ""
+	}
+}
+
+func describeComparison(places []PlaceWeather) string {
+	parts := make([]string, 0, len(places))
+	var warmest *PlaceWeather
+	for i, p := range places {
+		if p.Weather.TemperatureMissing {
+			parts = append(parts, fmt.Sprintf("%s is not reporting a temperature", p.Location))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s is %.0f°F and %s", p.Location, math.Round(p.Weather.Temperature), strings.ToLower(p.Weather.Conditions)))
+		if warmest == nil || p.Weather.Temperature > warmest.Weather.Temperature {
+			warmest = &places[i]
+		}
+	}
+	if warmest == nil {
+		return strings.Join(parts, "; ") + "."
+	}
+	return fmt.Sprintf("%s. %s is the warmest.", strings.Join(parts, "; "), warmest.Location)
+}
+
+// degrade replaces a failed model call with the template description and
+// records why.
+func degrade(resp *WeatherResponse, cause error) *WeatherResponse {
+	resp.Answer = nil
+	resp.Description = templateDescription(resp)
+	resp.Describer = DescriberTemplate
+	if cause != nil {
+		resp.DescriberError = cause.Error()
+	}
+	return resp
+}
@@ -0,0 +1,124 @@
+package ollama_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/breaker"
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+	"learn-go/weather/weathertest"
+)
+
+func TestGetWeatherDataDegradesWhenModelFails(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(ollamatest.Status(http.StatusServiceUnavailable, "model is loading"))
+
+	got, err := client.GetWeatherData("Miami, FL", 0)
+	if err != nil {
+		t.Fatalf("GetWeatherData: %v", err)
+	}
+	if got.Describer != ollama.DescriberTemplate {
+		t.Errorf("Describer = %q, want template", got.Describer)
+	}
+	if got.Weather == nil || got.Weather.Temperature != 77 {
+		t.Errorf("Weather = %+v, want the fetched observation", got.Weather)
+	}
+	if !strings.HasPrefix(got.Description, "Partly Cloudy in Miami, FL at 77°F") {
+		t.Errorf("Description = %q", got.Description)
+	}
+	if !strings.Contains(got.DescriberError, "model is loading") {
+		t.Errorf("DescriberError = %q, want the model failure", got.DescriberError)
+	}
+}
+
+func TestGetWeatherAnswerDegradesWhenModelFails(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(directQuestion(), ollamatest.Status(http.StatusInternalServerError, "out of memory"))
+
+	got, err := client.GetWeather("Is it raining in Miami?", 0)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Answer != nil || got.Describer != ollama.DescriberTemplate || got.Description == "" {
+		t.Errorf("got answer=%+v describer=%q description=%q, want template description only", got.Answer, got.Describer, got.Description)
+	}
+}
+
+func TestGetWeatherDataSkipsModelWhileBreakerOpen(t *testing.T) {
+	client, ai, _ := newClient(t, ollama.WithBreakerSettings(breaker.Settings{Failures: 2, Cooldown: time.Hour}))
+	ai.Chat(ollamatest.Status(http.StatusBadGateway, "down"))
+
+	if _, err := client.GetWeatherData("Miami, FL", 1); err != nil {
+		t.Fatalf("GetWeatherData: %v", err)
+	}
+	if got := client.Breakers()[0].State(); got != breaker.Open {
+		t.Fatalf("breaker %v after two failures, want open", got)
+	}
+
+	got, err := client.GetWeatherData("Miami, FL", 3)
+	if err != nil {
+		t.Fatalf("GetWeatherData: %v", err)
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 2 {
+		t.Errorf("model called %d times, want no calls while the breaker is open", n)
+	}
+	if got.Describer != ollama.DescriberTemplate || !strings.Contains(got.DescriberError, "circuit breaker open") {
+		t.Errorf("got describer=%q error=%q, want template after open breaker", got.Describer, got.DescriberError)
+	}
+}
+
+func TestNoAI(t *testing.T) {
+	client, ai, nws := newClient(t, ollama.WithAI(false))
+
+	got, err := client.GetWeather("Miami, FL", 3)
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Describer != ollama.DescriberTemplate || got.DescriberError != "" {
+		t.Errorf("got describer=%q error=%q, want a plain template", got.Describer, got.DescriberError)
+	}
+	if n := ai.Hits(ollamatest.ChatPath); n != 0 {
+		t.Errorf("model called %d times with AI disabled", n)
+	}
+	if n := nws.Hits(weathertest.ObservationPath); n != 1 {
+		t.Errorf("observation fetched %d times, want 1", n)
+	}
+}
+
+func TestTemplateDescriptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		extraction ollamatest.Response
+		alerts     string
+		want       string
+	}{
+		{"hourly", extraction("hourly", "now"), weathertest.NoAlertsJSON,
+			"Partly Sunny in Miami, FL from Wed 10AM to Wed 1PM, with temperatures between 76°F and 79°F. Highest chance of precipitation is 15% around 12PM."},
+		{"daily", extraction("daily", "now"), weathertest.NoAlertsJSON,
+			"Forecast for Miami, FL. This Afternoon: Partly Sunny, high near 79°F, 10% chance of precipitation."},
+		{"no alerts", extraction("alerts", "now"), weathertest.NoAlertsJSON,
+			"There are no active weather alerts for Miami, FL."},
+		{"alerts", extraction("alerts", "now"), weathertest.AlertsJSON,
+			"1 active alert for Miami, FL: Rip Current Statement (moderate) until 2025-01-08T21:00:00-05:00."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := time.FixedZone("EST", -5*60*60)
+			client, ai, nws := newClient(t, ollama.WithClock(func() time.Time { return time.Date(2025, 1, 8, 9, 0, 0, 0, est) }))
+			nws.Handle(weathertest.AlertsPath, weathertest.Body(tt.alerts))
+			ai.Chat(tt.extraction, ollamatest.Status(http.StatusServiceUnavailable, "down"))
+
+			got, err := client.GetWeather("Miami", 0)
+			if err != nil {
+				t.Fatalf("GetWeather: %v", err)
+			}
+			if !strings.HasPrefix(got.Description, tt.want) {
+				t.Errorf("Description = %q, want prefix %q", got.Description, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package ollama
+
+import (
+	"fmt"
+	"strings"
+
+	"learn-go/guard"
+	"learn-go/weather"
+)
+
+// Verification statuses.
+const (
+	// VerificationPassed means the first description matched the data.
+	VerificationPassed = "passed"
+	// VerificationCorrected means a later attempt matched after the first
+	// one was rejected.
+	VerificationCorrected = "corrected"
+	// VerificationFallback means no model output matched and the
+	// description was generated from a template instead.
+	VerificationFallback = "fallback"
+)
+
+// Verification records how a description was checked against the data it
+// was generated from.
+type Verification struct {
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	// Issues are the mismatches found in rejected drafts.
+	Issues []guard.Issue `json:"issues,omitempty"`
+}
+
+// verify checks description against resp.Weather. On a mismatch the model
+// is first asked to correct the specific statements, then asked again from
+// scratch at a low temperature, and finally replaced by the template
+// description.
+func (c *Client) verify(req OllamaRequest, description string, resp *WeatherResponse, maxRetries int) (string, *Verification) {
+	v := &Verification{Status: VerificationPassed, Attempts: 1}
+
+	issues := guard.Check(description, resp.Weather)
+	if len(issues) == 0 {
+		return description, v
+	}
+	v.Issues = append(v.Issues, issues...)
+
+	correction := req
+	correction.Messages = append(append([]ChatMessage(nil), req.Messages...),
+		ChatMessage{Role: "assistant", Content: description},
+		ChatMessage{Role: "user", Content: correctionPrompt(issues)},
+	)
+
+	cold := req
+	cold.Options = &Options{Temperature: 0, Seed: 42}
+	if req.Options != nil {
+		cold.Options.Seed = req.Options.Seed
+	}
+
+	for _, retry := range []OllamaRequest{correction, cold} {
+		v.Attempts++
+		draft, err := c.getAIResponse(retry, maxRetries)
+		if err != nil {
+			break
+		}
+		issues := guard.Check(draft, resp.Weather)
+		if len(issues) == 0 {
+			v.Status = VerificationCorrected
+			return draft, v
+		}
+		v.Issues = append(v.Issues, issues...)
+	}
+
+	v.Status = VerificationFallback
+	return weather.Describe(resp.Location, resp.Weather), v
+}
+
+func correctionPrompt(issues []guard.Issue) string {
+	var b strings.Builder
+	b.WriteString("Some statements in that description contradict the data:\n")
+	for _, issue := range issues {
+		fmt.Fprintf(&b, "- %s\n", issue)
+	}
+	b.WriteString("Rewrite the description using only values exactly as they appear in the data.")
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package ollama_test
+
+import (
+	"strings"
+	"testing"
+
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+)
+
+func TestDescriptionVerification(t *testing.T) {
+	tests := []struct {
+		name         string
+		replies      []ollamatest.Response
+		wantStatus   string
+		wantAttempts int
+		wantDesc     string
+	}{
+		{
+			name:         "passes",
+			replies:      []ollamatest.Response{ollamatest.Reply("77°F and partly cloudy.")},
+			wantStatus:   ollama.VerificationPassed,
+			wantAttempts: 1,
+			wantDesc:     "77°F and partly cloudy.",
+		},
+		{
+			name: "corrected on re-prompt",
+			replies: []ollamatest.Response{
+				ollamatest.Reply("Around 80°F and partly cloudy."),
+				ollamatest.Reply("77°F and partly cloudy."),
+			},
+			wantStatus:   ollama.VerificationCorrected,
+			wantAttempts: 2,
+			wantDesc:     "77°F and partly cloudy.",
+		},
+		{
+			name: "corrected at low temperature",
+			replies: []ollamatest.Response{
+				ollamatest.Reply("80°F with rain."),
+				ollamatest.Reply("79°F with rain."),
+				ollamatest.Reply("A partly cloudy 77 degrees."),
+			},
+			wantStatus:   ollama.VerificationCorrected,
+			wantAttempts: 3,
+			wantDesc:     "A partly cloudy 77 degrees.",
+		},
+		{
+			name:         "falls back to template",
+			replies:      []ollamatest.Response{ollamatest.Reply("Heavy snow and 20°F.")},
+			wantStatus:   ollama.VerificationFallback,
+			wantAttempts: 3,
+			wantDesc:     "Partly Cloudy in Miami, FL at 77°F, with 70% humidity. Wind at 9 mph.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, ai, _ := newClient(t)
+			ai.Chat(tt.replies...)
+
+			got, err := client.GetWeatherData("Miami, FL", 0)
+			if err != nil {
+				t.Fatalf("GetWeatherData: %v", err)
+			}
+			v := got.Verification
+			if v == nil {
+				t.Fatal("Verification not recorded")
+			}
+			if v.Status != tt.wantStatus || v.Attempts != tt.wantAttempts {
+				t.Errorf("Verification = %s after %d attempts, want %s after %d", v.Status, v.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if got.Description != tt.wantDesc {
+				t.Errorf("Description = %q, want %q", got.Description, tt.wantDesc)
+			}
+			if tt.wantStatus != ollama.VerificationPassed && len(v.Issues) == 0 {
+				t.Error("rejected drafts recorded no issues")
+			}
+		})
+	}
+}
+
+func TestDescriptionCorrectionPrompt(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(ollamatest.Reply("A balmy 85°F."), ollamatest.Reply("77°F."))
+
+	if _, err := client.GetWeatherData("Miami, FL", 0); err != nil {
+		t.Fatalf("GetWeatherData: %v", err)
+	}
+
+	reqs := ai.ChatRequests()
+	msgs := reqs[1].Messages
+	if got := msgs[len(msgs)-1].Content; !strings.Contains(got, `"85°F"`) || !strings.Contains(got, "77.0°F") {
+		t.Errorf("correction prompt = %q, want the bad claim and the observed value", got)
+	}
+}
+
+func TestDescriptionGuardDisabled(t *testing.T) {
+	client, ai, _ := newClient(t, ollama.WithGuard(false))
+	ai.Chat(ollamatest.Reply("A balmy 85°F."))
+
+	got, err := client.GetWeatherData("Miami, FL", 0)
+	if err != nil {
+		t.Fatalf("GetWeatherData: %v", err)
+	}
+	if got.Verification != nil || got.Description != "A balmy 85°F." {
+		t.Errorf("got %q with verification %+v, want the unchecked description", got.Description, got.Verification)
+	}
+}
+
+func TestDescriptionRegeneratedCold(t *testing.T) {
+	client, ai, _ := newClient(t)
+	ai.Chat(ollamatest.Reply("80°F with rain."), ollamatest.Reply("79°F with rain."), ollamatest.Reply("77°F."))
+
+	if _, err := client.GetWeatherData("Miami, FL", 0); err != nil {
+		t.Fatalf("GetWeatherData: %v", err)
+	}
+
+	reqs := ai.ChatRequests()
+	if len(reqs) != 3 {
+		t.Fatalf("got %d chat requests, want 3", len(reqs))
+	}
+	if temp, ok := reqs[2].Options["temperature"]; !ok || temp != 0.0 {
+		t.Errorf("regeneration options = %v, want temperature 0", reqs[2].Options)
+	}
+	if temp := reqs[0].Options["temperature"]; temp != 0.7 {
+		t.Errorf("first request temperature = %v, want 0.7", temp)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"learn-go/ollama"
+	"learn-go/profile"
+	"learn-go/render"
+	"learn-go/weather"
+)
+
+// profileUsage lists the profile subcommands.
+const profileUsage = "profile [home <location> | save <name> <location> | remove <name> | units <units|default> | output <format|template|default>]"
+
+// profileCmd shows or edits the user's profile.
+func (a *app) profileCmd(args []string) error {
+	fs, o := a.flags("profile")
+	positional, weatherSvc, _, err := a.setup(fs, o, args, ollama.PriorityInteractive)
+	if err != nil {
+		return helpOK(err)
+	}
+	if len(positional) == 0 {
+		a.showProfile()
+		return nil
+	}
+
+	p := a.profile
+	verb, rest := positional[0], positional[1:]
+	switch verb {
+	case "home":
+		if len(rest) == 0 {
+			return usagef("profile home: missing location")
+		}
+		return a.savePlace(weatherSvc, profile.Home, strings.Join(rest, " "))
+	case "save":
+		if len(rest) < 2 {
+			return usagef("profile save: want a name and a location")
+		}
+		return a.savePlace(weatherSvc, rest[0], strings.Join(rest[1:], " "))
+	case "remove":
+		if len(rest) == 0 {
+			return usagef("profile remove: missing name")
+		}
+		name := strings.Join(rest, " ")
+		if !p.Remove(name) {
+			return fmt.Errorf("no place saved as %q", name)
+		}
+	case "units":
+		if len(rest) != 1 {
+			return usagef("profile units: want imperial, metric or default")
+		}
+		var units render.Units
+		if rest[0] != "default" {
+			if units, err = render.ParseUnits(rest[0]); err != nil {
+				return &usageError{msg: err.Error()}
+			}
+		}
+		p.Units = string(units)
+	case "output":
+		if len(rest) != 1 {
+			return usagef("profile output: want a format, a built-in template or default")
+		}
+		output := ""
+		if rest[0] != "default" {
+			if err := checkOutput(rest[0]); err != nil {
+				return err
+			}
+			output = rest[0]
+		}
+		p.Output = output
+	default:
+		return usagef("usage: weather %s", profileUsage)
+	}
+	if err := p.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Saved %s\n", p.Path())
+	return nil
+}
+
+// savePlace geocodes location once and saves its coordinates as name.
+func (a *app) savePlace(weatherSvc *weather.NWSService, name, location string) error {
+	geo, err := weatherSvc.Locate(location)
+	if err != nil {
+		return err
+	}
+	lat, lon := geo.Coordinates()
+	place := profile.Place{Name: strings.TrimSpace(location), Lat: lat, Lon: lon}
+	if err := a.profile.Set(name, place); err != nil {
+		return &usageError{msg: err.Error()}
+	}
+	if err := a.profile.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Saved %s as %s (%.4f, %.4f)\n", place.Name, strings.ToLower(name), lat, lon)
+	return nil
+}
+
+// checkOutput accepts an -output format or a built-in template name.
+func checkOutput(output string) error {
+	if _, ok := render.Builtin(output); ok {
+		return nil
+	}
+	if _, err := render.New(output, render.Options{}); err != nil {
+		return usagef("unknown output %q (want one of %s, or a template: %s)", output, strings.Join(render.Formats, ", "), strings.Join(render.BuiltinNames(), ", "))
+	}
+	return nil
+}
+
+func (a *app) showProfile() {
+	p := a.profile
+	path := p.Path()
+	if path == "" {
+		path = "(not saved: no configuration directory)"
+	}
+	fmt.Fprintf(a.stdout, "Profile: %s\n", path)
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	places := p.Places()
+	for _, name := range p.Names() {
+		place := places[name]
+		fmt.Fprintf(w, "  %s\t%s\t(%.4f, %.4f)\n", name, place.Name, place.Lat, place.Lon)
+	}
+	w.Flush()
+	if len(places) == 0 {
+		fmt.Fprintln(a.stdout, "  No saved places; add one with 'weather profile home <location>'.")
+	}
+	fmt.Fprintf(a.stdout, "Units: %s\n", orDefault(p.Units))
+	fmt.Fprintf(a.stdout, "Output: %s\n", orDefault(p.Output))
+}
+
+func orDefault(s string) string {
+	if s == "" {
+		return "default"
+	}
+	return s
+}
+
+// preferences fills in -units and -output from the profile when neither
+// the command line nor the environment chose them.
+func (a *app) preferences(set map[string]bool, o *options) {
+	p := a.profile
+	if p.Units != "" && !set["units"] && os.Getenv("WEATHER_UNITS") == "" {
+		o.units = p.Units
+	}
+	if p.Output != "" && !set["output"] && !set["template"] && !set["template-file"] && os.Getenv("WEATHER_OUTPUT") == "" {
+		if _, ok := render.Builtin(p.Output); ok {
+			o.template = p.Output
+		} else {
+			o.output = p.Output
+		}
+	}
+}
+
+// placeOptions pass the profile's saved places to the weather service and
+// the extractor, and center disambiguation on home.
+func (a *app) placeOptions() ([]weather.Option, []ollama.Option) {
+	places := a.profile.Places()
+	if len(places) == 0 {
+		return nil, nil
+	}
+	geo := make(map[string]weather.GeoLocation, len(places))
+	names := make(map[string]string, len(places))
+	for name, place := range places {
+		geo[place.Name] = weather.NewGeoLocation(place.Lat, place.Lon)
+		names[name] = place.Name
+	}
+	weatherOpts := []weather.Option{weather.WithPlaces(geo)}
+	if home := a.profile.Home; home != nil {
+		weatherOpts = append(weatherOpts, weather.WithNear(home.Lat, home.Lon))
+	}
+	return weatherOpts, []ollama.Option{ollama.WithPlaces(names)}
+}
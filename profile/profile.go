@@ -0,0 +1,195 @@
+// Package profile stores a user's saved places and display preferences in
+// a JSON file, so "weather at home" works without naming the city and
+// every run starts in the user's preferred units and format.
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FileName is the profile's name in the configuration directory.
+const FileName = "profile.json"
+
+// Home is the saved name of the home location.
+const Home = "home"
+
+// Place is a saved location. Its coordinates are found when it is saved,
+// so using it needs no geocoding.
+type Place struct {
+	// Name is the location as the user gave it, such as "Lake Tahoe, CA".
+	Name string  `json:"name"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+// Profile is the user's profile. The zero value is an empty profile that
+// isn't saved anywhere.
+type Profile struct {
+	Home *Place `json:"home,omitempty"`
+	// Favorites are other saved places by name, such as "office" or
+	// "cabin". Names are lower case.
+	Favorites map[string]Place `json:"favorites,omitempty"`
+	// Units and Output are the preferred -units and -output; Output may
+	// also name a built-in template.
+	Units  string `json:"units,omitempty"`
+	Output string `json:"output,omitempty"`
+
+	path string
+}
+
+// Load reads the profile at path. A missing file is an empty profile,
+// which Save creates.
+func Load(path string) (*Profile, error) {
+	p := &Profile{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading profile: %w", err)
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("reading profile %s: %w", path, err)
+	}
+
+	favorites := make(map[string]Place, len(p.Favorites))
+	for name, place := range p.Favorites {
+		favorites[normalize(name)] = place
+	}
+	p.Favorites = favorites
+	return p, nil
+}
+
+// Path returns the file the profile is saved to.
+func (p *Profile) Path() string {
+	return p.path
+}
+
+// Save writes the profile back to its file, replacing it in one step so
+// an interrupted write can't leave it half written.
+func (p *Profile) Save() error {
+	if p.path == "" {
+		return errors.New("saving profile: no configuration directory")
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("saving profile: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o700); err != nil {
+		return fmt.Errorf("saving profile: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), ".profile-*.json")
+	if err != nil {
+		return fmt.Errorf("saving profile: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving profile: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving profile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		return fmt.Errorf("saving profile: %w", err)
+	}
+	return nil
+}
+
+// Places returns every saved place by name, home included.
+func (p *Profile) Places() map[string]Place {
+	places := make(map[string]Place, len(p.Favorites)+1)
+	for name, place := range p.Favorites {
+		places[name] = place
+	}
+	if p.Home != nil {
+		places[Home] = *p.Home
+	}
+	return places
+}
+
+// Names lists the saved names, home first and the rest in alphabetical
+// order.
+func (p *Profile) Names() []string {
+	var names []string
+	for name := range p.Favorites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if p.Home != nil {
+		names = append([]string{Home}, names...)
+	}
+	return names
+}
+
+// Lookup returns the place saved as name, ignoring case.
+func (p *Profile) Lookup(name string) (Place, bool) {
+	name = normalize(name)
+	if name == Home {
+		if p.Home == nil {
+			return Place{}, false
+		}
+		return *p.Home, true
+	}
+	place, ok := p.Favorites[name]
+	return place, ok
+}
+
+// Set saves place under name; "home" sets the home location.
+func (p *Profile) Set(name string, place Place) error {
+	name = normalize(name)
+	if err := validName(name); err != nil {
+		return err
+	}
+	if name == Home {
+		p.Home = &place
+		return nil
+	}
+	if p.Favorites == nil {
+		p.Favorites = make(map[string]Place)
+	}
+	p.Favorites[name] = place
+	return nil
+}
+
+// Remove forgets the place saved as name and reports whether there was
+// one.
+func (p *Profile) Remove(name string) bool {
+	name = normalize(name)
+	if name == Home {
+		had := p.Home != nil
+		p.Home = nil
+		return had
+	}
+	_, ok := p.Favorites[name]
+	delete(p.Favorites, name)
+	return ok
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// validName accepts names made of letters, digits, spaces, hyphens and
+// apostrophes, which read naturally in a query: "cabin", "mom's house".
+func validName(name string) error {
+	if name == "" {
+		return errors.New("empty place name")
+	}
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == ' ', r == '-', r == '\'':
+		default:
+			return fmt.Errorf("place name %q may only contain letters, digits, spaces, hyphens and apostrophes", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package profile_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"learn-go/profile"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather", profile.FileName)
+	p, err := profile.Load(path)
+	if err != nil {
+		t.Fatalf("Load of a missing file: %v", err)
+	}
+	if len(p.Names()) != 0 {
+		t.Fatalf("new profile has places %q", p.Names())
+	}
+
+	miami := profile.Place{Name: "Miami, FL", Lat: 25.77, Lon: -80.19}
+	tahoe := profile.Place{Name: "Lake Tahoe, CA", Lat: 39.09, Lon: -120.03}
+	if err := p.Set("Home", miami); err != nil {
+		t.Fatalf("Set home: %v", err)
+	}
+	if err := p.Set("  The   Cabin ", tahoe); err != nil {
+		t.Fatalf("Set cabin: %v", err)
+	}
+	p.Units = "metric"
+	if err := p.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := profile.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := []string{"home", "the cabin"}; !reflect.DeepEqual(got.Names(), want) {
+		t.Errorf("Names = %q, want %q", got.Names(), want)
+	}
+	if place, ok := got.Lookup("THE CABIN"); !ok || place != tahoe {
+		t.Errorf("Lookup = %+v, %v; want %+v", place, ok, tahoe)
+	}
+	if place, ok := got.Lookup("home"); !ok || place != miami {
+		t.Errorf("Lookup home = %+v, %v", place, ok)
+	}
+	if got.Units != "metric" {
+		t.Errorf("Units = %q, want metric", got.Units)
+	}
+	if len(got.Places()) != 2 {
+		t.Errorf("Places = %+v, want home and the cabin", got.Places())
+	}
+
+	if !got.Remove("the cabin") || got.Remove("the cabin") {
+		t.Error("Remove should report the place only the first time")
+	}
+}
+
+func TestSetRejectsOddNames(t *testing.T) {
+	var p profile.Profile
+	for _, name := range []string{"", "cabin, CA", "{{home}}"} {
+		if err := p.Set(name, profile.Place{Name: "Miami, FL"}); err == nil {
+			t.Errorf("Set(%q) succeeded", name)
+		}
+	}
+	if err := p.Set("Mom's house", profile.Place{Name: "Miami, FL"}); err != nil {
+		t.Errorf("Set: %v", err)
+	}
+	if err := p.Save(); err == nil {
+		t.Error("Save of a profile without a file succeeded")
+	}
+}
+
+func TestLoadRejectsBadJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), profile.FileName)
+	if err := os.WriteFile(path, []byte(`{"home": "Miami"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := profile.Load(path); err == nil {
+		t.Error("Load of a malformed profile succeeded")
+	}
+}
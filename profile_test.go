@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"learn-go/config"
+	"learn-go/ollama"
+	"learn-go/profile"
+)
+
+func newTestApp(t *testing.T) (*app, *bytes.Buffer) {
+	t.Helper()
+	t.Setenv("WEATHER_UNITS", "")
+	t.Setenv("WEATHER_OUTPUT", "")
+	dir := t.TempDir()
+	p, err := profile.Load(filepath.Join(dir, profile.FileName))
+	if err != nil {
+		t.Fatalf("profile.Load: %v", err)
+	}
+	var stdout bytes.Buffer
+	cfg := &config.Config{OllamaURL: "http://127.0.0.1:1", Units: "imperial", Output: "text", ConfigDir: dir}
+	return &app{cfg: cfg, profile: p, stdin: strings.NewReader(""), stdout: &stdout, stderr: &bytes.Buffer{}}, &stdout
+}
+
+func TestProfileCommand(t *testing.T) {
+	a, stdout := newTestApp(t)
+	for _, args := range [][]string{
+		{"units", "metric"},
+		{"output", "oneline"},
+	} {
+		if err := a.profileCmd(args); err != nil {
+			t.Fatalf("profile %v: %v", args, err)
+		}
+	}
+	if err := a.profileCmd([]string{"output", "xml"}); exitCode(err) != exitUsage {
+		t.Errorf("unknown output gave %v, want a usage error", err)
+	}
+	if err := a.profileCmd([]string{"remove", "cabin"}); err == nil {
+		t.Error("removing an unsaved place succeeded")
+	}
+
+	saved, err := profile.Load(a.profile.Path())
+	if err != nil {
+		t.Fatalf("profile.Load: %v", err)
+	}
+	if saved.Units != "metric" || saved.Output != "oneline" {
+		t.Errorf("saved profile = %+v", saved)
+	}
+
+	stdout.Reset()
+	if err := a.profileCmd(nil); err != nil {
+		t.Fatalf("profile: %v", err)
+	}
+	for _, want := range []string{"No saved places", "Units: metric", "Output: oneline"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("profile output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestProfilePreferences(t *testing.T) {
+	tests := []struct {
+		args     []string
+		units    string
+		output   string
+		template string
+	}{
+		{nil, "metric", "text", "oneline"},
+		{[]string{"-units", "imperial"}, "imperial", "text", "oneline"},
+		{[]string{"-output", "json"}, "metric", "json", ""},
+	}
+	for _, tt := range tests {
+		a, _ := newTestApp(t)
+		a.profile.Units, a.profile.Output = "metric", "oneline"
+		fs, o := a.flags("current")
+		if _, _, _, err := a.setup(fs, o, tt.args, ollama.PriorityInteractive); err != nil {
+			t.Fatalf("setup %v: %v", tt.args, err)
+		}
+		if o.units != tt.units || o.output != tt.output || o.template != tt.template {
+			t.Errorf("setup %v: units %q, output %q, template %q; want %q, %q, %q", tt.args, o.units, o.output, o.template, tt.units, tt.output, tt.template)
+		}
+	}
+}
+
+func TestTUINeedsLocations(t *testing.T) {
+	a, _ := newTestApp(t)
+	if err := a.tui(nil); exitCode(err) != exitUsage {
+		t.Errorf("tui without locations gave %v, want a usage error", err)
+	}
+	if err := a.tui([]string{"Miami", "-every", "0s"}); exitCode(err) != exitUsage {
+		t.Errorf("tui -every 0s gave %v, want a usage error", err)
+	}
+}
@@ -0,0 +1,386 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"learn-go/weather"
+)
+
+// Series charted from an hourly forecast.
+const (
+	Temperature   = "temperature"
+	Precipitation = "precipitation"
+	Wind          = "wind"
+)
+
+// maxChartHours is how far ahead charts look.
+const maxChartHours = 48
+
+// chartHeight is the number of rows of a line or bar chart.
+const chartHeight = 5
+
+// blocks are the eighths used by sparklines and the tops of bars. In plain
+// mode sparklines use asciiRamp instead.
+var (
+	blocks    = []rune("▁▂▃▄▅▆▇█")
+	asciiRamp = []rune("_.-=+*#@")
+)
+
+// series is one quantity over time, ready to chart.
+type series struct {
+	title  string
+	unit   string
+	values []float64
+	times  []time.Time
+	// lo and hi bound the vertical scale; precipitation is always 0-100%
+	// so a dry day doesn't look like a downpour.
+	lo, hi float64
+	// peak keeps the maximum rather than the mean when hours are merged
+	// to fit the width, so a short shower isn't averaged away.
+	peak bool
+}
+
+// newSeries extracts the named series from the first maxChartHours
+// periods of an hourly forecast.
+func newSeries(name string, periods []weather.ForecastPeriod, u Units) (*series, error) {
+	if len(periods) > maxChartHours {
+		periods = periods[:maxChartHours]
+	}
+	s := &series{}
+	for _, p := range periods {
+		var v float64
+		switch name {
+		case Temperature:
+			v = p.Temperature
+			if u == Metric {
+				v = weather.FahrenheitToCelsius(v)
+			}
+		case Precipitation:
+			v = float64(p.PrecipitationChance)
+		case Wind:
+			v = windMph(p.WindSpeed)
+			if u == Metric {
+				v *= 1.609344
+			}
+		default:
+			return nil, fmt.Errorf("unknown series %q (want %s, %s or %s)", name, Temperature, Precipitation, Wind)
+		}
+		s.values = append(s.values, v)
+		s.times = append(s.times, p.StartTime)
+	}
+
+	s.lo, s.hi = bounds(s.values)
+	switch name {
+	case Temperature:
+		s.title, s.unit = "Temperature", "°F"
+		if u == Metric {
+			s.unit = "°C"
+		}
+	case Precipitation:
+		s.title, s.unit = "Precipitation", "%"
+		s.lo, s.hi = 0, 100
+		s.peak = true
+	case Wind:
+		s.title, s.unit = "Wind", "mph"
+		if u == Metric {
+			s.unit = "km/h"
+		}
+		s.lo = 0
+		s.peak = true
+	}
+	return s, nil
+}
+
+func bounds(values []float64) (lo, hi float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	lo, hi = values[0], values[0]
+	for _, v := range values[1:] {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	return lo, hi
+}
+
+// windMph reads an NWS forecast wind speed such as "10 mph" or "5 to 15
+// km/h", taking the higher figure of a range.
+func windMph(s string) float64 {
+	var v float64
+	for _, f := range strings.Fields(s) {
+		if n, err := strconv.ParseFloat(f, 64); err == nil {
+			v = math.Max(v, n)
+		}
+	}
+	if strings.Contains(s, "km/h") {
+		v /= 1.609344
+	}
+	return v
+}
+
+// fit merges neighbouring hours until the series is at most width points
+// wide, and returns how many columns each point may take.
+func (s *series) fit(width int) (*series, int) {
+	n := len(s.values)
+	if n == 0 || width <= 0 {
+		return s, 1
+	}
+	if n <= width {
+		return s, max(1, min(width/n, 3))
+	}
+
+	per := (n + width - 1) / width
+	fitted := *s
+	fitted.values, fitted.times = nil, nil
+	for i := 0; i < n; i += per {
+		group := s.values[i:min(i+per, n)]
+		v := group[0]
+		for _, g := range group[1:] {
+			if s.peak {
+				v = math.Max(v, g)
+			} else {
+				v += g
+			}
+		}
+		if !s.peak {
+			v /= float64(len(group))
+		}
+		fitted.values = append(fitted.values, v)
+		fitted.times = append(fitted.times, s.times[i])
+	}
+	return &fitted, 1
+}
+
+// level scales v to [0, 1] between the series bounds.
+func (s *series) level(v float64) float64 {
+	if s.hi <= s.lo {
+		return 0.5
+	}
+	return math.Max(0, math.Min(1, (v-s.lo)/(s.hi-s.lo)))
+}
+
+// sparkline draws the series as one line of block characters.
+func (s *series) sparkline(plain bool) string {
+	ramp := blocks
+	if plain {
+		ramp = asciiRamp
+	}
+	var b strings.Builder
+	for _, v := range s.values {
+		b.WriteRune(ramp[int(math.Round(s.level(v)*float64(len(ramp)-1)))])
+	}
+	return b.String()
+}
+
+// grid draws the series as a line chart, or as bars, chartHeight rows
+// high with scale columns per point. Rows are returned top first.
+func (s *series) grid(bars bool, scale int, plain bool) []string {
+	rows := make([][]rune, chartHeight)
+	for r := range rows {
+		rows[r] = []rune(strings.Repeat(" ", len(s.values)*scale))
+	}
+	dot, full := '•', '█'
+	if plain {
+		dot, full = '*', '#'
+	}
+
+	for i, v := range s.values {
+		height := s.level(v) * chartHeight
+		for c := i * scale; c < (i+1)*scale; c++ {
+			if !bars {
+				r := int(math.Round(s.level(v) * (chartHeight - 1)))
+				rows[chartHeight-1-r][c] = dot
+				continue
+			}
+			for r := 0; r < chartHeight; r++ {
+				fill := height - float64(r)
+				switch {
+				case fill >= 1:
+					rows[chartHeight-1-r][c] = full
+				case fill <= 0:
+				case plain:
+					if fill >= 0.5 {
+						rows[chartHeight-1-r][c] = full
+					}
+				default:
+					if eighths := int(math.Round(fill * 8)); eighths > 0 {
+						rows[chartHeight-1-r][c] = blocks[eighths-1]
+					}
+				}
+			}
+		}
+	}
+
+	lines := make([]string, chartHeight)
+	for r, row := range rows {
+		lines[r] = strings.TrimRight(string(row), " ")
+	}
+	return lines
+}
+
+// timeAxis labels the points' hours every six hours, or every three on a
+// short chart, where they fit.
+func (s *series) timeAxis(scale int) string {
+	every := 6
+	if len(s.values)*scale < 36 {
+		every = 3
+	}
+	width := len(s.values) * scale
+	axis := []rune(strings.Repeat(" ", width))
+	next := 0
+	for i, t := range s.times {
+		col := i * scale
+		if t.Hour()%every != 0 && i != 0 || col < next {
+			continue
+		}
+		label := []rune(t.Format("3PM"))
+		if col+len(label) > width {
+			break
+		}
+		copy(axis[col:], label)
+		next = col + len(label) + 1
+	}
+	return strings.TrimRight(string(axis), " ")
+}
+
+// chart draws a titled line or bar chart with a scale on the left and
+// hours underneath, at most width cells wide.
+func (s *series) chart(bars bool, width int, plain bool) string {
+	top := formatValue(s.hi) + s.unit
+	bottom := formatValue(s.lo) + s.unit
+	labelWidth := max(StringWidth(top), StringWidth(bottom))
+	fitted, scale := s.fit(width - labelWidth - 2)
+
+	tick, corner, rule := "┤", "└", "─"
+	if plain {
+		tick, corner, rule = "|", "+", "-"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", s.title, s.unit)
+	for r, row := range fitted.grid(bars, scale, plain) {
+		label := ""
+		switch r {
+		case 0:
+			label = top
+		case chartHeight - 1:
+			label = bottom
+		}
+		fmt.Fprintf(&b, "%s %s%s\n", padLeft(label, labelWidth), tick, row)
+	}
+	fmt.Fprintf(&b, "%s %s%s\n", strings.Repeat(" ", labelWidth), corner, strings.Repeat(rule, len(fitted.values)*scale))
+	fmt.Fprintf(&b, "%s  %s", strings.Repeat(" ", labelWidth), fitted.timeAxis(scale))
+	return b.String()
+}
+
+// sparkLine is a one-line summary: title, sparkline and range.
+func (s *series) sparkLine(width int, plain bool) string {
+	title := fmt.Sprintf("%s (%s)", s.title, s.unit)
+	lo, hi := bounds(s.values)
+	span := fmt.Sprintf("%s-%s", formatValue(lo), formatValue(hi))
+	fitted, _ := s.fit(width - StringWidth(title) - len(span) - 4)
+	return fmt.Sprintf("%s  %s  %s", title, fitted.sparkline(plain), span)
+}
+
+func formatValue(v float64) string {
+	return strconv.Itoa(int(math.Round(v)))
+}
+
+func padLeft(s string, width int) string {
+	if n := width - StringWidth(s); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
+
+// writeCharts charts the temperature, precipitation chance and wind of an
+// hourly forecast.
+func writeCharts(b *strings.Builder, periods []weather.ForecastPeriod, opts Options) {
+	width := opts.LineWidth()
+	temp, _ := newSeries(Temperature, periods, opts.Units)
+	precip, _ := newSeries(Precipitation, periods, opts.Units)
+	wind, _ := newSeries(Wind, periods, opts.Units)
+
+	b.WriteString("\n" + temp.chart(false, width, opts.Plain) + "\n")
+	b.WriteString("\n" + precip.chart(true, width, opts.Plain) + "\n")
+	b.WriteString("\n" + wind.sparkLine(width, opts.Plain) + "\n")
+}
+
+// chartPeriods accepts what a template passes to the chart helpers: a
+// forecast, its periods or a whole response.
+func chartPeriods(data interface{}) ([]weather.ForecastPeriod, error) {
+	switch d := data.(type) {
+	case *weather.Forecast:
+		if d == nil {
+			return nil, nil
+		}
+		return d.Periods, nil
+	case []weather.ForecastPeriod:
+		return d, nil
+	case TemplateData:
+		if d.WeatherResponse == nil || d.Forecast == nil {
+			return nil, nil
+		}
+		return d.Forecast.Periods, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("chart: unexpected %T (want .Forecast or .Forecast.Periods)", data)
+}
+
+// Chart draws the named series of an hourly forecast as a titled line
+// chart, or a bar chart for precipitation, at most width cells wide. It is
+// empty when there are no periods.
+func Chart(name string, periods []weather.ForecastPeriod, width int, opts Options) (string, error) {
+	if len(periods) == 0 {
+		return "", nil
+	}
+	s, err := newSeries(name, periods, opts.Units)
+	if err != nil {
+		return "", err
+	}
+	return s.chart(name == Precipitation, width, opts.Plain), nil
+}
+
+// SparkLine summarizes the named series of an hourly forecast on one line
+// of at most width cells: title, sparkline and range.
+func SparkLine(name string, periods []weather.ForecastPeriod, width int, opts Options) (string, error) {
+	if len(periods) == 0 {
+		return "", nil
+	}
+	s, err := newSeries(name, periods, opts.Units)
+	if err != nil {
+		return "", err
+	}
+	return s.sparkLine(width, opts.Plain), nil
+}
+
+// chartFuncs are the template helpers "chart" and "sparkline", which draw
+// a series of an hourly forecast: {{chart "temperature" .Forecast}}.
+// Both are empty when there is no forecast.
+func chartFuncs(opts Options) template.FuncMap {
+	return template.FuncMap{
+		"chart": func(name string, data interface{}) (string, error) {
+			periods, err := chartPeriods(data)
+			if err != nil {
+				return "", err
+			}
+			return Chart(name, periods, opts.LineWidth(), opts)
+		},
+		"sparkline": func(name string, data interface{}) (string, error) {
+			periods, err := chartPeriods(data)
+			if err != nil || len(periods) == 0 {
+				return "", err
+			}
+			s, err := newSeries(name, periods, opts.Units)
+			if err != nil {
+				return "", err
+			}
+			fitted, _ := s.fit(opts.LineWidth())
+			return fitted.sparkline(opts.Plain), nil
+		},
+	}
+}
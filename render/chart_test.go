@@ -0,0 +1,110 @@
+package render_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/render"
+	"learn-go/weather"
+)
+
+// hourly returns an hourly forecast of n hours from 10AM, with the
+// temperature rising a degree an hour from 60°F, the chance of rain
+// cycling through 0, 50 and 100% and the wind rising a mile an hour.
+func hourly(n int) *ollama.WeatherResponse {
+	start := time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC)
+	var periods []weather.ForecastPeriod
+	for i := 0; i < n; i++ {
+		periods = append(periods, weather.ForecastPeriod{
+			StartTime:           start.Add(time.Duration(i) * time.Hour),
+			EndTime:             start.Add(time.Duration(i+1) * time.Hour),
+			Temperature:         float64(60 + i),
+			PrecipitationChance: i % 3 * 50,
+			WindSpeed:           fmt.Sprintf("%d to %d mph", i/2, i),
+			ShortForecast:       "Cloudy",
+		})
+	}
+	return &ollama.WeatherResponse{
+		Intent:      ollama.IntentHourly,
+		Location:    "Miami, FL",
+		Forecast:    &weather.Forecast{Hourly: true, Periods: periods},
+		Description: "Warming up.",
+		Describer:   ollama.DescriberTemplate,
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		text string
+		opts render.Options
+		want string
+	}{
+		{`{{sparkline "precipitation" .Forecast}}`, render.Options{}, "▁▅█▁▅█"},
+		{`{{sparkline "temperature" .Forecast.Periods}}`, render.Options{}, "▁▂▄▅▇█"},
+		{`{{sparkline "wind" .Forecast}}`, render.Options{Plain: true}, "_.=+#@"},
+	}
+	for _, tt := range tests {
+		if got := execute(t, tt.text, tt.opts, hourly(6)); got != tt.want+"\n" {
+			t.Errorf("%s = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	// Without a forecast there is nothing to draw.
+	if got := execute(t, `{{sparkline "wind" .Forecast}}`, render.Options{}, current); got != "" {
+		t.Errorf("sparkline of current conditions = %q, want nothing", got)
+	}
+}
+
+func TestChartFitsWidth(t *testing.T) {
+	for _, width := range []int{30, 60, 120} {
+		opts := render.Options{Width: width}
+		out := execute(t, `{{chart "temperature" .Forecast}}{{"\n"}}{{chart "precipitation" .Forecast}}`, opts, hourly(48))
+		for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+			if w := render.StringWidth(line); w > opts.LineWidth() {
+				t.Errorf("width %d: line %q is %d cells, want at most %d", width, line, w, opts.LineWidth())
+			}
+		}
+		if !strings.Contains(out, "107°F") || !strings.Contains(out, "100%") {
+			t.Errorf("width %d: chart is missing its scale:\n%s", width, out)
+		}
+	}
+}
+
+func TestChartPlain(t *testing.T) {
+	out := execute(t, `{{chart "precipitation" .Forecast}}`, render.Options{Width: 80, Plain: true}, hourly(12))
+	want := `Precipitation (%)
+100% |      ###      ###      ###      ###
+     |      ###      ###      ###      ###
+     |   ######   ######   ######   ######
+     |   ######   ######   ######   ######
+  0% |   ######   ######   ######   ######
+     +------------------------------------
+      10AM  12PM              6PM
+`
+	if out != want {
+		t.Errorf("chart =\n%s\nwant\n%s", out, want)
+	}
+
+	r, err := render.NewTemplate(`{{chart "humidity" .Forecast}}`, render.Options{})
+	if err != nil {
+		t.Fatalf("NewTemplate: %v", err)
+	}
+	if err := r.Render(&strings.Builder{}, hourly(3)); err == nil || !strings.Contains(err.Error(), "unknown series") {
+		t.Errorf("unknown series gave %v", err)
+	}
+}
+
+func TestTextHourlyCharts(t *testing.T) {
+	out := renderString(t, render.Text, render.Options{Width: 80, Units: render.Metric}, hourly(24))
+	for _, want := range []string{"Temperature (°C)", "Precipitation (%)", "Wind (km/h)", "┤", "10AM"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("hourly text output missing %q:\n%s", want, out)
+		}
+	}
+	if out := renderString(t, render.Text, render.Options{}, forecast); strings.Contains(out, "Temperature (") {
+		t.Errorf("daily forecast got charts:\n%s", out)
+	}
+}
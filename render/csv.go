@@ -0,0 +1,73 @@
+package render
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/weather"
+)
+
+// csvRenderer writes the main table of a response with a header row:
+// one row per forecast period, alert or compared place, or a single row of
+// current conditions. Column names match the JSON field names.
+type csvRenderer struct{}
+
+var (
+	conditionsHeader = []string{"location", "temperature", "feels_like", "conditions", "humidity",
+		"wind_speed", "wind_speed_unit", "wind_direction", "wind_gust", "visibility", "pressure",
+		"dew_point", "uv_index", "cloud_cover", "precipitation_chance", "timestamp", "description"}
+	forecastHeader = []string{"location", "name", "start_time", "end_time", "is_daytime", "temperature",
+		"wind_speed", "wind_direction", "precipitation_chance", "humidity", "short_forecast"}
+	alertsHeader = []string{"location", "id", "event", "severity", "urgency", "certainty", "area",
+		"effective", "expires", "headline"}
+)
+
+func (r *csvRenderer) Render(w io.Writer, resp *ollama.WeatherResponse) error {
+	cw := csv.NewWriter(w)
+	switch {
+	case resp.Comparison != nil:
+		cw.Write(conditionsHeader)
+		for _, p := range resp.Comparison {
+			cw.Write(conditionsRow(p.Location, p.Weather, ""))
+		}
+	case resp.Forecast != nil:
+		cw.Write(forecastHeader)
+		for _, p := range resp.Forecast.Periods {
+			cw.Write([]string{resp.Location, p.Name, p.StartTime.Format(time.RFC3339), p.EndTime.Format(time.RFC3339),
+				strconv.FormatBool(p.IsDaytime), num(p.Temperature), p.WindSpeed, p.WindDirection,
+				strconv.Itoa(p.PrecipitationChance), strconv.Itoa(p.Humidity), p.ShortForecast})
+		}
+	case resp.Intent == ollama.IntentAlerts:
+		cw.Write(alertsHeader)
+		for _, a := range resp.Alerts {
+			cw.Write([]string{resp.Location, a.ID, a.Event, a.Severity, a.Urgency, a.Certainty, a.Area,
+				a.Effective, a.Expires, a.Headline})
+		}
+	default:
+		cw.Write(conditionsHeader)
+		cw.Write(conditionsRow(resp.Location, resp.Weather, resp.Description))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func conditionsRow(location string, d *weather.WeatherData, description string) []string {
+	if d == nil {
+		d = &weather.WeatherData{}
+	}
+	temp := ""
+	if !d.TemperatureMissing {
+		temp = num(d.Temperature)
+	}
+	return []string{location, temp, num(d.FeelsLike), d.Conditions, strconv.Itoa(d.Humidity),
+		num(d.WindSpeed), d.WindSpeedUnit, d.WindDirection, num(d.WindGust), num(d.Visibility), num(d.Pressure),
+		num(d.DewPoint), num(d.UVIndex), strconv.Itoa(d.CloudCover), strconv.Itoa(d.PrecipitationChance),
+		d.Timestamp, description}
+}
+
+func num(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,22 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+
+	"learn-go/ollama"
+)
+
+// jsonRenderer writes the response as the HTTP API does, indented for
+// reading or one object per line for NDJSON.
+type jsonRenderer struct {
+	indent bool
+}
+
+func (r *jsonRenderer) Render(w io.Writer, resp *ollama.WeatherResponse) error {
+	enc := json.NewEncoder(w)
+	if r.indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(resp)
+}
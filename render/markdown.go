@@ -0,0 +1,122 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"learn-go/ollama"
+	"learn-go/weather"
+)
+
+// markdownRenderer writes a heading, the data as a table and the
+// description, for pasting into issues, chat or documents.
+type markdownRenderer struct {
+	units Units
+}
+
+func (r *markdownRenderer) Render(w io.Writer, resp *ollama.WeatherResponse) error {
+	u := r.units
+	var b strings.Builder
+	if resp.Location != "" {
+		fmt.Fprintf(&b, "## %s\n\n", mdCell(resp.Location))
+	}
+
+	switch {
+	case resp.Comparison != nil:
+		mdTable(&b, []string{"Location", "Temperature", "Conditions", "Humidity"}, func(row func(...string)) {
+			for _, p := range resp.Comparison {
+				row(p.Location, ObservedTemp(p.Weather, u.Temp), p.Weather.Conditions, fmt.Sprintf("%d%%", p.Weather.Humidity))
+			}
+		})
+	case resp.Forecast != nil:
+		mdTable(&b, []string{"Period", "Temperature", "Forecast", "Precipitation"}, func(row func(...string)) {
+			for _, p := range resp.Forecast.Periods {
+				name := p.Name
+				if resp.Forecast.Hourly || name == "" {
+					name = p.StartTime.Format("Mon 3PM")
+				}
+				row(name, u.WholeTemp(p.Temperature), p.ShortForecast, fmt.Sprintf("%d%%", p.PrecipitationChance))
+			}
+		})
+	case resp.Intent == ollama.IntentAlerts:
+		if len(resp.Alerts) == 0 {
+			b.WriteString("No active alerts.\n\n")
+			break
+		}
+		mdTable(&b, []string{"Severity", "Event", "Until"}, func(row func(...string)) {
+			for _, a := range resp.Alerts {
+				row(a.Severity, a.Event, a.Expires)
+			}
+		})
+	case resp.Weather != nil:
+		mdTable(&b, []string{"", ""}, func(row func(...string)) {
+			for _, f := range conditionFields(resp.Weather, u) {
+				row(f[0], f[1])
+			}
+		})
+	}
+
+	if resp.Answer != nil {
+		fmt.Fprintf(&b, "**%s**\n\n", mdCell(resp.Answer.Short))
+	}
+	if resp.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", resp.Description)
+	}
+	if resp.DescriberError != "" {
+		fmt.Fprintf(&b, "_AI unavailable: %s_\n\n", resp.DescriberError)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// conditionFields lists the observed values worth showing, as label and
+// formatted value pairs.
+func conditionFields(d *weather.WeatherData, u Units) [][2]string {
+	fields := [][2]string{
+		{"Temperature", ObservedTemp(d, u.Temp)},
+	}
+	add := func(ok bool, label, value string) {
+		if ok {
+			fields = append(fields, [2]string{label, value})
+		}
+	}
+	add(d.FeelsLike > 0, "Feels Like", u.Temp(d.FeelsLike))
+	add(true, "Conditions", d.Conditions)
+	add(true, "Humidity", fmt.Sprintf("%d%%", d.Humidity))
+	add(d.WindSpeed > 0, "Wind Speed", u.Speed(d.WindSpeed, d.WindSpeedUnit))
+	add(d.WindDirection != "", "Wind Direction", d.WindDirection)
+	add(d.WindGust > 0, "Wind Gust", u.Speed(d.WindGust, d.WindSpeedUnit))
+	add(d.Visibility > 0, "Visibility", u.Distance(d.Visibility))
+	add(d.Pressure > 0, "Pressure", fmt.Sprintf("%.1f mb", d.Pressure))
+	add(d.DewPoint > 0, "Dew Point", u.Temp(d.DewPoint))
+	add(d.UVIndex > 0, "UV Index", fmt.Sprintf("%.1f", d.UVIndex))
+	add(d.CloudCover > 0, "Cloud Cover", fmt.Sprintf("%d%%", d.CloudCover))
+	add(d.PrecipitationChance > 0, "Precipitation", fmt.Sprintf("%d%%", d.PrecipitationChance))
+	add(true, "Last Updated", d.Timestamp)
+	return fields
+}
+
+func mdTable(b *strings.Builder, header []string, rows func(row func(...string))) {
+	row := func(cells ...string) {
+		for i := range cells {
+			cells[i] = mdCell(cells[i])
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
+	}
+	row(header...)
+	sep := make([]string, len(header))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	fmt.Fprintf(b, "| %s |\n", strings.Join(sep, " | "))
+	rows(row)
+	b.WriteString("\n")
+}
+
+// mdCell escapes text for a table cell or heading.
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,132 @@
+// Package render formats weather responses for the command line: the
+// decorated text tables meant for people, and JSON, NDJSON, YAML, CSV and
+// Markdown for scripts and documents.
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"learn-go/ollama"
+	"learn-go/weather"
+)
+
+// Output formats accepted by New.
+const (
+	Text     = "text"
+	JSON     = "json"
+	NDJSON   = "ndjson"
+	YAML     = "yaml"
+	CSV      = "csv"
+	Markdown = "markdown"
+)
+
+// Formats lists the output formats in the order they are documented.
+var Formats = []string{Text, JSON, NDJSON, YAML, CSV, Markdown}
+
+// Renderer writes one weather response.
+type Renderer interface {
+	Render(w io.Writer, resp *ollama.WeatherResponse) error
+}
+
+// Options configures a Renderer.
+type Options struct {
+	// Units selects how the text and Markdown formats print temperatures,
+	// speeds and distances. The structured formats always carry the data
+	// as the API returns it.
+	Units Units
+	// Width is the terminal width in columns; zero when unknown, which
+	// keeps a 50-column layout.
+	Width int
+	// Color highlights temperatures and alert severities in the text
+	// format with ANSI colors.
+	Color bool
+	// Plain drops emoji, box drawing and color from the text format, for
+	// screen readers and logs.
+	Plain bool
+}
+
+// New returns the renderer for format.
+func New(format string, opts Options) (Renderer, error) {
+	if opts.Units == "" {
+		opts.Units = Imperial
+	}
+	switch strings.ToLower(format) {
+	case Text, "":
+		return &textRenderer{opts: opts}, nil
+	case JSON:
+		return &jsonRenderer{indent: true}, nil
+	case NDJSON, "jsonl":
+		return &jsonRenderer{}, nil
+	case YAML, "yml":
+		return &yamlRenderer{}, nil
+	case CSV:
+		return &csvRenderer{}, nil
+	case Markdown, "md":
+		return &markdownRenderer{units: opts.Units}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (want %s)", format, strings.Join(Formats, ", "))
+}
+
+// Units selects how temperatures, speeds and distances are printed. The
+// data itself is always imperial; conversion happens only for display.
+type Units string
+
+const (
+	Imperial Units = "imperial"
+	Metric   Units = "metric"
+)
+
+// ParseUnits parses "imperial" or "metric".
+func ParseUnits(s string) (Units, error) {
+	switch u := Units(strings.ToLower(s)); u {
+	case Imperial, Metric:
+		return u, nil
+	}
+	return "", fmt.Errorf("unknown units %q (want imperial or metric)", s)
+}
+
+// MissingTemp is shown in place of a temperature the station didn't report.
+const MissingTemp = "unavailable"
+
+// ObservedTemp formats the temperature observed in d with format, such as
+// Units.Temp, or returns MissingTemp when the station didn't report one.
+func ObservedTemp(d *weather.WeatherData, format func(float64) string) string {
+	if d.TemperatureMissing {
+		return MissingTemp
+	}
+	return format(d.Temperature)
+}
+
+// Temp formats a temperature given in °F.
+func (u Units) Temp(f float64) string {
+	if u == Metric {
+		return fmt.Sprintf("%.1f°C", weather.FahrenheitToCelsius(f))
+	}
+	return fmt.Sprintf("%.1f°F", f)
+}
+
+// WholeTemp formats a temperature given in °F without decimals.
+func (u Units) WholeTemp(f float64) string {
+	if u == Metric {
+		return fmt.Sprintf("%.0f°C", weather.FahrenheitToCelsius(f))
+	}
+	return fmt.Sprintf("%.0f°F", f)
+}
+
+// Speed formats a speed reported with an NWS unit code.
+func (u Units) Speed(value float64, unitCode string) string {
+	if u == Metric {
+		return fmt.Sprintf("%.1f km/h", weather.SpeedToMph(value, unitCode)*1.609344)
+	}
+	return fmt.Sprintf("%.1f mph", weather.SpeedToMph(value, unitCode))
+}
+
+// Distance formats a distance given in miles.
+func (u Units) Distance(miles float64) string {
+	if u == Metric {
+		return fmt.Sprintf("%.1f km", miles*1.609344)
+	}
+	return fmt.Sprintf("%.1f miles", miles)
+}
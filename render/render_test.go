@@ -0,0 +1,236 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/render"
+	"learn-go/weather"
+)
+
+var current = &ollama.WeatherResponse{
+	Intent:   ollama.IntentCurrent,
+	Location: "Miami, FL",
+	Weather: &weather.WeatherData{
+		Temperature:   77,
+		Conditions:    "Partly Cloudy",
+		Humidity:      65,
+		WindSpeed:     16.1,
+		WindSpeedUnit: "wmoUnit:km_h-1",
+		WindDirection: "SE",
+		Timestamp:     "2025-01-08T15:53:00+00:00",
+	},
+	Description: "Warm: 77°F | partly cloudy.",
+	Describer:   ollama.DescriberTemplate,
+}
+
+var forecast = &ollama.WeatherResponse{
+	Intent:   ollama.IntentDaily,
+	Location: "Denver, CO",
+	Forecast: &weather.Forecast{
+		Periods: []weather.ForecastPeriod{
+			{Name: "Tonight", StartTime: time.Date(2025, 1, 8, 18, 0, 0, 0, time.UTC), Temperature: 20, ShortForecast: "Snow", PrecipitationChance: 80},
+			{Name: "Thursday", StartTime: time.Date(2025, 1, 9, 6, 0, 0, 0, time.UTC), Temperature: 35, ShortForecast: "Sunny", IsDaytime: true},
+		},
+	},
+	Description: "Snow tonight, then sunny.",
+	Describer:   ollama.DescriberAI,
+}
+
+var alerts = &ollama.WeatherResponse{
+	Intent:   ollama.IntentAlerts,
+	Location: "Houston, TX",
+	Alerts: []weather.Alert{
+		{ID: "urn:1", Event: "Flood Watch", Severity: "Severe", Expires: "2025-01-09T06:00:00-06:00"},
+	},
+	Description: "A flood watch is in effect.",
+	Describer:   ollama.DescriberAI,
+}
+
+func renderString(t *testing.T, format string, opts render.Options, resp *ollama.WeatherResponse) string {
+	t.Helper()
+	r, err := render.New(format, opts)
+	if err != nil {
+		t.Fatalf("New(%q): %v", format, err)
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, resp); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return buf.String()
+}
+
+func TestNewUnknownFormat(t *testing.T) {
+	if _, err := render.New("xml", render.Options{}); err == nil || !strings.Contains(err.Error(), "markdown") {
+		t.Errorf("error = %v, want the list of formats", err)
+	}
+	for _, alias := range []string{"md", "yml", "jsonl", "JSON"} {
+		if _, err := render.New(alias, render.Options{}); err != nil {
+			t.Errorf("New(%q): %v", alias, err)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	want, _ := json.Marshal(current)
+
+	var got bytes.Buffer
+	if err := json.Compact(&got, []byte(renderString(t, render.JSON, render.Options{}, current))); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.String() != string(want) {
+		t.Errorf("JSON = %s, want the API encoding %s", got.String(), want)
+	}
+
+	nd := renderString(t, render.NDJSON, render.Options{}, current)
+	if nd != string(want)+"\n" {
+		t.Errorf("NDJSON = %q, want one line", nd)
+	}
+}
+
+func TestYAML(t *testing.T) {
+	got := renderString(t, render.YAML, render.Options{}, alerts)
+	want := `---
+weather: null
+description: A flood watch is in effect.
+intent: alerts
+location: Houston, TX
+alerts:
+  - id: urn:1
+    event: Flood Watch
+    headline: ""
+    severity: Severe
+    urgency: ""
+    certainty: ""
+    area: ""
+    effective: ""
+    expires: "2025-01-09T06:00:00-06:00"
+    description: ""
+describer: ai
+`
+	if got != want {
+		t.Errorf("YAML =\n%s\nwant\n%s", got, want)
+	}
+
+	got = renderString(t, render.YAML, render.Options{}, current)
+	for _, line := range []string{
+		"weather:\n  temperature: 77\n",
+		"  wind_speed: 16.1\n",
+		`description: "Warm: 77°F | partly cloudy."`,
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("YAML missing %q in\n%s", line, got)
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	tests := []struct {
+		resp      *ollama.WeatherResponse
+		rows      int
+		wantCells map[string]string
+	}{
+		{current, 1, map[string]string{"location": "Miami, FL", "temperature": "77", "description": "Warm: 77°F | partly cloudy."}},
+		{forecast, 2, map[string]string{"name": "Tonight", "start_time": "2025-01-08T18:00:00Z", "precipitation_chance": "80"}},
+		{alerts, 1, map[string]string{"event": "Flood Watch", "severity": "Severe"}},
+	}
+	for _, tt := range tests {
+		records, err := csv.NewReader(strings.NewReader(renderString(t, render.CSV, render.Options{}, tt.resp))).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: invalid CSV: %v", tt.resp.Intent, err)
+		}
+		if len(records) != tt.rows+1 {
+			t.Fatalf("%s: %d records, want header plus %d", tt.resp.Intent, len(records), tt.rows)
+		}
+		for col, want := range tt.wantCells {
+			i := indexOf(records[0], col)
+			if i < 0 {
+				t.Errorf("%s: no %q column in %v", tt.resp.Intent, col, records[0])
+			} else if records[1][i] != want {
+				t.Errorf("%s: %s = %q, want %q", tt.resp.Intent, col, records[1][i], want)
+			}
+		}
+	}
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestMarkdown(t *testing.T) {
+	got := renderString(t, render.Markdown, render.Options{Units: render.Metric}, forecast)
+	for _, want := range []string{
+		"## Denver, CO\n",
+		"| Period | Temperature | Forecast | Precipitation |\n| --- | --- | --- | --- |\n",
+		"| Tonight | -7°C | Snow | 80% |\n",
+		"Snow tonight, then sunny.\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Markdown missing %q in\n%s", want, got)
+		}
+	}
+
+	got = renderString(t, render.Markdown, render.Options{}, current)
+	if !strings.Contains(got, "| Temperature | 77.0°F |") {
+		t.Errorf("Markdown missing conditions table in\n%s", got)
+	}
+}
+
+func TestTextUnits(t *testing.T) {
+	got := renderString(t, render.Text, render.Options{}, current)
+	if !strings.Contains(got, "77.0°F") || !strings.Contains(got, "📝 Summary") {
+		t.Errorf("text output =\n%s", got)
+	}
+
+	if !strings.Contains(got, "10.0 mph") || strings.Contains(got, "wmoUnit") {
+		t.Errorf("imperial text output shows the raw wind speed:\n%s", got)
+	}
+
+	got = renderString(t, render.Text, render.Options{Units: render.Metric}, current)
+	if !strings.Contains(got, "25.0°C") || !strings.Contains(got, "16.1 km/h") {
+		t.Errorf("metric text output =\n%s", got)
+	}
+}
+
+func TestMissingTemperature(t *testing.T) {
+	cloudy := &weather.WeatherData{TemperatureMissing: true, Conditions: "Cloudy"}
+	resp := &ollama.WeatherResponse{Intent: ollama.IntentCurrent, Location: "Miami, FL", Weather: cloudy}
+	comparison := &ollama.WeatherResponse{
+		Intent:     ollama.IntentComparison,
+		Location:   "Miami, FL",
+		Comparison: []ollama.PlaceWeather{{Location: "Miami, FL", Weather: cloudy}},
+	}
+
+	outputs := map[string]string{
+		"text":                renderString(t, render.Text, render.Options{}, resp),
+		"markdown":            renderString(t, render.Markdown, render.Options{}, resp),
+		"markdown comparison": renderString(t, render.Markdown, render.Options{}, comparison),
+	}
+	for _, name := range render.BuiltinNames() {
+		text, _ := render.Builtin(name)
+		r, err := render.NewTemplate(text, render.Options{})
+		if err != nil {
+			t.Fatalf("NewTemplate(%s): %v", name, err)
+		}
+		var buf bytes.Buffer
+		if err := r.Render(&buf, resp); err != nil {
+			t.Fatalf("Render(%s): %v", name, err)
+		}
+		outputs[name] = buf.String()
+	}
+	for name, got := range outputs {
+		if strings.Contains(got, "0°F") || !strings.Contains(got, render.MissingTemp) {
+			t.Errorf("%s output shows a missing temperature as a reading:\n%s", name, got)
+		}
+	}
+}
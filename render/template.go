@@ -0,0 +1,237 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/weather"
+)
+
+// TemplateData is what an output template is executed with: the whole
+// response, so .Weather, .Forecast.Periods, .Alerts, .Answer and
+// .Description are all available, plus the units chosen for display.
+type TemplateData struct {
+	*ollama.WeatherResponse
+	Units Units
+}
+
+// builtinTemplates are the templates selectable by name.
+var builtinTemplates = map[string]string{
+	// oneline fits a status bar: "Miami, FL: ⛅ 77°F Partly Cloudy".
+	"oneline": `{{.Location}}:
+{{- with .Weather}} {{icon .Conditions}} {{observedWholeTemp .}} {{.Conditions}}
+{{- else}}{{with .Forecast}}{{with .Periods}}{{with index . 0}} {{icon .ShortForecast}} {{wholeTemp .Temperature}} {{.ShortForecast}}{{end}}{{end}}{{end}}
+{{- end}}
+{{- with .Alerts}} ⚠ {{len .}} alert{{if gt (len .) 1}}s{{end}}{{end}}`,
+
+	// detailed lists every field without the decoration of the text format.
+	"detailed": `{{.Location}}
+{{- with .Weather}}
+  Conditions:    {{icon .Conditions}} {{.Conditions}}
+  Temperature:   {{observedTemp .}}{{if .FeelsLike}} (feels like {{temp .FeelsLike}}){{end}}
+  Humidity:      {{.Humidity}}%
+{{- if .WindSpeed}}
+  Wind:          {{speed .WindSpeed .WindSpeedUnit}}{{with .WindDirection}} from {{cardinal .}}{{end}}{{if .WindGust}}, gusts {{speed .WindGust .WindSpeedUnit}}{{end}}
+{{- end}}
+{{- if .Visibility}}
+  Visibility:    {{distance .Visibility}}
+{{- end}}
+{{- if .Pressure}}
+  Pressure:      {{round 1 .Pressure}} mb
+{{- end}}
+  Updated:       {{date "Mon Jan 2 3:04 PM" .Timestamp}}
+{{- end}}
+{{- with .Forecast}}
+{{- range .Periods}}
+  {{if $.Forecast.Hourly}}{{date "Mon 3PM" .StartTime}}{{else}}{{.Name}}{{end}}: {{wholeTemp .Temperature}}, {{.ShortForecast}}{{if .PrecipitationChance}} ({{.PrecipitationChance}}% precipitation){{end}}
+{{- end}}
+{{- end}}
+{{- range .Alerts}}
+  ⚠ {{.Severity}}: {{.Event}} until {{date "Mon Jan 2 3:04 PM" .Expires}}
+{{- end}}
+{{- with .Answer}}
+
+{{.Short}}
+{{- end}}
+
+{{wrap 72 .Description}}
+`,
+
+	// slack is a Slack mrkdwn block.
+	"slack": `*{{.Location}}*
+{{- with .Weather}} {{icon .Conditions}} {{observedWholeTemp .}}, {{.Conditions}}, humidity {{.Humidity}}%{{end}}
+{{- with .Forecast}}
+{{- range .Periods}}
+• *{{if $.Forecast.Hourly}}{{date "Mon 3PM" .StartTime}}{{else}}{{.Name}}{{end}}* {{icon .ShortForecast}} {{wholeTemp .Temperature}} {{.ShortForecast}}
+{{- end}}
+{{- end}}
+{{- range .Alerts}}
+:warning: *{{.Event}}* ({{.Severity}}) until {{date "Mon Jan 2 3:04 PM" .Expires}}
+{{- end}}
+{{- with .Answer}}
+*{{.Short}}*
+{{- end}}
+> {{.Description}}
+`,
+}
+
+// Builtin returns the text of a built-in template.
+func Builtin(name string) (string, bool) {
+	text, ok := builtinTemplates[name]
+	return text, ok
+}
+
+// BuiltinNames lists the built-in templates in alphabetical order.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtinTemplates))
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateRenderer executes a user-supplied text/template.
+type templateRenderer struct {
+	tmpl  *template.Template
+	units Units
+}
+
+// NewTemplate returns a renderer executing text with TemplateData. Output
+// that doesn't end in a newline gets one, so one-line templates print
+// cleanly.
+func NewTemplate(text string, opts Options) (Renderer, error) {
+	if opts.Units == "" {
+		opts.Units = Imperial
+	}
+	tmpl, err := template.New("output").Funcs(Funcs(opts)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	return &templateRenderer{tmpl: tmpl, units: opts.Units}, nil
+}
+
+func (r *templateRenderer) Render(w io.Writer, resp *ollama.WeatherResponse) error {
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, TemplateData{WeatherResponse: resp, Units: r.units}); err != nil {
+		return fmt.Errorf("executing template: %w", err)
+	}
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Funcs returns the helper functions available to output templates, with
+// temp, wholeTemp, speed and distance formatting in the chosen units and
+// charts fitted to the terminal. observedTemp and observedWholeTemp take
+// the current weather and print MissingTemp when there was no reading.
+func Funcs(opts Options) template.FuncMap {
+	u := opts.Units
+	funcs := template.FuncMap{
+		"temp":              u.Temp,
+		"wholeTemp":         u.WholeTemp,
+		"observedTemp":      func(d *weather.WeatherData) string { return ObservedTemp(d, u.Temp) },
+		"observedWholeTemp": func(d *weather.WeatherData) string { return ObservedTemp(d, u.WholeTemp) },
+		"speed":             u.Speed,
+		"distance":          u.Distance,
+		"celsius":           weather.FahrenheitToCelsius,
+		"fahrenheit":        func(c float64) float64 { return c*9/5 + 32 },
+		"mph":               weather.SpeedToMph,
+		"kmh":               func(v float64, unitCode string) float64 { return weather.SpeedToMph(v, unitCode) * 1.609344 },
+		"round":             round,
+		"wrap":              func(width int, text string) string { return wrapText(text, width) },
+		"cardinal":          cardinal,
+		"icon":              icon,
+		"date":              date,
+		"upper":             strings.ToUpper,
+		"lower":             strings.ToLower,
+		"join":              strings.Join,
+		"repeat":            func(n int, s string) string { return strings.Repeat(s, n) },
+	}
+	for name, fn := range chartFuncs(opts) {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// round rounds v to places decimal places.
+func round(places int, v float64) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// cardinal names the compass point of a direction in degrees. Directions
+// that are already compass points, as NWS forecasts give them, are
+// returned unchanged.
+func cardinal(direction interface{}) (string, error) {
+	var deg float64
+	switch d := direction.(type) {
+	case string:
+		return d, nil
+	case int:
+		deg = float64(d)
+	case float64:
+		deg = d
+	default:
+		return "", fmt.Errorf("cardinal: unexpected %T", direction)
+	}
+	i := int(math.Round(math.Mod(deg+360, 360)/22.5)) % len(compassPoints)
+	return compassPoints[i], nil
+}
+
+// icons maps words of a conditions description to an emoji, checked in
+// order so "Thunderstorms and Rain" gets the storm.
+var icons = []struct {
+	words []string
+	icon  string
+}{
+	{[]string{"thunder", "storm"}, "⛈️"},
+	{[]string{"snow", "sleet", "flurr", "ice", "blizzard"}, "❄️"},
+	{[]string{"rain", "shower", "drizzle"}, "🌧️"},
+	{[]string{"fog", "haze", "mist", "smoke"}, "🌫️"},
+	{[]string{"partly", "mostly sunny", "mostly clear"}, "⛅"},
+	{[]string{"cloud", "overcast"}, "☁️"},
+	{[]string{"wind", "breez"}, "💨"},
+	{[]string{"sun", "clear", "fair"}, "☀️"},
+}
+
+// icon returns an emoji for a conditions description such as "Partly
+// Cloudy".
+func icon(conditions string) string {
+	lower := strings.ToLower(conditions)
+	for _, entry := range icons {
+		for _, w := range entry.words {
+			if strings.Contains(lower, w) {
+				return entry.icon
+			}
+		}
+	}
+	return "🌡️"
+}
+
+// date formats t, a time.Time or an RFC 3339 string as NWS returns them,
+// with layout. Strings that don't parse are returned as they are.
+func date(layout string, t interface{}) (string, error) {
+	switch v := t.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return v, nil
+		}
+		return parsed.Format(layout), nil
+	}
+	return "", fmt.Errorf("date: unexpected %T", t)
+}
@@ -0,0 +1,94 @@
+package render_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"learn-go/ollama"
+	"learn-go/render"
+)
+
+func execute(t *testing.T, text string, opts render.Options, resp *ollama.WeatherResponse) string {
+	t.Helper()
+	r, err := render.NewTemplate(text, opts)
+	if err != nil {
+		t.Fatalf("NewTemplate: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, resp); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return buf.String()
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	tests := []struct {
+		text string
+		opts render.Options
+		want string
+	}{
+		{`{{temp .Weather.Temperature}}`, render.Options{}, "77.0°F"},
+		{`{{temp .Weather.Temperature}}`, render.Options{Units: render.Metric}, "25.0°C"},
+		{`{{.Units}}`, render.Options{Units: render.Metric}, "metric"},
+		{`{{round 1 (celsius .Weather.Temperature)}}`, render.Options{}, "25"},
+		{`{{fahrenheit 100}}`, render.Options{}, "212"},
+		{`{{round 2 (mph .Weather.WindSpeed .Weather.WindSpeedUnit)}}`, render.Options{}, "10"},
+		{`{{speed .Weather.WindSpeed .Weather.WindSpeedUnit}}`, render.Options{Units: render.Metric}, "16.1 km/h"},
+		{`{{speed .Weather.WindSpeed .Weather.WindSpeedUnit}}`, render.Options{}, "10.0 mph"},
+		{`{{cardinal 225}} {{cardinal 350.0}} {{cardinal "SE"}}`, render.Options{}, "SW N SE"},
+		{`{{icon .Weather.Conditions}} {{icon "Thunderstorms and Rain"}} {{icon "Light Snow"}}`, render.Options{}, "⛅ ⛈️ ❄️"},
+		{`{{wrap 12 "one two three four"}}`, render.Options{}, "one two\nthree four"},
+		{`{{date "Jan 2 15:04" .Weather.Timestamp}}`, render.Options{}, "Jan 8 15:53"},
+		{`{{upper .Location}}`, render.Options{}, "MIAMI, FL"},
+	}
+	for _, tt := range tests {
+		if got := execute(t, tt.text, tt.opts, current); got != tt.want+"\n" {
+			t.Errorf("%s = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	oneline, ok := render.Builtin("oneline")
+	if !ok {
+		t.Fatal("no oneline template")
+	}
+	tests := []struct {
+		resp *ollama.WeatherResponse
+		want string
+	}{
+		{current, "Miami, FL: ⛅ 77°F Partly Cloudy\n"},
+		{forecast, "Denver, CO: ❄️ 20°F Snow\n"},
+		{alerts, "Houston, TX: ⚠ 1 alert\n"},
+	}
+	for _, tt := range tests {
+		if got := execute(t, oneline, render.Options{}, tt.resp); got != tt.want {
+			t.Errorf("oneline(%s) = %q, want %q", tt.resp.Intent, got, tt.want)
+		}
+	}
+
+	// Every built-in must handle every kind of response.
+	for _, name := range render.BuiltinNames() {
+		text, _ := render.Builtin(name)
+		for _, resp := range []*ollama.WeatherResponse{current, forecast, alerts} {
+			if got := execute(t, text, render.Options{}, resp); !strings.Contains(got, resp.Location) {
+				t.Errorf("%s(%s) = %q, want the location", name, resp.Intent, got)
+			}
+		}
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	if _, err := render.NewTemplate("{{.Weather", render.Options{}); err == nil {
+		t.Error("parsed an unterminated action")
+	}
+
+	r, err := render.NewTemplate("{{.Weather.Temperature}}", render.Options{})
+	if err != nil {
+		t.Fatalf("NewTemplate: %v", err)
+	}
+	if err := r.Render(&bytes.Buffer{}, alerts); err == nil || !strings.Contains(err.Error(), "executing template") {
+		t.Errorf("error = %v, want an execution error for missing weather", err)
+	}
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// defaultLineWidth is used when the terminal width is unknown, as when
+// output is piped.
+const defaultLineWidth = 50
+
+// maxLineWidth keeps prose readable on wide terminals.
+const maxLineWidth = 80
+
+// LineWidth is the width text is wrapped and rules are drawn at.
+func (o Options) LineWidth() int {
+	switch {
+	case o.Width <= 0:
+		return defaultLineWidth
+	case o.Width > maxLineWidth:
+		return maxLineWidth
+	case o.Width < 20:
+		return 20
+	}
+	// Leave the last column free; some terminals wrap on it.
+	return o.Width - 1
+}
+
+func (o Options) colored() bool {
+	return o.Color && !o.Plain
+}
+
+// Terminal describes where output is going.
+type Terminal struct {
+	// Width is the number of columns, zero when unknown.
+	Width int
+	// Color is set for a terminal that should get ANSI colors: a TTY
+	// whose TERM isn't "dumb", with NO_COLOR unset.
+	Color bool
+}
+
+// Detect inspects w. Width comes from the terminal, or from COLUMNS when
+// w isn't one.
+func Detect(w io.Writer) Terminal {
+	var t Terminal
+	tty := false
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		tty = true
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil {
+			t.Width = width
+		}
+	}
+	if t.Width <= 0 {
+		t.Width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	// https://no-color.org: any non-empty value disables color.
+	t.Color = tty && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	return t
+}
+
+// ANSI SGR sequences used for highlighting.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiRed     = "\x1b[31m"
+	ansiBoldRed = "\x1b[1;31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[34m"
+	ansiCyan    = "\x1b[36m"
+)
+
+func colorize(s, code string) string {
+	return code + s + ansiReset
+}
+
+// Banner writes a boxed title and lines of text, such as the greeting of
+// the interactive prompt. The icon is left out in plain mode.
+func Banner(w io.Writer, opts Options, icon, title string, lines ...string) {
+	if opts.Plain {
+		fmt.Fprintf(w, "\n%s\n", title)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+		return
+	}
+	width := opts.LineWidth()
+	fmt.Fprintf(w, "\n%s\n", strings.Repeat("═", width))
+	fmt.Fprintf(w, "%s  %s\n", icon, title)
+	fmt.Fprintf(w, "%s\n", strings.Repeat("─", width))
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "%s\n", strings.Repeat("═", width))
+}
+
+// Symbol returns decorated, or plain in plain mode: for status marks such
+// as "✓" and "ok".
+func (o Options) Symbol(decorated, plain string) string {
+	if o.Plain {
+		return plain
+	}
+	return decorated
+}
@@ -0,0 +1,216 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"learn-go/ollama"
+	"learn-go/weather"
+)
+
+// textRenderer writes the decorated tables shown in the interactive
+// prompt, fitted to the terminal and colored when it supports color.
+type textRenderer struct {
+	opts Options
+}
+
+func (r *textRenderer) Render(out io.Writer, weatherData *ollama.WeatherResponse) error {
+	var b strings.Builder
+	switch {
+	case weatherData.Comparison != nil:
+		r.comparison(&b, weatherData.Comparison)
+	case weatherData.Forecast != nil:
+		r.forecast(&b, weatherData.Forecast)
+	case weatherData.Intent == ollama.IntentAlerts:
+		r.alerts(&b, weatherData.Alerts)
+	case weatherData.Weather != nil:
+		r.conditions(&b, weatherData.Weather)
+	}
+
+	if weatherData.Answer != nil {
+		r.answer(&b, weatherData.Answer)
+	}
+
+	switch {
+	case weatherData.Describer == ollama.DescriberTemplate:
+		r.heading(&b, "📝 Summary", "Summary")
+	case weatherData.Cached:
+		r.heading(&b, "🤖 AI Description (cached)", "AI Description (cached)")
+	default:
+		r.heading(&b, "🤖 AI Description", "AI Description")
+	}
+	b.WriteString(wrapText(weatherData.Description, r.opts.LineWidth()) + "\n")
+	if weatherData.DescriberError != "" {
+		b.WriteString(wrapText("(AI unavailable: "+weatherData.DescriberError+")", r.opts.LineWidth()) + "\n")
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// heading starts a section: a ruled, emoji-decorated title, or in plain
+// mode the bare title.
+func (r *textRenderer) heading(b *strings.Builder, decorated, plain string) {
+	if r.opts.Plain {
+		fmt.Fprintf(b, "\n%s\n", plain)
+		return
+	}
+	rule := strings.Repeat("─", r.opts.LineWidth())
+	fmt.Fprintf(b, "\n%s\n%s\n%s\n", rule, decorated, rule)
+}
+
+func (r *textRenderer) conditions(b *strings.Builder, data *weather.WeatherData) {
+	r.heading(b, "🌡️  Weather Data", "Weather Data")
+	u := r.opts.Units
+
+	rows := [][]string{{"Temperature:", r.observed(data, u.Temp)}}
+	add := func(ok bool, label, value string) {
+		if ok {
+			rows = append(rows, []string{label, value})
+		}
+	}
+	add(data.FeelsLike > 0, "Feels Like:", r.temp(data.FeelsLike, u.Temp))
+	add(true, "Conditions:", data.Conditions)
+	add(true, "Humidity:", fmt.Sprintf("%d%%", data.Humidity))
+	add(data.WindSpeed > 0, "Wind Speed:", u.Speed(data.WindSpeed, data.WindSpeedUnit))
+	add(data.WindDirection != "", "Wind Direction:", data.WindDirection)
+	add(data.WindGust > 0, "Wind Gust:", u.Speed(data.WindGust, data.WindSpeedUnit))
+	add(data.Visibility > 0, "Visibility:", u.Distance(data.Visibility))
+	add(data.Pressure > 0, "Pressure:", fmt.Sprintf("%.1f mb", data.Pressure))
+	add(data.DewPoint > 0, "Dew Point:", u.Temp(data.DewPoint))
+	add(data.UVIndex > 0, "UV Index:", fmt.Sprintf("%.1f", data.UVIndex))
+	add(data.CloudCover > 0, "Cloud Cover:", fmt.Sprintf("%d%%", data.CloudCover))
+	add(data.PrecipitationChance > 0, "Precipitation:", fmt.Sprintf("%d%%", data.PrecipitationChance))
+	add(true, "Last Updated:", data.Timestamp)
+	writeTable(b, rows)
+}
+
+func (r *textRenderer) forecast(b *strings.Builder, forecast *weather.Forecast) {
+	if forecast.Hourly {
+		r.heading(b, "🕒 Hourly Forecast", "Hourly Forecast")
+	} else {
+		r.heading(b, "📅 Forecast", "Forecast")
+	}
+
+	var rows [][]string
+	for _, p := range forecast.Periods {
+		name := p.Name
+		if forecast.Hourly || name == "" {
+			name = p.StartTime.Format("Mon 3PM")
+		}
+		conditions := p.ShortForecast
+		if p.PrecipitationChance > 0 {
+			conditions += fmt.Sprintf(" (%d%%)", p.PrecipitationChance)
+		}
+		rows = append(rows, []string{name + ":", r.temp(p.Temperature, r.opts.Units.WholeTemp), conditions})
+	}
+	writeTable(b, rows)
+
+	if forecast.Hourly && len(forecast.Periods) > 1 {
+		writeCharts(b, forecast.Periods, r.opts)
+	}
+}
+
+func (r *textRenderer) alerts(b *strings.Builder, alerts []weather.Alert) {
+	r.heading(b, "⚠️  Active Alerts", "Active Alerts")
+
+	if len(alerts) == 0 {
+		b.WriteString("None\n")
+	}
+	var rows [][]string
+	for _, a := range alerts {
+		rows = append(rows,
+			[]string{r.severity(a.Severity + ":"), a.Event},
+			[]string{"Until:", a.Expires})
+	}
+	writeTable(b, rows)
+}
+
+func (r *textRenderer) comparison(b *strings.Builder, places []ollama.PlaceWeather) {
+	r.heading(b, "⚖️  Comparison", "Comparison")
+
+	var rows [][]string
+	for _, p := range places {
+		rows = append(rows, []string{p.Location + ":", r.observed(p.Weather, r.opts.Units.Temp), p.Weather.Conditions, fmt.Sprintf("%d%%", p.Weather.Humidity)})
+	}
+	writeTable(b, rows)
+}
+
+func (r *textRenderer) answer(b *strings.Builder, answer *ollama.Answer) {
+	r.heading(b, "💬 Answer", "Answer")
+	b.WriteString(wrapText(answer.Short, r.opts.LineWidth()) + "\n")
+
+	var cited []string
+	for _, e := range answer.Evidence {
+		cited = append(cited, fmt.Sprintf("%s=%v", e.Field, e.Value))
+	}
+	b.WriteString(wrapText("Based on: "+strings.Join(cited, ", "), r.opts.LineWidth()) + "\n")
+}
+
+// observed formats the observed temperature with temp, or says it is
+// missing.
+func (r *textRenderer) observed(d *weather.WeatherData, format func(float64) string) string {
+	return ObservedTemp(d, func(f float64) string { return r.temp(f, format) })
+}
+
+// temp formats a temperature with format, colored by how warm it is.
+func (r *textRenderer) temp(f float64, format func(float64) string) string {
+	s := format(f)
+	if !r.opts.colored() {
+		return s
+	}
+	switch {
+	case f < 32:
+		return colorize(s, ansiBlue)
+	case f < 50:
+		return colorize(s, ansiCyan)
+	case f < 70:
+		return colorize(s, ansiGreen)
+	case f < 85:
+		return colorize(s, ansiYellow)
+	}
+	return colorize(s, ansiRed)
+}
+
+// severity colors an alert label by the NWS severity it starts with.
+func (r *textRenderer) severity(s string) string {
+	if !r.opts.colored() {
+		return s
+	}
+	switch {
+	case strings.HasPrefix(s, "Extreme"):
+		return colorize(s, ansiBoldRed)
+	case strings.HasPrefix(s, "Severe"):
+		return colorize(s, ansiRed)
+	case strings.HasPrefix(s, "Moderate"):
+		return colorize(s, ansiYellow)
+	case strings.HasPrefix(s, "Minor"):
+		return colorize(s, ansiCyan)
+	}
+	return s
+}
+
+// writeTable writes rows with columns aligned by display width, two
+// spaces apart.
+func writeTable(b *strings.Builder, rows [][]string) {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], StringWidth(cell))
+		}
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(row)-1 {
+				b.WriteString(cell)
+				break
+			}
+			b.WriteString(padRight(cell, widths[i]+2))
+		}
+		b.WriteString("\n")
+	}
+}
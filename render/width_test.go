@@ -0,0 +1,133 @@
+package render_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"learn-go/ollama"
+	"learn-go/render"
+	"learn-go/weather"
+)
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"Miami", 5},
+		{"77.0°F", 6},
+		{"東京", 4},
+		{"서울", 4},
+		{"ｈｉ", 4},
+		{"⛅", 2},
+		{"🌡️", 2},      // narrow symbol made an emoji by VS16
+		{"⚠️ x", 4},    // likewise
+		{"👩‍👩‍👧", 2},   // ZWJ sequence
+		{"e\u0301", 1}, // combining accent
+		{"\x1b[31mhot\x1b[0m", 3},
+	}
+	for _, tt := range tests {
+		if got := render.StringWidth(tt.s); got != tt.want {
+			t.Errorf("StringWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTextAlignsWideCharacters(t *testing.T) {
+	resp := &ollama.WeatherResponse{
+		Intent: ollama.IntentComparison,
+		Comparison: []ollama.PlaceWeather{
+			{Location: "東京", Weather: &weather.WeatherData{Temperature: 50, Conditions: "Clear"}},
+			{Location: "Miami", Weather: &weather.WeatherData{Temperature: 77, Conditions: "Cloudy"}},
+		},
+	}
+	got := renderString(t, render.Text, render.Options{}, resp)
+
+	var cols []int
+	for _, line := range strings.Split(got, "\n") {
+		if i := strings.Index(line, "°F"); i >= 0 {
+			cols = append(cols, render.StringWidth(line[:i]))
+		}
+	}
+	if len(cols) != 2 || cols[0] != cols[1] {
+		t.Errorf("temperatures start at columns %v, want aligned in\n%s", cols, got)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	resp := *current
+	resp.Description = strings.Repeat("word ", 40)
+
+	for _, width := range []int{0, 40, 200} {
+		got := renderString(t, render.Text, render.Options{Width: width}, &resp)
+		limit := render.Options{Width: width}.LineWidth()
+		for _, line := range strings.Split(got, "\n") {
+			if !strings.HasPrefix(line, "word") && !strings.HasPrefix(line, "─") {
+				continue
+			}
+			if w := render.StringWidth(line); w > limit {
+				t.Errorf("width %d: line %q is %d cells, want at most %d", width, line, w, limit)
+			}
+		}
+	}
+}
+
+func TestTextPlainAndColor(t *testing.T) {
+	plain := renderString(t, render.Text, render.Options{Plain: true, Color: true}, alerts)
+	for _, bad := range []string{"⚠", "─", "🤖", "\x1b["} {
+		if strings.Contains(plain, bad) {
+			t.Errorf("plain output contains %q:\n%s", bad, plain)
+		}
+	}
+	if !strings.Contains(plain, "Active Alerts\n") || !strings.Contains(plain, "Flood Watch") {
+		t.Errorf("plain output =\n%s", plain)
+	}
+
+	colored := renderString(t, render.Text, render.Options{Color: true}, alerts)
+	if !strings.Contains(colored, "\x1b[31mSevere:\x1b[0m") {
+		t.Errorf("severity not colored:\n%q", colored)
+	}
+	colored = renderString(t, render.Text, render.Options{Color: true}, current)
+	if !strings.Contains(colored, "\x1b[33m77.0°F\x1b[0m") {
+		t.Errorf("temperature not colored:\n%q", colored)
+	}
+	if uncolored := renderString(t, render.Text, render.Options{}, current); strings.Contains(uncolored, "\x1b[") {
+		t.Errorf("colored without Color:\n%q", uncolored)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("COLUMNS", "120")
+	t.Setenv("NO_COLOR", "")
+	got := render.Detect(&bytes.Buffer{})
+	if got.Width != 120 || got.Color {
+		t.Errorf("Detect(buffer) = %+v, want width from COLUMNS and no color", got)
+	}
+}
+
+func TestBanner(t *testing.T) {
+	var buf bytes.Buffer
+	render.Banner(&buf, render.Options{Plain: true}, "🌤️", "Weather Assistant", "Type 'quit' to exit")
+	if buf.String() != "\nWeather Assistant\nType 'quit' to exit\n" {
+		t.Errorf("plain banner = %q", buf.String())
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"Miami, FL", 20, "Miami, FL"},
+		{"Miami, FL", 5, "Miami"},
+		{"東京 Tokyo", 3, "東"},
+		{"\x1b[31mhot\x1b[0m day", 2, "\x1b[31mho\x1b[0m"},
+	}
+	for _, tt := range tests {
+		if got := render.Truncate(tt.s, tt.width); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"learn-go/ollama"
+)
+
+// yamlRenderer writes the response as YAML. It is produced from the JSON
+// encoding, so field names, omitted fields and key order match the API.
+type yamlRenderer struct{}
+
+func (r *yamlRenderer) Render(w io.Writer, resp *ollama.WeatherResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	root, err := parseNode(json.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return fmt.Errorf("converting to yaml: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	writeYAML(&buf, root, 0)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// node is a JSON value with object keys kept in their encoded order.
+type node struct {
+	keys   []string // object keys; nil for arrays and scalars
+	items  []*node  // object values or array elements
+	object bool
+	array  bool
+	scalar interface{} // string, json.Number, bool or nil
+}
+
+func parseNode(dec *json.Decoder) (*node, error) {
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return parseToken(dec, tok)
+}
+
+func parseToken(dec *json.Decoder, tok json.Token) (*node, error) {
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return &node{scalar: tok}, nil
+	}
+
+	n := &node{object: delim == '{', array: delim == '['}
+	for dec.More() {
+		if n.object {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, key.(string))
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		item, err := parseToken(dec, tok)
+		if err != nil {
+			return nil, err
+		}
+		n.items = append(n.items, item)
+	}
+	// Consume the closing delimiter.
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (n *node) isCollection() bool {
+	return (n.object || n.array) && len(n.items) > 0
+}
+
+// writeYAML writes n in block style at the given indent. Collections are
+// expected to start on a fresh line.
+func writeYAML(buf *bytes.Buffer, n *node, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch {
+	case n.object && len(n.items) > 0:
+		for i, key := range n.keys {
+			buf.WriteString(pad + yamlString(key) + ":")
+			writeValue(buf, n.items[i], indent+1)
+		}
+	case n.array && len(n.items) > 0:
+		for _, item := range n.items {
+			buf.WriteString(pad + "-")
+			if item.object && len(item.items) > 0 {
+				// The first key shares the dash's line.
+				var sub bytes.Buffer
+				writeYAML(&sub, item, indent+1)
+				buf.WriteString(" " + strings.TrimLeft(sub.String(), " "))
+				continue
+			}
+			writeValue(buf, item, indent+1)
+		}
+	default:
+		buf.WriteString(pad + yamlScalar(n) + "\n")
+	}
+}
+
+// writeValue writes n after a "key:" or "-" already on the line.
+func writeValue(buf *bytes.Buffer, n *node, indent int) {
+	if n.isCollection() {
+		buf.WriteString("\n")
+		writeYAML(buf, n, indent)
+		return
+	}
+	buf.WriteString(" " + yamlScalar(n) + "\n")
+}
+
+func yamlScalar(n *node) string {
+	switch {
+	case n.object:
+		return "{}"
+	case n.array:
+		return "[]"
+	}
+	switch v := n.scalar.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	}
+	return fmt.Sprint(n.scalar)
+}
+
+// yamlString returns s plain when YAML would read it back as the same
+// string, and double-quoted otherwise.
+func yamlString(s string) string {
+	if needsQuotes(s) {
+		// JSON string escapes are valid in YAML double-quoted scalars.
+		quoted, _ := json.Marshal(s)
+		return string(quoted)
+	}
+	return s
+}
+
+func needsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	// Numbers, and strings such as dates that some parsers would read as
+	// timestamps or sexagesimal numbers.
+	if s[0] >= '0' && s[0] <= '9' || s[0] == '.' || s[0] == '+' {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
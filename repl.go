@@ -0,0 +1,495 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"learn-go/internal/lineedit"
+	"learn-go/ollama"
+	"learn-go/profile"
+	"learn-go/render"
+	"learn-go/weather"
+)
+
+// maxRecentLocations is how many answered locations are kept for tab
+// completion.
+const maxRecentLocations = 100
+
+// errQuit ends the prompt.
+var errQuit = errors.New("quit")
+
+// session is the state of the interactive prompt, which slash-commands
+// change as it runs.
+type session struct {
+	a       *app
+	o       *options
+	weather *weather.NWSService
+	client  *ollama.Client
+	ui      io.Writer
+	recent  *recentLocations
+	debug   bool
+	editor  *lineedit.Editor
+}
+
+type slashCommand struct {
+	name    string
+	args    string
+	summary string
+	run     func(s *session, arg string) error
+	// complete lists the values the argument can take, for Tab.
+	complete func(s *session) []string
+}
+
+var slashCommands []slashCommand
+
+func init() {
+	slashCommands = []slashCommand{
+		{"/units", "[imperial|metric]", "show or change units", (*session).units, func(*session) []string {
+			return []string{string(render.Imperial), string(render.Metric)}
+		}},
+		{"/model", "[name]", "show or change the Ollama model", (*session).model, nil},
+		{"/format", "[format|template]", "show or change the output format or template", (*session).format, func(*session) []string {
+			return append(append([]string(nil), render.Formats...), render.BuiltinNames()...)
+		}},
+		{"/home", "[location]", "show the weather at home, or save a new home", (*session).showHome, func(s *session) []string {
+			return s.recent.Names()
+		}},
+		{"/history", "[n|clear]", "list the last n queries, or clear the history", (*session).history, func(*session) []string {
+			return []string{"clear"}
+		}},
+		{"/clear", "", "clear the screen", (*session).clear, nil},
+		{"/debug", "[on|off]", "show how each query was answered", (*session).toggleDebug, func(*session) []string {
+			return []string{"on", "off"}
+		}},
+		{"/help", "", "list slash-commands", (*session).help, nil},
+		{"/quit", "", "leave the prompt", func(*session, string) error { return errQuit }, nil},
+	}
+}
+
+func lookupSlash(name string) *slashCommand {
+	for i := range slashCommands {
+		if strings.EqualFold(slashCommands[i].name, name) {
+			return &slashCommands[i]
+		}
+	}
+	return nil
+}
+
+// repl runs the interactive prompt.
+func (a *app) repl(args []string) error {
+	fs, o := a.flags("repl")
+	_, weatherSvc, client, err := a.setup(fs, o, args, ollama.PriorityInteractive)
+	if err != nil {
+		return helpOK(err)
+	}
+	// With a machine-readable format or a template only the results go to
+	// stdout, so the prompt can be piped into other tools.
+	ui := a.stdout
+	if !strings.EqualFold(o.output, render.Text) || o.template != "" || o.templateFile != "" {
+		ui = a.stderr
+	}
+
+	s := &session{a: a, o: o, weather: weatherSvc, client: client, ui: ui}
+	s.recent = loadRecentLocations(a.stateFile("locations"))
+	s.editor = lineedit.New(a.stdin, ui)
+	s.editor.Prompt = "> "
+	s.editor.Width = render.StringWidth
+	s.editor.Complete = s.complete
+	s.editor.History = lineedit.NewHistory(lineedit.DefaultHistorySize)
+	if path := a.stateFile("history"); path != "" && a.cfg.History {
+		if s.editor.History, err = lineedit.LoadHistory(path, lineedit.DefaultHistorySize); err != nil {
+			fmt.Fprintf(a.stderr, "Warning: %v\n", err)
+			s.editor.History = lineedit.NewHistory(lineedit.DefaultHistorySize)
+		}
+	}
+
+	render.Banner(ui, o.display, "🌤️", "Weather Assistant",
+		"Type 'quit' to exit, or /help for commands",
+		"Ask me about the weather anywhere in the US!",
+		"Example: 'What's the weather like in Miami?'",
+		"         'Will it rain this weekend in Denver?'",
+		"         'Is Miami warmer than Austin?'")
+
+	for {
+		fmt.Fprintln(ui)
+		line, err := s.editor.ReadLine()
+		if errors.Is(err, lineedit.ErrInterrupt) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		query := strings.TrimSpace(line)
+		switch {
+		case query == "":
+			continue
+		case strings.EqualFold(query, "quit"):
+			err = errQuit
+		case strings.HasPrefix(query, "/"):
+			err = s.slash(query)
+		default:
+			err = s.ask(func(client *ollama.Client) (*ollama.WeatherResponse, error) {
+				return client.GetWeather(query, maxRetries)
+			})
+		}
+		if errors.Is(err, errQuit) {
+			fmt.Fprintf(ui, "\nGoodbye!%s\n", o.display.Symbol(" 👋", ""))
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(ui, "\n%sError: %v\n", o.display.Symbol("❌ ", ""), err)
+		}
+	}
+}
+
+// stateFile returns the path of a file the prompt keeps between sessions,
+// or "" when there is no configuration directory.
+func (a *app) stateFile(name string) string {
+	if a.cfg.ConfigDir == "" {
+		return ""
+	}
+	return filepath.Join(a.cfg.ConfigDir, name)
+}
+
+// ask answers a query with fetch, prints the result and remembers where it
+// was for.
+func (s *session) ask(fetch func(client *ollama.Client) (*ollama.WeatherResponse, error)) error {
+	client := s.client
+	start := time.Now()
+	resp, err := withTimeout(s.o.timeout, func() (*ollama.WeatherResponse, error) {
+		return fetch(client)
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.o.renderer.Render(s.a.stdout, resp); err != nil {
+		return err
+	}
+
+	s.recent.Add(resp.Location)
+	for _, p := range resp.Comparison {
+		s.recent.Add(p.Location)
+	}
+	if s.debug {
+		s.debugInfo(resp, time.Since(start))
+	}
+	return nil
+}
+
+func (s *session) debugInfo(resp *ollama.WeatherResponse, elapsed time.Duration) {
+	fmt.Fprintf(s.ui, "\n[debug] intent=%s location=%q describer=%s cached=%t elapsed=%v\n",
+		resp.Intent, resp.Location, resp.Describer, resp.Cached, elapsed.Round(time.Millisecond))
+	var states []string
+	for _, b := range append(s.weather.Breakers(), s.client.Breakers()...) {
+		states = append(states, b.Name()+"="+b.State().String())
+	}
+	fmt.Fprintf(s.ui, "[debug] model=%s breakers: %s\n", s.client.Model(), strings.Join(states, " "))
+}
+
+// slash runs a slash-command line such as "/units metric".
+func (s *session) slash(line string) error {
+	name, arg, _ := strings.Cut(line, " ")
+	cmd := lookupSlash(name)
+	if cmd == nil {
+		return fmt.Errorf("unknown command %s (try /help)", name)
+	}
+	return cmd.run(s, strings.TrimSpace(arg))
+}
+
+func (s *session) help(string) error {
+	w := s.ui
+	fmt.Fprintln(w, "\nCommands:")
+	for _, c := range slashCommands {
+		fmt.Fprintf(w, "  %-28s %s\n", strings.TrimSpace(c.name+" "+c.args), c.summary)
+	}
+	fmt.Fprintln(w, "\nAnything else is a question about the weather. Tab completes commands")
+	fmt.Fprintln(w, "and places asked about before; the arrow keys recall earlier queries.")
+	return nil
+}
+
+func (s *session) units(arg string) error {
+	if arg == "" {
+		fmt.Fprintf(s.ui, "Units: %s\n", s.o.display.Units)
+		return nil
+	}
+	units, err := render.ParseUnits(arg)
+	if err != nil {
+		return err
+	}
+	display := s.o.display
+	display.Units = units
+	return s.rerender(func(o *options) {
+		o.units = string(units)
+		o.display = display
+	}, fmt.Sprintf("Units: %s", units))
+}
+
+func (s *session) format(arg string) error {
+	if arg == "" {
+		switch {
+		case s.o.templateFile != "":
+			fmt.Fprintf(s.ui, "Template file: %s\n", s.o.templateFile)
+		case s.o.template != "":
+			fmt.Fprintf(s.ui, "Template: %s\n", s.o.template)
+		default:
+			fmt.Fprintf(s.ui, "Format: %s\n", s.o.output)
+		}
+		return nil
+	}
+	return s.rerender(func(o *options) {
+		o.templateFile = ""
+		o.template = ""
+		if _, ok := render.Builtin(arg); ok || strings.Contains(arg, "{{") {
+			o.template = arg
+		} else {
+			o.output = arg
+		}
+	}, fmt.Sprintf("Output: %s", arg))
+}
+
+// rerender applies change to the options and switches to the renderer
+// they describe, leaving everything as it was if that fails.
+func (s *session) rerender(change func(o *options), done string) error {
+	next := *s.o
+	change(&next)
+	r, err := next.newRenderer()
+	if err != nil {
+		return err
+	}
+	next.renderer = r
+	*s.o = next
+	fmt.Fprintln(s.ui, done)
+	return nil
+}
+
+func (s *session) model(arg string) error {
+	if arg == "" {
+		fmt.Fprintf(s.ui, "Model: %s\n", s.client.Model())
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	models, err := s.client.Models(ctx)
+	cancel()
+	if err == nil && !ollama.HasModel(models, arg) {
+		return fmt.Errorf("model %s not installed (run: ollama pull %s)", arg, arg)
+	}
+
+	next := *s.o
+	next.model = arg
+	weatherSvc, client, err := s.a.build(&next)
+	if err != nil {
+		return err
+	}
+	*s.o = next
+	s.weather, s.client = weatherSvc, client
+	fmt.Fprintf(s.ui, "Model: %s\n", arg)
+	return nil
+}
+
+func (s *session) showHome(arg string) error {
+	p := s.a.profile
+	if arg == "" {
+		if p.Home == nil {
+			return errors.New("no home set (use /home <location>)")
+		}
+		home := p.Home.Name
+		return s.ask(func(client *ollama.Client) (*ollama.WeatherResponse, error) {
+			return client.GetWeatherData(home, maxRetries)
+		})
+	}
+
+	geo, err := s.weather.Locate(arg)
+	if err != nil {
+		return err
+	}
+	lat, lon := geo.Coordinates()
+	if err := p.Set(profile.Home, profile.Place{Name: arg, Lat: lat, Lon: lon}); err != nil {
+		return err
+	}
+	// Rebuild the clients so "home" in a query means the new place.
+	weatherSvc, client, err := s.a.build(s.o)
+	if err != nil {
+		return err
+	}
+	s.weather, s.client = weatherSvc, client
+	if err := p.Save(); err != nil {
+		fmt.Fprintf(s.ui, "Home: %s, for this session only (%v)\n", arg, err)
+		return nil
+	}
+	fmt.Fprintf(s.ui, "Home: %s (saved to %s)\n", arg, p.Path())
+	return nil
+}
+
+func (s *session) history(arg string) error {
+	h := s.editor.History
+	if arg == "clear" {
+		if err := h.Clear(); err != nil {
+			return err
+		}
+		fmt.Fprintln(s.ui, "History cleared")
+		return nil
+	}
+	n := 20
+	if arg != "" {
+		var err error
+		if n, err = strconv.Atoi(arg); err != nil || n <= 0 {
+			return fmt.Errorf("/history: want a count or \"clear\", got %q", arg)
+		}
+	}
+	entries := h.Entries()
+	first := max(len(entries)-n, 0)
+	for i := first; i < len(entries); i++ {
+		fmt.Fprintf(s.ui, "%5d  %s\n", i+1, entries[i])
+	}
+	return nil
+}
+
+func (s *session) clear(string) error {
+	fmt.Fprint(s.ui, "\x1b[H\x1b[2J")
+	return nil
+}
+
+func (s *session) toggleDebug(arg string) error {
+	switch strings.ToLower(arg) {
+	case "":
+		s.debug = !s.debug
+	case "on":
+		s.debug = true
+	case "off":
+		s.debug = false
+	default:
+		return fmt.Errorf("/debug: want on or off, got %q", arg)
+	}
+	state := "off"
+	if s.debug {
+		state = "on"
+	}
+	fmt.Fprintf(s.ui, "Debug: %s\n", state)
+	return nil
+}
+
+// places are the names Tab completes in a query: those saved in the
+// profile, then places asked about before.
+func (s *session) places() []string {
+	return append(s.a.profile.Names(), s.recent.Names()...)
+}
+
+// complete completes slash-commands and their arguments, and otherwise
+// the name of a place asked about before.
+func (s *session) complete(line string, pos int) ([]string, int) {
+	line = line[:pos]
+	if !strings.HasPrefix(line, "/") {
+		return completeLocation(line, s.places())
+	}
+
+	name, arg, found := strings.Cut(line, " ")
+	if !found {
+		var names []string
+		for _, c := range slashCommands {
+			names = append(names, c.name)
+		}
+		return withPrefix(names, name), 0
+	}
+	cmd := lookupSlash(name)
+	if cmd == nil || cmd.complete == nil {
+		return nil, 0
+	}
+	typed := strings.TrimLeft(arg, " ")
+	return withPrefix(cmd.complete(s), typed), len(line) - len(typed)
+}
+
+// completeLocation finds the longest tail of line, starting at a word,
+// that begins one of locations, so "weather in san" completes to
+// "weather in San Francisco, CA".
+func completeLocation(line string, locations []string) ([]string, int) {
+	for start := 0; start <= len(line); start++ {
+		if start > 0 && line[start-1] != ' ' {
+			continue
+		}
+		if matches := withPrefix(locations, line[start:]); len(matches) > 0 {
+			return matches, start
+		}
+	}
+	return nil, 0
+}
+
+// withPrefix returns the candidates starting with prefix, ignoring case.
+func withPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, c := range candidates {
+		if len(c) >= len(prefix) && strings.EqualFold(c[:len(prefix)], prefix) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// recentLocations are the places answers were for, most recent first,
+// saved one per line so completion carries over between sessions.
+type recentLocations struct {
+	path  string
+	names []string
+}
+
+func loadRecentLocations(path string) *recentLocations {
+	r := &recentLocations{path: path}
+	if path == "" {
+		return r
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return r
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() && len(r.names) < maxRecentLocations {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			r.names = append(r.names, name)
+		}
+	}
+	return r
+}
+
+// Names returns the locations in alphabetical order.
+func (r *recentLocations) Names() []string {
+	names := append([]string(nil), r.names...)
+	sort.Strings(names)
+	return names
+}
+
+// Add moves name to the front, dropping the oldest beyond
+// maxRecentLocations. Saving is best effort.
+func (r *recentLocations) Add(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, "\r\n") {
+		return
+	}
+	names := []string{name}
+	for _, n := range r.names {
+		if !strings.EqualFold(n, name) && len(names) < maxRecentLocations {
+			names = append(names, n)
+		}
+	}
+	r.names = names
+
+	if r.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o700); err != nil {
+		return
+	}
+	os.WriteFile(r.path, []byte(strings.Join(r.names, "\n")+"\n"), 0o600)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"learn-go/config"
+	"learn-go/profile"
+)
+
+func TestCompleteLocation(t *testing.T) {
+	locations := []string{"Miami, FL", "San Diego, CA", "San Francisco, CA"}
+	tests := []struct {
+		line  string
+		want  []string
+		start int
+	}{
+		{"weather in mia", []string{"Miami, FL"}, 11},
+		{"weather in San", []string{"San Diego, CA", "San Francisco, CA"}, 11},
+		{"is san f", []string{"San Francisco, CA"}, 3},
+		{"weather in Paris", nil, 0},
+	}
+	for _, tt := range tests {
+		got, start := completeLocation(tt.line, locations)
+		if !reflect.DeepEqual(got, tt.want) || (got != nil && start != tt.start) {
+			t.Errorf("completeLocation(%q) = %q, %d; want %q, %d", tt.line, got, start, tt.want, tt.start)
+		}
+	}
+}
+
+func TestSlashCompletion(t *testing.T) {
+	s := &session{recent: &recentLocations{names: []string{"Miami, FL"}}}
+	tests := []struct {
+		line  string
+		want  []string
+		start int
+	}{
+		{"/h", []string{"/home", "/history", "/help"}, 0},
+		{"/units m", []string{"metric"}, 7},
+		{"/format  one", []string{"oneline"}, 9},
+		{"/home mi", []string{"Miami, FL"}, 6},
+	}
+	for _, tt := range tests {
+		got, start := s.complete(tt.line, len(tt.line))
+		if !reflect.DeepEqual(got, tt.want) || start != tt.start {
+			t.Errorf("complete(%q) = %q, %d; want %q, %d", tt.line, got, start, tt.want, tt.start)
+		}
+	}
+}
+
+func TestReplSlashCommands(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{OllamaURL: "http://127.0.0.1:1", Units: "imperial", Output: "text", ConfigDir: dir, History: true}
+	input := strings.Join([]string{
+		"/units metric",
+		"/units",
+		"/format oneline",
+		"/format nope",
+		"/debug",
+		"/home",
+		"/bogus",
+		"/history 2",
+		"/quit",
+	}, "\n") + "\n"
+	var stdout, stderr bytes.Buffer
+	a := &app{cfg: cfg, profile: &profile.Profile{}, stdin: strings.NewReader(input), stdout: &stdout, stderr: &stderr}
+	if err := a.repl([]string{"-plain"}); err != nil {
+		t.Fatalf("repl: %v", err)
+	}
+
+	out := stdout.String() + stderr.String()
+	for _, want := range []string{
+		"Units: metric",
+		"Output: oneline",
+		`unknown output format "nope"`,
+		"Debug: on",
+		"no home set",
+		"unknown command /bogus",
+		"    7  /bogus\n    8  /history 2\n",
+		"Goodbye!",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	saved, err := os.ReadFile(filepath.Join(dir, "history"))
+	if err != nil {
+		t.Fatalf("reading history: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(saved)), "\n"); len(lines) != 9 || lines[0] != "/units metric" {
+		t.Errorf("saved history = %q", lines)
+	}
+}
+
+func TestRecentLocations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locations")
+	r := loadRecentLocations(path)
+	r.Add("Miami, FL")
+	r.Add("Denver, CO")
+	r.Add("miami, fl")
+
+	got := loadRecentLocations(path).Names()
+	if want := []string{"Denver, CO", "miami, fl"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded locations = %q, want %q", got, want)
+	}
+}
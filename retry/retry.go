@@ -0,0 +1,235 @@
+// Package retry runs calls to flaky upstream services with capped,
+// jittered exponential backoff, honouring Retry-After and an overall time
+// budget. Only errors classified as transient are retried.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy controls how often and how long a call is retried.
+type Policy struct {
+	// MaxAttempts is the total number of calls, including the first.
+	// Values below one mean a single attempt.
+	MaxAttempts int
+	// BaseDelay is the wait before the second attempt; it doubles for each
+	// attempt after that, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Budget bounds the total time spent, including waits. Zero means no
+	// limit beyond MaxAttempts.
+	Budget time.Duration
+}
+
+// Default suits interactive calls to public APIs.
+var Default = Policy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    4 * time.Second,
+	Budget:      20 * time.Second,
+}
+
+// Error is returned when a call failed. It records how many attempts were
+// made and how long they took alongside the last error.
+type Error struct {
+	Attempts int
+	Elapsed  time.Duration
+	// Reason is why retrying stopped: "permanent error", "attempts
+	// exhausted", "budget exhausted" or "context done".
+	Reason string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Attempts <= 1 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (%s after %d attempts in %v)", e.Err, e.Reason, e.Attempts, e.Elapsed.Round(time.Millisecond))
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is an unexpected HTTP status from an upstream service.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the server's requested wait, zero if none was given.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// maxErrorBody bounds how much of an error response is kept.
+const maxErrorBody = 4 << 10
+
+// FromResponse builds a StatusError from resp, reading (but not closing)
+// at most a few kilobytes of its body.
+func FromResponse(resp *http.Response) *StatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// ParseRetryAfter reads a Retry-After header given either in seconds or as
+// an HTTP date.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+type retryableError struct{ err error }
+
+func (e retryableError) Error() string { return e.err.Error() }
+func (e retryableError) Unwrap() error { return e.err }
+
+// Retryable marks err as transient, for failures the classifier can't
+// recognise on its own such as a truncated or incomplete response body.
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return retryableError{err}
+}
+
+// IsRetryable reports whether err is worth another attempt: network
+// failures, timeouts, 408, 425, 429 and 5xx responses, an upstream that is
+// still loading its model, and errors wrapped with Retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var marked retryableError
+	if errors.As(err, &marked) {
+		return true
+	}
+
+	var status *StatusError
+	if errors.As(err, &status) {
+		switch {
+		case status.StatusCode == http.StatusRequestTimeout,
+			status.StatusCode == http.StatusTooEarly,
+			status.StatusCode == http.StatusTooManyRequests,
+			status.StatusCode >= 500:
+			return true
+		}
+		return modelLoading(status.Body)
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// modelLoading recognises Ollama's replies while a model is being loaded
+// into memory.
+func modelLoading(body string) bool {
+	body = strings.ToLower(body)
+	return strings.Contains(body, "loading model") || strings.Contains(body, "model is loading")
+}
+
+// Do calls fn until it succeeds, returns an error that isn't retryable, or
+// the policy runs out. fn receives the 1-based attempt number. The error,
+// if any, is an *Error.
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context, attempt int) error) error {
+	start := time.Now()
+	maxAttempts := p.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		callCtx := ctx
+		cancel := func() {}
+		if p.Budget > 0 {
+			callCtx, cancel = context.WithDeadline(ctx, start.Add(p.Budget))
+		}
+		err = fn(callCtx, attempt)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		fail := func(reason string) error {
+			return &Error{Attempts: attempt, Elapsed: time.Since(start), Reason: reason, Err: err}
+		}
+
+		if ctx.Err() != nil {
+			return fail("context done")
+		}
+		if !IsRetryable(err) {
+			return fail("permanent error")
+		}
+		if attempt >= maxAttempts {
+			return fail("attempts exhausted")
+		}
+
+		delay := p.backoff(attempt)
+		var status *StatusError
+		if errors.As(err, &status) && status.RetryAfter > delay {
+			delay = status.RetryAfter
+		}
+		if p.Budget > 0 && time.Since(start)+delay >= p.Budget {
+			return fail("budget exhausted")
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return fail("context done")
+			}
+		}
+	}
+}
+
+// backoff is the wait after the given attempt: exponential, capped at
+// MaxDelay, with "equal jitter" so waits land between half and all of the
+// exponential value.
+func (p Policy) backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay << uint(attempt-1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,166 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/retry"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain", errors.New("boom"), false},
+		{"marked", retry.Retryable(errors.New("truncated")), true},
+		{"wrapped mark", fmt.Errorf("decoding: %w", retry.Retryable(errors.New("eof"))), true},
+		{"429", &retry.StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"500", &retry.StatusError{StatusCode: http.StatusInternalServerError}, true},
+		{"503", &retry.StatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"404", &retry.StatusError{StatusCode: http.StatusNotFound}, false},
+		{"400 while loading", &retry.StatusError{StatusCode: http.StatusBadRequest, Body: `{"error":"loading model"}`}, true},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"unexpected eof", fmt.Errorf("reading: %w", io.ErrUnexpectedEOF), true},
+		{"canceled", context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retry.IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		if got := retry.ParseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	transient := &retry.StatusError{StatusCode: http.StatusServiceUnavailable, Body: "busy"}
+	permanent := &retry.StatusError{StatusCode: http.StatusNotFound, Body: "missing"}
+	slow := &retry.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 30 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		policy    retry.Policy
+		errs      []error
+		wantCalls int
+		wantErr   string
+	}{
+		{"first try", retry.Policy{MaxAttempts: 3}, []error{nil}, 1, ""},
+		{"transient then ok", retry.Policy{MaxAttempts: 3}, []error{transient, nil}, 2, ""},
+		{"exhausted", retry.Policy{MaxAttempts: 3}, []error{transient}, 3, "attempts exhausted after 3 attempts"},
+		{"permanent", retry.Policy{MaxAttempts: 3}, []error{permanent}, 1, "unexpected status: 404, body: missing"},
+		{"zero attempts means one", retry.Policy{}, []error{transient}, 1, "unexpected status: 503"},
+		{
+			"budget",
+			retry.Policy{MaxAttempts: 10, Budget: 50 * time.Millisecond},
+			[]error{slow},
+			2,
+			"budget exhausted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := tt.policy.Do(context.Background(), func(ctx context.Context, attempt int) error {
+				calls++
+				if attempt != calls {
+					t.Errorf("attempt = %d, want %d", attempt, calls)
+				}
+				return tt.errs[min(calls, len(tt.errs))-1]
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Do: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			var rerr *retry.Error
+			if !errors.As(err, &rerr) || rerr.Attempts != tt.wantCalls {
+				t.Errorf("error = %#v, want *retry.Error with %d attempts", err, tt.wantCalls)
+			}
+			var status *retry.StatusError
+			if !errors.As(err, &status) {
+				t.Errorf("error %v does not unwrap to the last StatusError", err)
+			}
+		})
+	}
+}
+
+func TestDoHonoursRetryAfter(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	wait := &retry.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 100 * time.Millisecond}
+
+	start := time.Now()
+	calls := 0
+	err := policy.Do(context.Background(), func(ctx context.Context, attempt int) error {
+		calls++
+		if calls == 1 {
+			return wait
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("retried after %v, want at least the Retry-After of 100ms", elapsed)
+	}
+}
+
+func TestDoStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := retry.Policy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	calls := 0
+	err := policy.Do(ctx, func(ctx context.Context, attempt int) error {
+		calls++
+		cancel()
+		return &retry.StatusError{StatusCode: http.StatusBadGateway}
+	})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	var rerr *retry.Error
+	if !errors.As(err, &rerr) || rerr.Reason != "context done" {
+		t.Errorf("error = %v, want context done", err)
+	}
+}
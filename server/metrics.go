@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// metric is one series in the Prometheus text format.
+type metric struct {
+	labels string
+	value  float64
+}
+
+func writeMetric(w io.Writer, name, kind, help string, series []metric) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+	for _, m := range series {
+		if m.labels == "" {
+			fmt.Fprintf(w, "%s %g\n", name, m.value)
+			continue
+		}
+		fmt.Fprintf(w, "%s{%s} %g\n", name, m.labels, m.value)
+	}
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var state, calls []metric
+	for _, b := range s.breakers {
+		stats := b.Stats()
+		upstream := fmt.Sprintf("upstream=%q", stats.Name)
+		state = append(state, metric{upstream, float64(stats.State)})
+		calls = append(calls,
+			metric{upstream + `,result="success"`, float64(stats.Successes)},
+			metric{upstream + `,result="failure"`, float64(stats.Failures)},
+			metric{upstream + `,result="rejected"`, float64(stats.Rejected)},
+		)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetric(w, "weather_breaker_state", "gauge", "Circuit breaker state: 0 closed, 1 open, 2 half-open.", state)
+	writeMetric(w, "weather_breaker_calls_total", "counter", "Upstream calls by outcome; rejected calls were failed fast by an open breaker.", calls)
+
+	cache := s.client.CacheStats()
+	writeMetric(w, "weather_description_cache_entries", "gauge", "Model descriptions held in the cache.", []metric{{"", float64(cache.Entries)}})
+	writeMetric(w, "weather_description_cache_lookups_total", "counter", "Description cache lookups by outcome.", []metric{
+		{`result="hit"`, float64(cache.Hits)},
+		{`result="miss"`, float64(cache.Misses)},
+	})
+
+	admission := s.client.Admission().Stats()
+	writeMetric(w, "weather_ollama_in_flight", "gauge", "Model calls running.", []metric{{"", float64(admission.InFlight)}})
+	writeMetric(w, "weather_ollama_queued", "gauge", "Model calls waiting for a slot.", []metric{{"", float64(admission.Queued)}})
+	writeMetric(w, "weather_ollama_admissions_total", "counter", "Model calls by admission outcome.", []metric{
+		{`result="admitted"`, float64(admission.Admitted)},
+		{`result="shed"`, float64(admission.Shed)},
+	})
+}
@@ -0,0 +1,107 @@
+// Package server answers weather queries over HTTP, alongside health and
+// metrics endpoints reporting the state of the upstream circuit breakers.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"learn-go/breaker"
+	"learn-go/middleware"
+	"learn-go/ollama"
+	"learn-go/weather"
+)
+
+// maxRetries is the retry count used for model calls made on behalf of
+// HTTP clients.
+const maxRetries = 3
+
+// Server handles /weather, /health and /metrics.
+type Server struct {
+	client   *ollama.Client
+	breakers []*breaker.Breaker
+}
+
+// New returns a server answering queries with client and reporting on
+// breakers.
+func New(client *ollama.Client, breakers ...*breaker.Breaker) *Server {
+	return &Server{client: client, breakers: breakers}
+}
+
+// Handler routes requests. The middlewares wrap /weather only, so health
+// checks and scrapes aren't rate limited or asked for an API key.
+func (s *Server) Handler(mw ...middleware.Middleware) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/weather", middleware.Chain(s.handleWeather, mw...))
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	return mux
+}
+
+func (s *Server) handleWeather(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing query parameter q"))
+		return
+	}
+
+	resp, err := s.client.GetWeather(query, maxRetries)
+	if err != nil {
+		var open *breaker.OpenError
+		var overloaded *ollama.OverloadedError
+		switch {
+		case errors.As(err, &open):
+			setRetryAfter(w, open.RetryIn)
+			writeError(w, http.StatusServiceUnavailable, err)
+		case errors.As(err, &overloaded):
+			setRetryAfter(w, overloaded.RetryAfter)
+			writeError(w, http.StatusServiceUnavailable, err)
+		case errors.Is(err, ollama.ErrNoLocation), errors.Is(err, weather.ErrOutsideCoverage):
+			writeError(w, http.StatusBadRequest, err)
+		case errors.Is(err, weather.ErrLocationNotFound):
+			writeError(w, http.StatusNotFound, err)
+		default:
+			writeError(w, http.StatusBadGateway, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Health is the body of /health.
+type Health struct {
+	// Status is "ok" when every breaker is closed and "degraded" otherwise.
+	Status   string          `json:"status"`
+	Breakers []breaker.Stats `json:"breakers"`
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := Health{Status: "ok", Breakers: []breaker.Stats{}}
+	for _, b := range s.breakers {
+		stats := b.Stats()
+		if stats.State != breaker.Closed {
+			health.Status = "degraded"
+		}
+		health.Breakers = append(health.Breakers, stats)
+	}
+	writeJSON(w, http.StatusOK, health)
+}
+
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,172 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/breaker"
+	"learn-go/ollama"
+	"learn-go/ollama/ollamatest"
+	"learn-go/server"
+	"learn-go/weather"
+	"learn-go/weather/weathertest"
+)
+
+type fixture struct {
+	handler   http.Handler
+	ai        *ollamatest.Server
+	nws       *weathertest.Server
+	admission *ollama.Admission
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	ai := ollamatest.NewServer()
+	t.Cleanup(ai.Close)
+	nws := weathertest.NewServer()
+	t.Cleanup(nws.Close)
+
+	svc := nws.NewService(weather.WithBreakerSettings(breaker.Settings{Failures: 3, Cooldown: time.Minute}))
+	admission := ollama.NewAdmission(ollama.AdmissionSettings{MaxInFlight: 1, MaxQueue: 0})
+	client := ollama.NewClient(svc,
+		ollama.WithBaseURL(ai.BaseURL()),
+		ollama.WithRetryDelay(0),
+		ollama.WithAdmission(admission),
+	)
+	srv := server.New(client, append(svc.Breakers(), client.Breakers()...)...)
+	return &fixture{handler: srv.Handler(), ai: ai, nws: nws, admission: admission}
+}
+
+func (f *fixture) get(path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	return rec
+}
+
+func TestWeather(t *testing.T) {
+	f := newFixture(t)
+	f.ai.Chat(ollamatest.Reply("Warm and partly cloudy in Miami at 77°F."))
+
+	rec := f.get("/weather?q=" + "Miami,+FL")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var got ollama.WeatherResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if got.Location != "Miami, FL" || got.Weather == nil || got.Weather.Temperature != 77 {
+		t.Errorf("response = %+v, want Miami at 77°F", got)
+	}
+}
+
+func TestWeatherMissingQuery(t *testing.T) {
+	if rec := newFixture(t).get("/weather"); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", rec.Code)
+	}
+}
+
+func TestWeatherBadLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		geocode string
+		want    int
+	}{
+		{"not found", `[]`, http.StatusNotFound},
+		{"outside US", `[{"lat":"51.5072","lon":"-0.1276"}]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.nws.Handle(weathertest.GeocodePath, weathertest.Body(tt.geocode))
+			if rec := f.get("/weather?q=Miami,+FL"); rec.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestBreakerOpen(t *testing.T) {
+	f := newFixture(t)
+	f.nws.Handle(weathertest.PointsPath, weathertest.Status(http.StatusServiceUnavailable))
+
+	if rec := f.get("/weather?q=Miami,+FL"); rec.Code != http.StatusBadGateway {
+		t.Fatalf("first query status %d, want 502", rec.Code)
+	}
+
+	rec := f.get("/weather?q=Miami,+FL")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d once the breaker opened, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+
+	rec = f.get("/health")
+	var health server.Health
+	if err := json.NewDecoder(rec.Body).Decode(&health); err != nil {
+		t.Fatalf("decoding health: %v", err)
+	}
+	if health.Status != "degraded" {
+		t.Errorf("health status = %q, want degraded", health.Status)
+	}
+	states := map[string]breaker.State{}
+	for _, b := range health.Breakers {
+		states[b.Name] = b.State
+	}
+	if states["nws"] != breaker.Open || states["ollama"] != breaker.Closed {
+		t.Errorf("breaker states = %v, want nws open and ollama closed", states)
+	}
+
+	metrics := f.get("/metrics").Body.String()
+	for _, want := range []string{
+		`weather_breaker_state{upstream="nws"} 1`,
+		`weather_breaker_state{upstream="ollama"} 0`,
+		`weather_breaker_calls_total{upstream="nws",result="failure"} 3`,
+		`weather_breaker_calls_total{upstream="nws",result="rejected"} 1`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics missing %q:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestOllamaOverloaded(t *testing.T) {
+	f := newFixture(t)
+	release, err := f.admission.Acquire(context.Background(), ollama.PriorityBatch)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer release()
+
+	rec := f.get("/weather?q=Miami,+FL")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d with the model busy, want 503: %s", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("no Retry-After header")
+	}
+	if n := f.ai.Hits(ollamatest.ChatPath); n != 0 {
+		t.Errorf("model called %d times, want none", n)
+	}
+
+	metrics := f.get("/metrics").Body.String()
+	for _, want := range []string{"weather_ollama_in_flight 1", `weather_ollama_admissions_total{result="shed"} 1`} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics missing %q:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestHealthOK(t *testing.T) {
+	rec := newFixture(t).get("/health")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"ok"`) {
+		t.Errorf("health = %d %s, want ok", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"learn-go/ollama"
+	"learn-go/tui"
+)
+
+// tui shows the full-screen dashboard for the given locations, or for the
+// places saved in the profile.
+func (a *app) tui(args []string) error {
+	fs, o := a.flags("tui")
+	every := fs.Duration("every", tui.DefaultRefresh, "reload the weather this often")
+	positional, weatherSvc, client, err := a.setup(fs, o, args, ollama.PriorityInteractive)
+	if err != nil {
+		return helpOK(err)
+	}
+	if *every <= 0 {
+		return usagef("tui: -every must be positive")
+	}
+
+	locations := positional
+	if len(locations) == 0 {
+		locations = a.profile.Names()
+	}
+	if len(locations) == 0 {
+		return usagef("tui: name the locations to show (quote names with spaces), or save places with 'weather profile'")
+	}
+
+	d := tui.New(tui.Options{
+		Locations: locations,
+		Weather:   weatherSvc,
+		Ask: func(query string) (*ollama.WeatherResponse, error) {
+			return withTimeout(o.timeout, func() (*ollama.WeatherResponse, error) {
+				return client.GetWeather(query, maxRetries)
+			})
+		},
+		Refresh: *every,
+		Display: o.display,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return d.Run(ctx, a.stdin, a.stdout)
+}
@@ -0,0 +1,434 @@
+// Package tui is a full-screen terminal dashboard for wall monitors: the
+// current conditions, an hourly chart, the week ahead and active alerts
+// for several locations, refreshed on an interval, with a prompt that
+// still answers questions in plain language.
+package tui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/render"
+	"learn-go/weather"
+
+	"golang.org/x/term"
+)
+
+// DefaultRefresh is how often the dashboard reloads when Options.Refresh
+// is zero.
+const DefaultRefresh = 10 * time.Minute
+
+// AskFunc answers a question typed at the prompt.
+type AskFunc func(query string) (*ollama.WeatherResponse, error)
+
+// Options configures a Dashboard.
+type Options struct {
+	// Locations are shown one at a time, in this order.
+	Locations []string
+	// Weather looks up the data shown for each location.
+	Weather weather.Service
+	// Ask answers questions typed at the prompt. Nil disables the prompt.
+	Ask AskFunc
+	// Refresh is how often every location is reloaded.
+	Refresh time.Duration
+	// Display sets the units, color and plain mode. Its width is ignored:
+	// the dashboard fills the terminal.
+	Display render.Options
+	// Size returns the terminal size; the default asks the output
+	// terminal and falls back to 80x24.
+	Size func() (width, height int)
+	// Now is the clock; the default is time.Now.
+	Now func() time.Time
+}
+
+// Dashboard is the state of the screen. It is only touched by the
+// goroutine running Run; lookups report back through Run's update
+// channel.
+type Dashboard struct {
+	opts     Options
+	places   []*place
+	selected int
+
+	// typing is set while keys go to the prompt rather than navigation.
+	typing bool
+	input  []rune
+	// question is the last question asked; answer or answerErr its
+	// outcome, both unset while it is being answered.
+	question  string
+	asking    bool
+	answer    *ollama.WeatherResponse
+	answerErr error
+}
+
+// place is the data shown for one location. Fields that failed to load
+// keep their previous values, and err says why.
+type place struct {
+	name    string
+	current *weather.WeatherData
+	hourly  *weather.Forecast
+	daily   *weather.Forecast
+	alerts  []weather.Alert
+	err     error
+	updated time.Time
+	loading bool
+}
+
+// New returns a dashboard for opts.Locations.
+func New(opts Options) *Dashboard {
+	if opts.Refresh <= 0 {
+		opts.Refresh = DefaultRefresh
+	}
+	if opts.Display.Units == "" {
+		opts.Display.Units = render.Imperial
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	d := &Dashboard{opts: opts}
+	for _, name := range opts.Locations {
+		d.places = append(d.places, &place{name: name})
+	}
+	return d
+}
+
+// Terminal control sequences.
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l" // alternate screen, hidden cursor
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	home        = "\x1b[H"
+	clearLine   = "\x1b[K"
+	clearBelow  = "\x1b[J"
+)
+
+// Run shows the dashboard on out until the user quits, in closes or ctx
+// is done. When in is a terminal it is put in raw mode for the duration.
+func (d *Dashboard) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return fmt.Errorf("entering raw mode: %w", err)
+		}
+		defer term.Restore(int(f.Fd()), state)
+	}
+	if d.opts.Size == nil {
+		d.opts.Size = terminalSize(out)
+	}
+
+	io.WriteString(out, enterScreen)
+	defer io.WriteString(out, leaveScreen)
+
+	// done stops lookups from reporting to a dashboard that has quit. The
+	// key reader may stay blocked on in; the process exits soon after.
+	done := make(chan struct{})
+	defer close(done)
+	keys := make(chan rune)
+	go readKeys(in, keys, done)
+	updates := make(chan func())
+
+	refresh := time.NewTicker(d.opts.Refresh)
+	defer refresh.Stop()
+	// The clock ticks to follow terminal resizes.
+	clock := time.NewTicker(time.Second)
+	defer clock.Stop()
+
+	d.refresh(updates, done)
+	for {
+		d.draw(out)
+		select {
+		case <-ctx.Done():
+			return nil
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch d.key(k) {
+			case actionQuit:
+				return nil
+			case actionRefresh:
+				d.refresh(updates, done)
+			case actionAsk:
+				d.ask(updates, done)
+			}
+		case apply := <-updates:
+			apply()
+		case <-refresh.C:
+			d.refresh(updates, done)
+		case <-clock.C:
+		}
+	}
+}
+
+// terminalSize measures out when it is a terminal.
+func terminalSize(out io.Writer) func() (int, int) {
+	return func() (int, int) {
+		if f, ok := out.(*os.File); ok {
+			if w, h, err := term.GetSize(int(f.Fd())); err == nil && w > 0 && h > 0 {
+				return w, h
+			}
+		}
+		return 80, 24
+	}
+}
+
+// draw repaints the screen in place, which doesn't flicker the way
+// clearing it first does.
+func (d *Dashboard) draw(out io.Writer) {
+	var b strings.Builder
+	b.WriteString(home)
+	for i, line := range d.view(d.opts.Size()) {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line + clearLine)
+	}
+	b.WriteString(clearBelow)
+	io.WriteString(out, b.String())
+}
+
+// send reports a finished lookup to Run, unless it has returned.
+func send(updates chan<- func(), done <-chan struct{}, apply func()) {
+	select {
+	case updates <- apply:
+	case <-done:
+	}
+}
+
+// refresh reloads every location not already loading.
+func (d *Dashboard) refresh(updates chan<- func(), done <-chan struct{}) {
+	for _, p := range d.places {
+		if p.loading {
+			continue
+		}
+		p.loading = true
+		go func(p *place) {
+			s := d.fetch(p.name)
+			send(updates, done, func() { p.apply(s) })
+		}(p)
+	}
+}
+
+// snapshot is the outcome of loading one location.
+type snapshot struct {
+	current *weather.WeatherData
+	hourly  *weather.Forecast
+	daily   *weather.Forecast
+	alerts  []weather.Alert
+	// alertsOK tells no alerts from alerts that failed to load.
+	alertsOK bool
+	err      error
+	at       time.Time
+}
+
+// fetch loads everything shown for location, carrying on past failures
+// so one bad endpoint doesn't blank the whole screen.
+func (d *Dashboard) fetch(location string) snapshot {
+	var s snapshot
+	keep := func(err error) {
+		if err != nil && s.err == nil {
+			s.err = err
+		}
+	}
+	var err error
+	s.current, err = d.opts.Weather.GetWeather(location)
+	keep(err)
+	s.hourly, err = d.opts.Weather.GetForecast(location, true)
+	keep(err)
+	s.daily, err = d.opts.Weather.GetForecast(location, false)
+	keep(err)
+	s.alerts, err = d.opts.Weather.GetAlerts(location)
+	s.alertsOK = err == nil
+	keep(err)
+	s.at = d.opts.Now()
+	return s
+}
+
+func (p *place) apply(s snapshot) {
+	p.loading = false
+	p.err = s.err
+	if s.current != nil {
+		p.current = s.current
+	}
+	if s.hourly != nil {
+		p.hourly = s.hourly
+	}
+	if s.daily != nil {
+		p.daily = s.daily
+	}
+	if s.alertsOK {
+		p.alerts = s.alerts
+	}
+	if s.err == nil || p.updated.IsZero() {
+		p.updated = s.at
+	}
+}
+
+// ask answers the question in the prompt in the background.
+func (d *Dashboard) ask(updates chan<- func(), done <-chan struct{}) {
+	query := d.question
+	go func() {
+		resp, err := d.opts.Ask(query)
+		send(updates, done, func() {
+			if d.question != query {
+				return // superseded by a later question
+			}
+			d.asking = false
+			d.answer, d.answerErr = resp, err
+		})
+	}()
+}
+
+// Keys that arrive as escape sequences, outside the range of runes.
+const (
+	keyUp rune = -(iota + 1)
+	keyDown
+	keyRight
+	keyLeft
+	keyEscape
+)
+
+// Control keys.
+const (
+	keyCtrlC     = 0x03
+	keyBackspace = 0x08
+	keyTab       = 0x09
+	keyLF        = 0x0a
+	keyCR        = 0x0d
+	keyCtrlU     = 0x15
+	keyDelete    = 0x7f
+)
+
+// readKeys decodes keystrokes from in until it fails, then closes keys.
+// An escape with nothing queued behind it is the Esc key itself; arrow
+// keys arrive as one read.
+func readKeys(in io.Reader, keys chan<- rune, done <-chan struct{}) {
+	defer close(keys)
+	r := bufio.NewReader(in)
+	for {
+		k, _, err := r.ReadRune()
+		if err != nil {
+			return
+		}
+		if k == 0x1b {
+			k = escape(r)
+		}
+		select {
+		case keys <- k:
+		case <-done:
+			return
+		}
+	}
+}
+
+// escape reads the rest of an escape sequence.
+func escape(r *bufio.Reader) rune {
+	if r.Buffered() == 0 {
+		return keyEscape
+	}
+	if next, _, err := r.ReadRune(); err != nil || next != '[' && next != 'O' {
+		return keyEscape
+	}
+	code, _, err := r.ReadRune()
+	if err != nil {
+		return keyEscape
+	}
+	switch code {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		return keyRight
+	case 'D':
+		return keyLeft
+	}
+	// Skip the rest of sequences we don't use, such as ESC [ 3 ~.
+	for code >= '0' && code <= '9' || code == ';' {
+		if code, _, err = r.ReadRune(); err != nil {
+			break
+		}
+	}
+	return keyEscape
+}
+
+// action is what Run does after a key.
+type action int
+
+const (
+	actionNone action = iota
+	actionQuit
+	actionRefresh
+	actionAsk
+)
+
+// key applies one keystroke.
+func (d *Dashboard) key(k rune) action {
+	if k == keyCtrlC {
+		return actionQuit
+	}
+	if d.typing {
+		return d.promptKey(k)
+	}
+
+	switch k {
+	case 'q', 'Q':
+		return actionQuit
+	case 'r', 'R':
+		return actionRefresh
+	case keyRight, keyDown, 'l', 'j', 'n', ' ':
+		d.move(1)
+	case keyLeft, keyUp, 'h', 'k', 'p':
+		d.move(-1)
+	case '/', keyTab, '?':
+		if d.opts.Ask != nil {
+			d.typing = true
+		}
+	case keyEscape:
+		d.question, d.answer, d.answerErr, d.asking = "", nil, nil, false
+	default:
+		if k >= '1' && k <= '9' && int(k-'1') < len(d.places) {
+			d.selected = int(k - '1')
+		}
+	}
+	return actionNone
+}
+
+// promptKey edits the question being typed.
+func (d *Dashboard) promptKey(k rune) action {
+	switch k {
+	case keyCR, keyLF:
+		query := strings.TrimSpace(string(d.input))
+		d.input, d.typing = nil, false
+		if query == "" {
+			return actionNone
+		}
+		d.question, d.asking = query, true
+		d.answer, d.answerErr = nil, nil
+		return actionAsk
+	case keyEscape:
+		d.input, d.typing = nil, false
+	case keyBackspace, keyDelete:
+		if len(d.input) > 0 {
+			d.input = d.input[:len(d.input)-1]
+		}
+	case keyCtrlU:
+		d.input = nil
+	default:
+		if k >= ' ' {
+			d.input = append(d.input, k)
+		}
+	}
+	return actionNone
+}
+
+// move selects the location delta places along, wrapping around.
+func (d *Dashboard) move(delta int) {
+	if n := len(d.places); n > 0 {
+		d.selected = ((d.selected+delta)%n + n) % n
+	}
+}
@@ -0,0 +1,220 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/render"
+	"learn-go/weather"
+)
+
+var now = time.Date(2025, 1, 8, 10, 30, 0, 0, time.UTC)
+
+// fakeWeather serves the same made-up weather everywhere except "Nowhere",
+// which fails.
+type fakeWeather struct{}
+
+func (fakeWeather) GetWeather(location string) (*weather.WeatherData, error) {
+	if location == "Nowhere" {
+		return nil, weather.ErrLocationNotFound
+	}
+	return &weather.WeatherData{Temperature: 72, Conditions: "Sunny", Humidity: 40, WindSpeed: 9, WindSpeedUnit: "mph", WindDirection: "SE"}, nil
+}
+
+func (fakeWeather) GetForecast(location string, hourly bool) (*weather.Forecast, error) {
+	if location == "Nowhere" {
+		return nil, weather.ErrLocationNotFound
+	}
+	start := time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC)
+	f := &weather.Forecast{Hourly: hourly}
+	if hourly {
+		for i := 0; i < 48; i++ {
+			f.Periods = append(f.Periods, weather.ForecastPeriod{
+				StartTime:           start.Add(time.Duration(i) * time.Hour),
+				EndTime:             start.Add(time.Duration(i+1) * time.Hour),
+				Temperature:         float64(60 + i%12),
+				PrecipitationChance: i % 3 * 50,
+				WindSpeed:           fmt.Sprintf("%d mph", i%10),
+			})
+		}
+		return f, nil
+	}
+	for i := 0; i < 16; i++ {
+		f.Periods = append(f.Periods, weather.ForecastPeriod{
+			StartTime:     start.Add(time.Duration(i) * 12 * time.Hour),
+			IsDaytime:     i%2 == 0,
+			Temperature:   float64(80 - i%2*15),
+			ShortForecast: "Partly Cloudy",
+		})
+	}
+	return f, nil
+}
+
+func (fakeWeather) GetAlerts(location string) ([]weather.Alert, error) {
+	if location == "Miami, FL" {
+		return []weather.Alert{{ID: "urn:1", Event: "Flood Watch", Severity: "Severe", Expires: "2025-01-09T06:00:00Z"}}, nil
+	}
+	return nil, nil
+}
+
+func newDashboard(ask AskFunc, locations ...string) *Dashboard {
+	d := New(Options{
+		Locations: locations,
+		Weather:   fakeWeather{},
+		Ask:       ask,
+		Display:   render.Options{Plain: true},
+		Now:       func() time.Time { return now },
+	})
+	for _, p := range d.places {
+		p.apply(d.fetch(p.name))
+	}
+	return d
+}
+
+func TestView(t *testing.T) {
+	d := newDashboard(nil, "Miami, FL", "Denver, CO")
+	lines := d.view(80, 40)
+	if len(lines) != 40 {
+		t.Fatalf("view is %d lines, want 40", len(lines))
+	}
+	screen := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"[1 Miami, FL]", " 2 Denver, CO ", "Updated 10:30 AM",
+		"Now  72°F, Sunny, humidity 40%, wind 9.0 mph SE",
+		"Temperature (°F)", "Precipitation (%)", "Wind (mph)",
+		"Next 7 days", "Wed Jan 8  80°F / 65°F", "Tue Jan 14",
+		"Severe: Flood Watch until Thu 6:00 AM",
+		"r refresh  q quit",
+	} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("screen missing %q:\n%s", want, screen)
+		}
+	}
+	if strings.Contains(screen, "Wed Jan 15") {
+		t.Errorf("forecast lists more than 7 days:\n%s", screen)
+	}
+	for _, line := range lines {
+		if w := render.StringWidth(line); w > 79 {
+			t.Errorf("line %q is %d cells wide, want at most 79", line, w)
+		}
+	}
+
+	// A short screen keeps the title and the prompt.
+	lines = d.view(80, 10)
+	if len(lines) != 10 || !strings.Contains(lines[0], "Miami") || !strings.Contains(lines[9], "q quit") {
+		t.Errorf("short view:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestViewError(t *testing.T) {
+	d := newDashboard(nil, "Nowhere")
+	screen := strings.Join(d.view(80, 24), "\n")
+	if !strings.Contains(screen, "Error: location not found") {
+		t.Errorf("screen doesn't show the error:\n%s", screen)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	d := newDashboard(func(string) (*ollama.WeatherResponse, error) { return nil, nil }, "A", "B", "C")
+	for _, tt := range []struct {
+		key  rune
+		want int
+	}{
+		{keyRight, 1}, {'l', 2}, {keyRight, 0}, {keyLeft, 2}, {'1', 0}, {'3', 2}, {'9', 2},
+	} {
+		d.key(tt.key)
+		if d.selected != tt.want {
+			t.Errorf("after %q selected %d, want %d", tt.key, d.selected, tt.want)
+		}
+	}
+
+	if got := d.key('r'); got != actionRefresh {
+		t.Errorf("r = %v, want refresh", got)
+	}
+	d.key('/')
+	for _, k := range "rain?x" {
+		d.key(k)
+	}
+	d.key(keyBackspace)
+	if got := d.key(keyCR); got != actionAsk || d.question != "rain?" || !d.asking || d.typing {
+		t.Errorf("Enter = %v with question %q, asking %v, typing %v", got, d.question, d.asking, d.typing)
+	}
+	// q is text while typing.
+	d.key('/')
+	if got := d.key('q'); got != actionNone {
+		t.Errorf("q while typing = %v, want none", got)
+	}
+	d.key(keyEscape)
+	if d.typing || len(d.input) != 0 {
+		t.Errorf("Esc left typing %v, input %q", d.typing, string(d.input))
+	}
+	if got := d.key('q'); got != actionQuit {
+		t.Errorf("q = %v, want quit", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	asked := make(chan string, 1)
+	d := New(Options{
+		Locations: []string{"Miami, FL", "Denver, CO"},
+		Weather:   fakeWeather{},
+		Ask: func(query string) (*ollama.WeatherResponse, error) {
+			asked <- query
+			return &ollama.WeatherResponse{Description: "No rain expected."}, nil
+		},
+		Display: render.Options{Plain: true},
+		Size:    func() (int, int) { return 80, 30 },
+		Now:     func() time.Time { return now },
+	})
+
+	in, keys := io.Pipe()
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() { done <- d.Run(context.Background(), in, &out) }()
+
+	io.WriteString(keys, "\x1b[C/will it rain?\r")
+	select {
+	case q := <-asked:
+		if q != "will it rain?" {
+			t.Errorf("asked %q", q)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("question was never asked")
+	}
+	keys.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	screen := out.String()
+	if !strings.HasPrefix(screen, enterScreen) || !strings.HasSuffix(screen, leaveScreen) {
+		t.Errorf("Run didn't switch to and from the alternate screen")
+	}
+	if !strings.Contains(screen, "[2 Denver, CO]") || !strings.Contains(screen, "Q: will it rain?") {
+		t.Errorf("screen didn't follow the keys:\n%s", screen)
+	}
+}
+
+func TestRunStopsOnContext(t *testing.T) {
+	d := New(Options{Locations: []string{"Miami, FL"}, Weather: fakeWeather{}, Size: func() (int, int) { return 80, 24 }})
+	ctx, cancel := context.WithCancel(context.Background())
+	in, _ := io.Pipe()
+	done := make(chan error)
+	go func() { done <- d.Run(ctx, in, io.Discard) }()
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil && !errors.Is(err, context.Canceled) {
+			t.Errorf("Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't stop")
+	}
+}
@@ -0,0 +1,315 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"learn-go/render"
+	"learn-go/weather"
+)
+
+// chartHours is how far ahead the hourly chart looks.
+const chartHours = 24
+
+// forecastDays is how many days the forecast table lists.
+const forecastDays = 7
+
+// ANSI SGR sequences used for highlighting.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiReverse = "\x1b[7m"
+	ansiRed     = "\x1b[31m"
+	ansiYellow  = "\x1b[33m"
+)
+
+// view lays out the screen as exactly height lines of at most width
+// cells: the location tabs, the selected location's weather, and at the
+// bottom the prompt and key help. Weather that doesn't fit is cut from
+// the end, alerts last.
+func (d *Dashboard) view(width, height int) []string {
+	width, height = max(width, 20), max(height, 6)
+	// Leave the last column free; some terminals wrap on it.
+	width--
+
+	top := []string{d.tabs(width), d.rule(width)}
+	bottom := d.footer(width)
+	body := d.body(width)
+
+	room := height - len(top) - len(bottom)
+	if room < 0 {
+		bottom = bottom[len(bottom)-min(len(bottom), height-len(top)):]
+		room = 0
+	}
+	if len(body) > room {
+		body = body[:room]
+	}
+	lines := append(top, body...)
+	for len(lines) < height-len(bottom) {
+		lines = append(lines, "")
+	}
+	lines = append(lines, bottom...)
+	for i, line := range lines {
+		lines[i] = render.Truncate(line, width)
+	}
+	return lines
+}
+
+func (d *Dashboard) colored() bool {
+	return d.opts.Display.Color && !d.opts.Display.Plain
+}
+
+func (d *Dashboard) highlight(s, code string) string {
+	if !d.colored() {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (d *Dashboard) rule(width int) string {
+	return strings.Repeat(d.opts.Display.Symbol("─", "-"), width)
+}
+
+// tabs is the title line: the numbered locations with the selected one
+// marked, and when it was last updated on the right.
+func (d *Dashboard) tabs(width int) string {
+	var b strings.Builder
+	b.WriteString(d.opts.Display.Symbol("🌤️  ", "") + d.highlight("Weather", ansiBold))
+	for i, p := range d.places {
+		label := fmt.Sprintf("%d %s", i+1, p.name)
+		switch {
+		case i != d.selected:
+			label = " " + label + " "
+		case d.colored():
+			label = d.highlight(" "+label+" ", ansiReverse)
+		default:
+			label = "[" + label + "]"
+		}
+		b.WriteString("  " + label)
+	}
+
+	status := ""
+	if p := d.current(); p != nil {
+		switch {
+		case p.loading && p.updated.IsZero():
+			status = "Loading..."
+		case p.loading:
+			status = "Refreshing..."
+		case !p.updated.IsZero():
+			status = "Updated " + p.updated.Format("3:04 PM")
+		}
+	}
+	left := b.String()
+	if gap := width - render.StringWidth(left) - render.StringWidth(status); gap >= 2 && status != "" {
+		return left + strings.Repeat(" ", gap) + status
+	}
+	return left
+}
+
+func (d *Dashboard) current() *place {
+	if d.selected < len(d.places) {
+		return d.places[d.selected]
+	}
+	return nil
+}
+
+// body is the weather of the selected location.
+func (d *Dashboard) body(width int) []string {
+	p := d.current()
+	if p == nil {
+		return []string{"", "No locations to show."}
+	}
+	var lines []string
+	add := func(s ...string) { lines = append(lines, s...) }
+
+	if p.err != nil {
+		add("", d.highlight("Error: "+p.err.Error(), ansiRed))
+	}
+	if p.current != nil {
+		add("", d.conditions(p.current))
+	}
+	if p.hourly != nil && len(p.hourly.Periods) > 1 {
+		periods := p.hourly.Between(d.opts.Now(), time.Time{})
+		if len(periods) > chartHours {
+			periods = periods[:chartHours]
+		}
+		if chart, err := render.Chart(render.Temperature, periods, width, d.opts.Display); err == nil && chart != "" {
+			add("")
+			add(strings.Split(chart, "\n")...)
+		}
+		for _, name := range []string{render.Precipitation, render.Wind} {
+			if line, err := render.SparkLine(name, periods, width, d.opts.Display); err == nil && line != "" {
+				add(line)
+			}
+		}
+	}
+	if p.daily != nil {
+		add("", d.highlight(fmt.Sprintf("Next %d days", forecastDays), ansiBold))
+		add(d.days(p.daily)...)
+	}
+	if p.updated.IsZero() {
+		add("", "Loading "+p.name+"...")
+	} else {
+		add("", d.highlight("Alerts", ansiBold))
+		add(d.alerts(p.alerts)...)
+	}
+	return lines
+}
+
+// conditions is a one-line summary of the current weather.
+func (d *Dashboard) conditions(w *weather.WeatherData) string {
+	u := d.opts.Display.Units
+	parts := []string{d.highlight(render.ObservedTemp(w, u.WholeTemp), ansiBold), w.Conditions}
+	if w.FeelsLike > 0 && !w.TemperatureMissing && w.FeelsLike != w.Temperature {
+		parts = append(parts, "feels like "+u.WholeTemp(w.FeelsLike))
+	}
+	parts = append(parts, fmt.Sprintf("humidity %d%%", w.Humidity))
+	if w.WindSpeed > 0 {
+		wind := "wind " + u.Speed(w.WindSpeed, w.WindSpeedUnit)
+		if w.WindDirection != "" {
+			wind += " " + w.WindDirection
+		}
+		parts = append(parts, wind)
+	}
+	return "Now  " + strings.Join(parts, d.opts.Display.Symbol("  ·  ", ", "))
+}
+
+// day is one row of the forecast table, merging the day and night
+// periods of a twelve-hour forecast.
+type day struct {
+	name      string
+	high, low string
+	forecast  string
+	precip    int
+}
+
+// days summarizes a twelve-hour forecast day by day.
+func (d *Dashboard) days(f *weather.Forecast) []string {
+	u := d.opts.Display.Units
+	var rows []day
+	var last string
+	for _, p := range f.Periods {
+		date := p.StartTime.Format("2006-01-02")
+		if date != last {
+			if len(rows) == forecastDays {
+				break
+			}
+			rows = append(rows, day{name: p.StartTime.Format("Mon Jan 2")})
+			last = date
+		}
+		r := &rows[len(rows)-1]
+		if p.IsDaytime {
+			r.high, r.forecast = u.WholeTemp(p.Temperature), p.ShortForecast
+		} else {
+			r.low = u.WholeTemp(p.Temperature)
+			if r.forecast == "" {
+				r.forecast = p.ShortForecast
+			}
+		}
+		r.precip = max(r.precip, p.PrecipitationChance)
+	}
+
+	var lines []string
+	for _, r := range rows {
+		temps := orDash(r.high) + " / " + orDash(r.low)
+		forecast := r.forecast
+		if r.precip > 0 {
+			forecast += fmt.Sprintf(" (%d%%)", r.precip)
+		}
+		lines = append(lines, fmt.Sprintf("  %-10s %-13s %s", r.name, temps, forecast))
+	}
+	return lines
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "--"
+	}
+	return s
+}
+
+// alerts lists active alerts with their severity and expiry.
+func (d *Dashboard) alerts(alerts []weather.Alert) []string {
+	if len(alerts) == 0 {
+		return []string{"  None"}
+	}
+	var lines []string
+	for _, a := range alerts {
+		severity := a.Severity
+		switch severity {
+		case "Extreme", "Severe":
+			severity = d.highlight(severity, ansiRed)
+		case "Moderate":
+			severity = d.highlight(severity, ansiYellow)
+		}
+		line := fmt.Sprintf("  %s%s: %s", d.opts.Display.Symbol("⚠ ", ""), severity, a.Event)
+		if until, err := time.Parse(time.RFC3339, a.Expires); err == nil {
+			line += " until " + until.Format("Mon 3:04 PM")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// footer is the question and answer, the prompt and the key help.
+func (d *Dashboard) footer(width int) []string {
+	lines := []string{d.rule(width)}
+	if d.question != "" {
+		lines = append(lines, "Q: "+d.question)
+		var answer string
+		switch {
+		case d.asking:
+			answer = "Thinking..."
+		case d.answerErr != nil:
+			answer = d.highlight("Error: "+d.answerErr.Error(), ansiRed)
+		case d.answer != nil:
+			answer = d.answer.Description
+			if d.answer.Answer != nil {
+				answer = d.answer.Answer.Short + " " + answer
+			}
+		}
+		// Long answers wrap onto at most three lines.
+		wrapped := wrap("A: "+answer, width)
+		if len(wrapped) > 3 {
+			wrapped = wrapped[:3]
+		}
+		lines = append(lines, wrapped...)
+	}
+
+	switch {
+	case d.typing:
+		// Keep the end of a long question in view.
+		input := d.input
+		for len(input) > 0 && render.StringWidth(string(input)) > width-6 {
+			input = input[1:]
+		}
+		lines = append(lines, "Ask: "+string(input)+d.opts.Display.Symbol("█", "_"))
+	case d.opts.Ask != nil:
+		lines = append(lines, "←/→ location  1-9 jump  r refresh  / ask  Esc clear  q quit")
+	default:
+		lines = append(lines, "←/→ location  1-9 jump  r refresh  q quit")
+	}
+	if d.opts.Display.Plain {
+		lines[len(lines)-1] = strings.ReplaceAll(lines[len(lines)-1], "←/→", "h/l")
+	}
+	return lines
+}
+
+// wrap splits text into lines of at most width cells at spaces.
+func wrap(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case render.StringWidth(line)+1+render.StringWidth(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	return append(lines, line)
+}
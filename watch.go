@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"learn-go/ollama"
+	"learn-go/render"
+	"learn-go/watch"
+)
+
+// hookTimeout bounds each -exec command and -webhook post.
+const hookTimeout = 30 * time.Second
+
+// watch polls one location and prints what changes until interrupted.
+func (a *app) watch(args []string) error {
+	fs, o := a.flags("watch")
+	every := fs.Duration("every", watch.DefaultEvery, "check this often")
+	threshold := fs.Float64("threshold", watch.DefaultThreshold, "report temperature changes of at least this many degrees, in -units")
+	execCmd := fs.String("exec", "", "run this shell command on each change, with the change as JSON on stdin")
+	webhook := fs.String("webhook", "", "POST each change as JSON to this URL")
+	positional, weatherSvc, _, err := a.setup(fs, o, args, ollama.PriorityInteractive)
+	if err != nil {
+		return helpOK(err)
+	}
+	location := strings.TrimSpace(strings.Join(positional, " "))
+	if location == "" {
+		return usagef("watch: missing location")
+	}
+	if *every <= 0 || *threshold <= 0 {
+		return usagef("watch: -every and -threshold must be positive")
+	}
+
+	degreesF := *threshold
+	if o.display.Units == render.Metric {
+		degreesF *= 1.8
+	}
+	w := watch.New(watch.Options{
+		Location:  location,
+		Weather:   weatherSvc,
+		Every:     *every,
+		Threshold: degreesF,
+		Units:     o.display.Units,
+	})
+
+	var enc *json.Encoder
+	switch strings.ToLower(o.output) {
+	case render.JSON, render.NDJSON, "jsonl":
+		enc = json.NewEncoder(a.stdout)
+	}
+	client := &http.Client{Timeout: hookTimeout}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Fprintf(a.stderr, "Watching %s every %v (Ctrl-C to stop)\n", location, *every)
+	return w.Run(ctx, func(ev *watch.Event, err error) {
+		if err != nil {
+			fmt.Fprintf(a.stderr, "Warning: %v\n", err)
+			return
+		}
+		if enc != nil {
+			enc.Encode(ev)
+		} else {
+			a.printWatch(ev, o.display)
+		}
+		if len(ev.Changes) == 0 {
+			return
+		}
+
+		hookCtx, cancel := context.WithTimeout(ctx, hookTimeout)
+		defer cancel()
+		if *execCmd != "" {
+			if err := watch.Exec(hookCtx, *execCmd, ev, a.stderr, a.stderr); err != nil {
+				fmt.Fprintf(a.stderr, "Warning: %v\n", err)
+			}
+		}
+		if *webhook != "" {
+			if err := watch.Post(hookCtx, client, *webhook, ev); err != nil {
+				fmt.Fprintf(a.stderr, "Warning: %v\n", err)
+			}
+		}
+	})
+}
+
+// printWatch prints the starting point of a watch, then one line per
+// change, each stamped with the time it was seen.
+func (a *app) printWatch(ev *watch.Event, opts render.Options) {
+	stamp := ev.Time.Format("3:04 PM")
+	if len(ev.Changes) > 0 {
+		for _, c := range ev.Changes {
+			fmt.Fprintf(a.stdout, "%s  %s\n", stamp, c.Summary)
+		}
+		return
+	}
+
+	var parts []string
+	if ev.Weather != nil {
+		if !ev.Weather.TemperatureMissing {
+			parts = append(parts, opts.Units.WholeTemp(ev.Weather.Temperature))
+		}
+		parts = append(parts, ev.Weather.Conditions)
+	}
+	var events []string
+	for _, alert := range ev.Alerts {
+		events = append(events, alert.Event)
+	}
+	switch len(events) {
+	case 0:
+		parts = append(parts, "no alerts")
+	case 1:
+		parts = append(parts, "1 alert ("+events[0]+")")
+	default:
+		parts = append(parts, fmt.Sprintf("%d alerts (%s)", len(events), strings.Join(events, ", ")))
+	}
+	fmt.Fprintf(a.stdout, "%s  %s: %s\n", stamp, ev.Location, strings.Join(parts, ", "))
+}
@@ -0,0 +1,255 @@
+// Package watch polls the weather at one place and reports only what
+// changed meaningfully since it last looked: the temperature moving past a
+// threshold, new conditions, and alerts issued or ended.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"learn-go/render"
+	"learn-go/weather"
+)
+
+// Defaults used when Options leave them zero.
+const (
+	DefaultEvery     = 10 * time.Minute
+	DefaultThreshold = 3 // °F
+)
+
+// Kinds of Change.
+const (
+	Temperature = "temperature"
+	Conditions  = "conditions"
+	AlertIssued = "alert"
+	AlertEnded  = "alert_ended"
+)
+
+// Change is one meaningful difference between two looks at the weather.
+type Change struct {
+	Kind string `json:"kind"`
+	// Summary says what changed in a sentence, in the watcher's units.
+	Summary string `json:"summary"`
+	// From and To are the old and new values of a temperature (°F) or
+	// conditions change.
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+	// Alert is the alert issued or ended.
+	Alert *weather.Alert `json:"alert,omitempty"`
+}
+
+// Event is the outcome of one look: the changes found, with the weather
+// and alerts they were found in. The first event has no changes and
+// describes the starting point.
+type Event struct {
+	Location string               `json:"location"`
+	Time     time.Time            `json:"time"`
+	Changes  []Change             `json:"changes"`
+	Weather  *weather.WeatherData `json:"weather,omitempty"`
+	Alerts   []weather.Alert      `json:"alerts"`
+}
+
+// Summary joins the summaries of the changes.
+func (e *Event) Summary() string {
+	var parts []string
+	for _, c := range e.Changes {
+		parts = append(parts, c.Summary)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Options configures a Watcher.
+type Options struct {
+	Location string
+	Weather  weather.Service
+	// Every is how often Run looks.
+	Every time.Duration
+	// Threshold is how many °F the temperature must move from the last
+	// reported value to count as a change.
+	Threshold float64
+	// Units sets how summaries print values.
+	Units render.Units
+	// Now is the clock; the default is time.Now.
+	Now func() time.Time
+}
+
+// Watcher remembers the last weather seen at one place.
+type Watcher struct {
+	opts Options
+
+	started bool
+	// reported is the temperature last reported, so a slow drift still
+	// shows once it adds up to the threshold.
+	reported   *float64
+	conditions string
+	alerts     map[string]weather.Alert
+	haveAlerts bool
+}
+
+// New returns a watcher for opts.Location.
+func New(opts Options) *Watcher {
+	if opts.Every <= 0 {
+		opts.Every = DefaultEvery
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.Units == "" {
+		opts.Units = render.Imperial
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Watcher{opts: opts}
+}
+
+// Check looks at the weather once and returns what changed. It fails only
+// when neither the conditions nor the alerts could be fetched; whichever
+// half failed is left out of the comparison rather than reported as
+// changed.
+func (w *Watcher) Check() (*Event, error) {
+	current, weatherErr := w.opts.Weather.GetWeather(w.opts.Location)
+	alerts, alertsErr := w.opts.Weather.GetAlerts(w.opts.Location)
+	if weatherErr != nil && alertsErr != nil {
+		return nil, weatherErr
+	}
+
+	ev := &Event{Location: w.opts.Location, Time: w.opts.Now(), Weather: current, Alerts: alerts}
+	if ev.Alerts == nil {
+		ev.Alerts = []weather.Alert{}
+	}
+	if weatherErr == nil {
+		ev.Changes = append(ev.Changes, w.weatherChanges(current)...)
+	}
+	if alertsErr == nil {
+		ev.Changes = append(ev.Changes, w.alertChanges(alerts)...)
+	}
+	if !w.started {
+		// The first look is the baseline; nothing has changed yet.
+		w.started = true
+		ev.Changes = nil
+	}
+	return ev, nil
+}
+
+func (w *Watcher) weatherChanges(current *weather.WeatherData) []Change {
+	u := w.opts.Units
+	var changes []Change
+	temp := current.Temperature
+	switch {
+	case current.TemperatureMissing:
+		// Unknown, not a change; compare the next reading with the last
+		// one reported.
+	case w.reported == nil:
+		w.reported = &temp
+	case math.Abs(temp-*w.reported) >= w.opts.Threshold:
+		direction := "up"
+		if temp < *w.reported {
+			direction = "down"
+		}
+		changes = append(changes, Change{
+			Kind:    Temperature,
+			Summary: fmt.Sprintf("Temperature %s %s to %s", direction, degrees(u, math.Abs(temp-*w.reported)), u.WholeTemp(temp)),
+			From:    *w.reported,
+			To:      temp,
+		})
+		w.reported = &temp
+	}
+
+	if conditions := strings.TrimSpace(current.Conditions); conditions != "" {
+		if w.conditions != "" && !strings.EqualFold(conditions, w.conditions) {
+			changes = append(changes, Change{
+				Kind:    Conditions,
+				Summary: fmt.Sprintf("Now %s (was %s)", conditions, w.conditions),
+				From:    w.conditions,
+				To:      conditions,
+			})
+		}
+		w.conditions = conditions
+	}
+	return changes
+}
+
+// degrees formats a temperature difference given in °F.
+func degrees(u render.Units, delta float64) string {
+	if u == render.Metric {
+		return fmt.Sprintf("%.0f°C", delta/1.8)
+	}
+	return fmt.Sprintf("%.0f°F", delta)
+}
+
+func (w *Watcher) alertChanges(alerts []weather.Alert) []Change {
+	current := make(map[string]weather.Alert, len(alerts))
+	for _, a := range alerts {
+		current[a.ID] = a
+	}
+	defer func() { w.alerts, w.haveAlerts = current, true }()
+	if !w.haveAlerts {
+		return nil
+	}
+
+	var changes []Change
+	for _, a := range alerts {
+		if _, ok := w.alerts[a.ID]; ok {
+			continue
+		}
+		a := a
+		summary := fmt.Sprintf("New alert: %s %s", a.Severity, a.Event)
+		if until, err := time.Parse(time.RFC3339, a.Expires); err == nil {
+			summary += " until " + until.Format("Mon 3:04 PM")
+		}
+		changes = append(changes, Change{Kind: AlertIssued, Summary: summary, Alert: &a})
+	}
+	// Ended alerts are listed in the order they were issued.
+	for _, id := range sortedIDs(w.alerts) {
+		if _, ok := current[id]; ok {
+			continue
+		}
+		a := w.alerts[id]
+		changes = append(changes, Change{Kind: AlertEnded, Summary: "Alert ended: " + a.Event, Alert: &a})
+	}
+	return changes
+}
+
+// sortedIDs orders alerts by when they took effect, then by ID.
+func sortedIDs(alerts map[string]weather.Alert) []string {
+	ids := make([]string, 0, len(alerts))
+	for id := range alerts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ei, ej := alerts[ids[i]].Effective, alerts[ids[j]].Effective; ei != ej {
+			return ei < ej
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// Run checks now and then every opts.Every until ctx is done, passing
+// handle the first event, every event with changes, and every failed
+// check.
+func (w *Watcher) Run(ctx context.Context, handle func(*Event, error)) error {
+	ticker := time.NewTicker(w.opts.Every)
+	defer ticker.Stop()
+	for {
+		first := !w.started
+		ev, err := w.Check()
+		switch {
+		case err != nil:
+			handle(nil, err)
+		case first || len(ev.Changes) > 0:
+			handle(ev, nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
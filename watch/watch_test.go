@@ -0,0 +1,178 @@
+package watch_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/render"
+	"learn-go/watch"
+	"learn-go/weather"
+)
+
+// fakeWeather returns the conditions and alerts it is given, or errors.
+type fakeWeather struct {
+	current    *weather.WeatherData
+	alerts     []weather.Alert
+	weatherErr error
+	alertsErr  error
+}
+
+func (f *fakeWeather) GetWeather(string) (*weather.WeatherData, error) {
+	if f.weatherErr != nil {
+		return nil, f.weatherErr
+	}
+	c := *f.current
+	return &c, nil
+}
+
+func (f *fakeWeather) GetForecast(string, bool) (*weather.Forecast, error) {
+	return nil, errors.New("not used")
+}
+
+func (f *fakeWeather) GetAlerts(string) ([]weather.Alert, error) {
+	return f.alerts, f.alertsErr
+}
+
+var flood = weather.Alert{ID: "urn:1", Event: "Flood Watch", Severity: "Severe", Effective: "2025-01-08T06:00:00Z", Expires: "2025-01-09T06:00:00Z"}
+
+func summaries(ev *watch.Event) []string {
+	var out []string
+	for _, c := range ev.Changes {
+		out = append(out, c.Summary)
+	}
+	return out
+}
+
+func TestCheck(t *testing.T) {
+	svc := &fakeWeather{current: &weather.WeatherData{Temperature: 70, Conditions: "Sunny"}}
+	w := watch.New(watch.Options{Location: "Site 4", Weather: svc})
+
+	steps := []struct {
+		name   string
+		change func()
+		want   []string
+	}{
+		{"baseline", func() {}, nil},
+		{"small drift", func() { svc.current.Temperature = 72 }, nil},
+		{"drift adds up", func() { svc.current.Temperature = 73 }, []string{"Temperature up 3°F to 73°F"}},
+		{"same conditions, other case", func() { svc.current.Conditions = "sunny" }, nil},
+		{"new conditions and alert", func() {
+			svc.current.Conditions = "Thunderstorms"
+			svc.alerts = []weather.Alert{flood}
+		}, []string{"Now Thunderstorms (was sunny)", "New alert: Severe Flood Watch until Thu 6:00 AM"}},
+		{"alerts unavailable", func() { svc.alertsErr = errors.New("down") }, nil},
+		{"temperature missing", func() {
+			svc.current.Temperature, svc.current.TemperatureMissing = 0, true
+		}, nil},
+		{"alert ends, cooler", func() {
+			svc.alerts, svc.alertsErr = nil, nil
+			svc.current.Temperature, svc.current.TemperatureMissing = 60, false
+		}, []string{"Temperature down 13°F to 60°F", "Alert ended: Flood Watch"}},
+	}
+	for _, step := range steps {
+		step.change()
+		ev, err := w.Check()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := summaries(ev); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: changes %q, want %q", step.name, got, step.want)
+		}
+	}
+
+	svc.weatherErr, svc.alertsErr = weather.ErrLocationNotFound, errors.New("down")
+	if _, err := w.Check(); !errors.Is(err, weather.ErrLocationNotFound) {
+		t.Errorf("Check with both lookups failing = %v", err)
+	}
+}
+
+func TestCheckMetric(t *testing.T) {
+	svc := &fakeWeather{current: &weather.WeatherData{Temperature: 50, Conditions: "Cloudy"}}
+	w := watch.New(watch.Options{Location: "Site 4", Weather: svc, Threshold: 1.8, Units: render.Metric})
+	w.Check()
+	svc.current.Temperature = 59
+	ev, _ := w.Check()
+	if got := summaries(ev); len(got) != 1 || got[0] != "Temperature up 5°C to 15°C" {
+		t.Errorf("changes %q", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	svc := &fakeWeather{current: &weather.WeatherData{Temperature: 70, Conditions: "Sunny"}}
+	w := watch.New(watch.Options{Location: "Site 4", Weather: svc, Every: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []*watch.Event
+	w.Run(ctx, func(ev *watch.Event, err error) {
+		if err != nil {
+			t.Errorf("Run: %v", err)
+		}
+		events = append(events, ev)
+		if len(events) == 1 {
+			svc.current.Temperature = 80
+		}
+		if len(events) == 2 {
+			cancel()
+		}
+	})
+	if len(events) != 2 || len(events[0].Changes) != 0 || len(events[1].Changes) != 1 {
+		t.Errorf("Run reported %d events: %+v", len(events), events)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	ev := &watch.Event{
+		Location: "Site 4",
+		Changes:  []watch.Change{{Kind: watch.Conditions, Summary: "Now Rain (was Sunny)"}},
+		Alerts:   []weather.Alert{},
+	}
+
+	var got watch.Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type %q", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+	if err := watch.Post(context.Background(), srv.Client(), srv.URL, ev); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if got.Location != "Site 4" || len(got.Changes) != 1 {
+		t.Errorf("webhook got %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if err := watch.Post(context.Background(), failing.Client(), failing.URL, ev); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Post to a failing webhook = %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	cmd := `printf '%s|' "$WEATHER_LOCATION" "$WEATHER_CHANGES" > ` + out + ` && cat >> ` + out
+	if err := watch.Exec(context.Background(), cmd, ev, io.Discard, io.Discard); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "Site 4|Now Rain (was Sunny)|{") {
+		t.Errorf("command saw %q", data)
+	}
+	if err := watch.Exec(context.Background(), "exit 3", ev, io.Discard, io.Discard); err == nil {
+		t.Error("failing command succeeded")
+	}
+}
@@ -1,8 +1,9 @@
 package weather
 
 const (
-	nwsBaseURL = "https://api.weather.gov"
-	userAgent  = "WeatherApp/1.0"
+	nwsBaseURL   = "https://api.weather.gov"
+	nominatimURL = "https://nominatim.openstreetmap.org/search"
+	userAgent    = "WeatherApp/1.0"
 )
 
 // USBounds defines the geographical boundaries for US territories
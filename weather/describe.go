@@ -0,0 +1,121 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Describe summarizes current conditions in a sentence or two without an
+// LLM. It only states values present in d.
+func Describe(location string, d *WeatherData) string {
+	var b strings.Builder
+
+	conditions := strings.TrimSpace(d.Conditions)
+	if conditions == "" {
+		conditions = "Current conditions"
+	}
+	fmt.Fprintf(&b, "%s in %s", conditions, location)
+	if !d.TemperatureMissing {
+		fmt.Fprintf(&b, " at %.0f°F", math.Round(d.Temperature))
+	}
+	if d.FeelsLike > 0 && !d.TemperatureMissing && math.Abs(d.FeelsLike-d.Temperature) >= 3 {
+		fmt.Fprintf(&b, ", feeling like %.0f°F", math.Round(d.FeelsLike))
+	}
+	if d.Humidity > 0 {
+		fmt.Fprintf(&b, ", with %d%% humidity", d.Humidity)
+	}
+	b.WriteString(".")
+
+	if mph := d.WindSpeedMph(); mph >= 1 {
+		b.WriteString(" Wind")
+		if d.WindDirection != "" {
+			fmt.Fprintf(&b, " from the %s", d.WindDirection)
+		}
+		fmt.Fprintf(&b, " at %.0f mph", math.Round(mph))
+		if gust := d.WindGustMph(); gust > mph {
+			fmt.Fprintf(&b, ", gusting to %.0f mph", math.Round(gust))
+		}
+		b.WriteString(".")
+	} else {
+		b.WriteString(" Winds are calm.")
+	}
+
+	if d.PrecipitationChance > 0 {
+		fmt.Fprintf(&b, " %d%% chance of precipitation.", d.PrecipitationChance)
+	}
+
+	return b.String()
+}
+
+// DescribeForecast summarizes the first few periods of a forecast.
+func DescribeForecast(location string, f *Forecast) string {
+	if len(f.Periods) == 0 {
+		return fmt.Sprintf("No forecast is available for %s.", location)
+	}
+
+	if f.Hourly {
+		first, last := f.Periods[0], f.Periods[len(f.Periods)-1]
+		lo, hi := first.Temperature, first.Temperature
+		rainiest := first
+		for _, p := range f.Periods {
+			lo = math.Min(lo, p.Temperature)
+			hi = math.Max(hi, p.Temperature)
+			if p.PrecipitationChance > rainiest.PrecipitationChance {
+				rainiest = p
+			}
+		}
+		s := fmt.Sprintf("%s in %s from %s to %s, with temperatures between %.0f°F and %.0f°F.",
+			first.ShortForecast, location,
+			first.StartTime.Format("Mon 3PM"), last.EndTime.Format("Mon 3PM"), lo, hi)
+		if rainiest.PrecipitationChance > 0 {
+			s += fmt.Sprintf(" Highest chance of precipitation is %d%% around %s.",
+				rainiest.PrecipitationChance, rainiest.StartTime.Format("3PM"))
+		}
+		return s
+	}
+
+	const maxPeriods = 4
+	var parts []string
+	for i, p := range f.Periods {
+		if i == maxPeriods {
+			break
+		}
+		part := fmt.Sprintf("%s: %s, ", p.Name, p.ShortForecast)
+		if p.IsDaytime {
+			part += fmt.Sprintf("high near %.0f°F", p.Temperature)
+		} else {
+			part += fmt.Sprintf("low around %.0f°F", p.Temperature)
+		}
+		if p.PrecipitationChance > 0 {
+			part += fmt.Sprintf(", %d%% chance of precipitation", p.PrecipitationChance)
+		}
+		parts = append(parts, part+".")
+	}
+	return fmt.Sprintf("Forecast for %s. %s", location, strings.Join(parts, " "))
+}
+
+// DescribeAlerts lists active alerts by event and expiry.
+func DescribeAlerts(location string, alerts []Alert) string {
+	if len(alerts) == 0 {
+		return fmt.Sprintf("There are no active weather alerts for %s.", location)
+	}
+
+	parts := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		part := a.Event
+		if a.Severity != "" && a.Severity != "Unknown" {
+			part += " (" + strings.ToLower(a.Severity) + ")"
+		}
+		if a.Expires != "" {
+			part += " until " + a.Expires
+		}
+		parts = append(parts, part)
+	}
+
+	noun := "alert"
+	if len(alerts) > 1 {
+		noun = "alerts"
+	}
+	return fmt.Sprintf("%d active %s for %s: %s.", len(alerts), noun, location, strings.Join(parts, "; "))
+}
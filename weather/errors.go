@@ -1,11 +1,35 @@
 package weather
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
-func newLocationError(location string) error {
-	return fmt.Errorf("location '%s' is outside US coverage area.\nThis API only supports US locations like:\n"+
+var (
+	// ErrLocationNotFound is returned when geocoding finds no match.
+	ErrLocationNotFound = errors.New("location not found")
+	// ErrOutsideCoverage matches, via errors.Is, a *LocationError.
+	ErrOutsideCoverage = errors.New("location outside US coverage")
+)
+
+// LocationError reports a place that geocodes outside the area served by
+// the National Weather Service.
+type LocationError struct {
+	Location string
+}
+
+func (e *LocationError) Error() string {
+	return fmt.Sprintf("location '%s' is outside US coverage area.\nThis API only supports US locations like:\n"+
 		"- San Francisco, CA\n"+
 		"- New York, NY\n"+
 		"- Miami, FL\n"+
-		"- Chicago, IL", location)
+		"- Chicago, IL", e.Location)
+}
+
+func (e *LocationError) Is(target error) bool {
+	return target == ErrOutsideCoverage
+}
+
+func newLocationError(location string) error {
+	return &LocationError{Location: location}
 }
@@ -0,0 +1,196 @@
+package weather
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ForecastPeriod is one entry of an NWS forecast: an hour for hourly
+// forecasts, a day or night for the default twelve-hour forecast.
+type ForecastPeriod struct {
+	Name                string    `json:"name,omitempty"`
+	StartTime           time.Time `json:"start_time"`
+	EndTime             time.Time `json:"end_time"`
+	IsDaytime           bool      `json:"is_daytime"`
+	Temperature         float64   `json:"temperature"`
+	WindSpeed           string    `json:"wind_speed"`
+	WindDirection       string    `json:"wind_direction"`
+	PrecipitationChance int       `json:"precipitation_chance"`
+	Humidity            int       `json:"humidity,omitempty"`
+	ShortForecast       string    `json:"short_forecast"`
+	DetailedForecast    string    `json:"detailed_forecast,omitempty"`
+}
+
+// Forecast is a sequence of forecast periods for one location.
+type Forecast struct {
+	Hourly    bool             `json:"hourly"`
+	UpdatedAt string           `json:"updated_at"`
+	Periods   []ForecastPeriod `json:"periods"`
+}
+
+// Between returns the periods that overlap [start, end]. A zero end means
+// open-ended.
+func (f *Forecast) Between(start, end time.Time) []ForecastPeriod {
+	var out []ForecastPeriod
+	for _, p := range f.Periods {
+		if !p.EndTime.After(start) {
+			continue
+		}
+		if !end.IsZero() && p.StartTime.After(end) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// Alert is an active NWS watch, warning or advisory.
+type Alert struct {
+	ID          string `json:"id"`
+	Event       string `json:"event"`
+	Headline    string `json:"headline"`
+	Severity    string `json:"severity"`
+	Urgency     string `json:"urgency"`
+	Certainty   string `json:"certainty"`
+	Area        string `json:"area"`
+	Effective   string `json:"effective"`
+	Expires     string `json:"expires"`
+	Description string `json:"description"`
+	Instruction string `json:"instruction,omitempty"`
+}
+
+// GetForecast returns the daily or hourly forecast for location.
+func (s *NWSService) GetForecast(location string, hourly bool) (*Forecast, error) {
+	key := locationKey(location)
+	if hourly {
+		key += "|hourly"
+	}
+	forecast, err, _ := s.forecasts.Do(key, func() (*Forecast, error) {
+		return s.getForecast(location, hourly)
+	})
+	return forecast, err
+}
+
+func (s *NWSService) getForecast(location string, hourly bool) (*Forecast, error) {
+	coords, err := s.validateLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.getPoints(coords)
+	if err != nil {
+		return nil, fmt.Errorf("getting points data: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/gridpoints/%s/%d,%d/forecast",
+		s.baseURL,
+		points.Properties.GridID,
+		points.Properties.GridX,
+		points.Properties.GridY)
+	if hourly {
+		url += "/hourly"
+	}
+
+	var resp ForecastResponse
+	if err := s.makeRequest(url, &resp); err != nil {
+		return nil, fmt.Errorf("getting forecast: %w", err)
+	}
+
+	return convertForecast(&resp, hourly), nil
+}
+
+// GetAlerts returns the alerts in effect at location.
+func (s *NWSService) GetAlerts(location string) ([]Alert, error) {
+	alerts, err, _ := s.alerts.Do(locationKey(location), func() ([]Alert, error) {
+		return s.getAlerts(location)
+	})
+	return alerts, err
+}
+
+func (s *NWSService) getAlerts(location string) ([]Alert, error) {
+	coords, err := s.validateLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.fetchAlerts(fmt.Sprintf("%s/alerts/active?point=%s,%s", s.baseURL, coords.Lat, coords.Lon))
+}
+
+// GetZoneAlerts returns the alerts in effect in an NWS forecast or county
+// zone such as "FLZ072" or "TXC201".
+func (s *NWSService) GetZoneAlerts(zone string) ([]Alert, error) {
+	zone = strings.ToUpper(strings.TrimSpace(zone))
+	if !IsZone(zone) {
+		return nil, fmt.Errorf("%q is not an NWS zone (want e.g. FLZ072)", zone)
+	}
+	alerts, err, _ := s.alerts.Do("zone:"+zone, func() ([]Alert, error) {
+		return s.fetchAlerts(fmt.Sprintf("%s/alerts/active?zone=%s", s.baseURL, zone))
+	})
+	return alerts, err
+}
+
+// IsZone reports whether s is an NWS zone ID: a state, Z for a forecast
+// zone or C for a county, and three digits.
+func IsZone(s string) bool {
+	return zonePattern.MatchString(s)
+}
+
+var zonePattern = regexp.MustCompile(`^[A-Z]{2}[CZ][0-9]{3}$`)
+
+func (s *NWSService) fetchAlerts(url string) ([]Alert, error) {
+	var resp AlertsResponse
+	if err := s.makeRequest(url, &resp); err != nil {
+		return nil, fmt.Errorf("getting alerts: %w", err)
+	}
+
+	alerts := make([]Alert, 0, len(resp.Features))
+	for _, f := range resp.Features {
+		p := f.Properties
+		alerts = append(alerts, Alert{
+			ID:          p.ID,
+			Event:       p.Event,
+			Headline:    p.Headline,
+			Severity:    p.Severity,
+			Urgency:     p.Urgency,
+			Certainty:   p.Certainty,
+			Area:        p.AreaDesc,
+			Effective:   p.Effective,
+			Expires:     p.Expires,
+			Description: p.Description,
+			Instruction: p.Instruction,
+		})
+	}
+	return alerts, nil
+}
+
+func convertForecast(resp *ForecastResponse, hourly bool) *Forecast {
+	forecast := &Forecast{
+		Hourly:    hourly,
+		UpdatedAt: resp.Properties.UpdateTime,
+		Periods:   make([]ForecastPeriod, 0, len(resp.Properties.Periods)),
+	}
+
+	for _, p := range resp.Properties.Periods {
+		temp := p.Temperature
+		if p.TemperatureUnit == "C" {
+			temp = temp*9/5 + 32
+		}
+		forecast.Periods = append(forecast.Periods, ForecastPeriod{
+			Name:                p.Name,
+			StartTime:           p.StartTime,
+			EndTime:             p.EndTime,
+			IsDaytime:           p.IsDaytime,
+			Temperature:         temp,
+			WindSpeed:           p.WindSpeed,
+			WindDirection:       p.WindDirection,
+			PrecipitationChance: int(p.ProbabilityOfPrecipitation.Value),
+			Humidity:            int(p.RelativeHumidity.Value),
+			ShortForecast:       p.ShortForecast,
+			DetailedForecast:    p.DetailedForecast,
+		})
+	}
+
+	return forecast
+}
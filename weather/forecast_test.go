@@ -0,0 +1,114 @@
+package weather_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"learn-go/weather/weathertest"
+)
+
+func TestGetForecast(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+
+	got, err := srv.NewService().GetForecast("Miami, FL", false)
+	if err != nil {
+		t.Fatalf("GetForecast: %v", err)
+	}
+	if got.Hourly || len(got.Periods) != 4 {
+		t.Fatalf("got hourly=%v with %d periods, want 4 daily periods", got.Hourly, len(got.Periods))
+	}
+	thu := got.Periods[2]
+	if thu.Name != "Thursday" || thu.Temperature != 81 || thu.PrecipitationChance != 60 || thu.ShortForecast != "Showers Likely" {
+		t.Errorf("Thursday = %+v", thu)
+	}
+	if n := srv.Hits(weathertest.HourlyPath); n != 0 {
+		t.Errorf("hourly endpoint hit %d times for a daily forecast", n)
+	}
+}
+
+func TestGetForecastHourly(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+
+	got, err := srv.NewService().GetForecast("Miami, FL", true)
+	if err != nil {
+		t.Fatalf("GetForecast: %v", err)
+	}
+	if !got.Hourly || len(got.Periods) != 3 {
+		t.Fatalf("got hourly=%v with %d periods, want 3 hourly periods", got.Hourly, len(got.Periods))
+	}
+	if got.Periods[1].Humidity != 68 {
+		t.Errorf("Humidity = %d, want 68", got.Periods[1].Humidity)
+	}
+
+	start := time.Date(2025, 1, 8, 16, 30, 0, 0, time.UTC)
+	between := got.Between(start, start.Add(time.Hour))
+	if len(between) != 2 || between[0].Temperature != 78 {
+		t.Errorf("Between = %+v, want the 11:00 and 12:00 EST hours", between)
+	}
+}
+
+func TestGetForecastError(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+	srv.Handle(weathertest.ForecastPath, weathertest.Status(http.StatusServiceUnavailable))
+
+	_, err := srv.NewService().GetForecast("Miami, FL", false)
+	if err == nil || !strings.Contains(err.Error(), "getting forecast") {
+		t.Fatalf("error = %v, want forecast failure", err)
+	}
+}
+
+func TestGetAlerts(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+
+	svc := srv.NewService()
+	alerts, err := svc.GetAlerts("Miami, FL")
+	if err != nil {
+		t.Fatalf("GetAlerts: %v", err)
+	}
+	if len(alerts) != 0 {
+		t.Errorf("got %d alerts, want none by default", len(alerts))
+	}
+
+	srv.Handle(weathertest.AlertsPath, weathertest.Body(weathertest.AlertsJSON))
+	alerts, err = svc.GetAlerts("Miami, FL")
+	if err != nil {
+		t.Fatalf("GetAlerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Event != "Rip Current Statement" || alerts[0].Severity != "Moderate" {
+		t.Errorf("alerts = %+v", alerts)
+	}
+
+	reqs := srv.Requests()
+	if q := reqs[len(reqs)-1].Query; q != "point=25.7741728,-80.19362" {
+		t.Errorf("alerts query = %q, want point parameter", q)
+	}
+}
+
+func TestGetZoneAlerts(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+	srv.Handle(weathertest.AlertsPath, weathertest.Body(weathertest.AlertsJSON))
+
+	svc := srv.NewService()
+	alerts, err := svc.GetZoneAlerts("flz072")
+	if err != nil {
+		t.Fatalf("GetZoneAlerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Event != "Rip Current Statement" {
+		t.Errorf("alerts = %+v", alerts)
+	}
+	reqs := srv.Requests()
+	if q := reqs[len(reqs)-1].Query; q != "zone=FLZ072" {
+		t.Errorf("alerts query = %q, want zone parameter", q)
+	}
+
+	if _, err := svc.GetZoneAlerts("Miami"); err == nil {
+		t.Error("GetZoneAlerts accepted a place name")
+	}
+}
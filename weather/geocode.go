@@ -1,11 +1,11 @@
 package weather
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"math"
 	"net/url"
-	"time"
+	"strconv"
+	"strings"
 )
 
 // GeoLocation represents the latitude and longitude coordinates
@@ -14,43 +14,114 @@ type GeoLocation struct {
 	Lon string `json:"lon"`
 }
 
-// geocode converts a location string to coordinates using OpenStreetMap's Nominatim service
-func geocode(location string) (*GeoLocation, error) {
-	baseURL := "https://nominatim.openstreetmap.org/search"
-	params := url.Values{}
-	params.Add("q", location)
-	params.Add("format", "json")
-	params.Add("limit", "1")
+// NewGeoLocation formats coordinates as Nominatim returns them.
+func NewGeoLocation(lat, lon float64) GeoLocation {
+	return GeoLocation{
+		Lat: strconv.FormatFloat(lat, 'f', -1, 64),
+		Lon: strconv.FormatFloat(lon, 'f', -1, 64),
+	}
+}
 
-	requestURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+// Coordinates returns the latitude and longitude as numbers.
+func (g GeoLocation) Coordinates() (lat, lon float64) {
+	lat, _ = strconv.ParseFloat(g.Lat, 64)
+	lon, _ = strconv.ParseFloat(g.Lon, 64)
+	return lat, lon
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("GET", requestURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating geocode request: %w", err)
+// nearCandidates is how many matches are compared when a reference point
+// is set.
+const nearCandidates = 5
+
+// WithPlaces gives coordinates for location names, such as places saved
+// in a user profile, so looking them up skips geocoding. Names match
+// ignoring case and spacing.
+func WithPlaces(places map[string]GeoLocation) Option {
+	return func(s *NWSService) {
+		s.places = make(map[string]GeoLocation, len(places))
+		for name, geo := range places {
+			s.places[locationKey(name)] = geo
+		}
 	}
+}
 
-	// Required by Nominatim's usage policy
-	req.Header.Set("User-Agent", "WeatherApp/1.0")
+// WithNear makes geocoding prefer, among places with the same name, the
+// one closest to lat, lon: "Springfield" means Springfield, IL to someone
+// living in Chicago.
+func WithNear(lat, lon float64) Option {
+	return func(s *NWSService) {
+		near := NewGeoLocation(lat, lon)
+		s.near = &near
+	}
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("geocoding request failed: %w", err)
+// geocode converts a location string to coordinates using OpenStreetMap's Nominatim service
+func (s *NWSService) geocode(location string) (*GeoLocation, error) {
+	if geo, ok := s.places[locationKey(location)]; ok {
+		return &geo, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("geocoding service returned status: %d", resp.StatusCode)
+	limit := 1
+	if s.near != nil {
+		limit = nearCandidates
 	}
+	params := url.Values{}
+	params.Add("q", location)
+	params.Add("format", "json")
+	params.Add("limit", strconv.Itoa(limit))
+
+	requestURL := fmt.Sprintf("%s?%s", s.geocodeURL, params.Encode())
 
+	// getJSON sets the User-Agent required by Nominatim's usage policy
 	var results []GeoLocation
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return nil, fmt.Errorf("decoding geocode response: %w", err)
+	if err := s.getJSON(s.geocoder, requestURL, "", &results); err != nil {
+		return nil, fmt.Errorf("geocoding request failed: %w", err)
 	}
 
 	if len(results) == 0 {
-		return nil, fmt.Errorf("location not found: %s", location)
+		return nil, fmt.Errorf("%w: %s", ErrLocationNotFound, location)
 	}
 
+	if s.near != nil {
+		return s.nearest(results), nil
+	}
 	return &results[0], nil
 }
+
+// nearest picks the result closest to s.near, preferring places NWS
+// covers. Results are otherwise in Nominatim's order of importance.
+func (s *NWSService) nearest(results []GeoLocation) *GeoLocation {
+	nearLat, nearLon := s.near.Coordinates()
+	best, bestDist := 0, math.Inf(1)
+	for i, r := range results {
+		lat, lon := r.Coordinates()
+		if !isUSLocation(lat, lon) {
+			continue
+		}
+		if d := distanceKm(nearLat, nearLon, lat, lon); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return &results[best]
+}
+
+// distanceKm is the great-circle distance between two points.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// Locate geocodes location and checks that NWS covers it, for saving a
+// place to look up later without geocoding.
+func (s *NWSService) Locate(location string) (GeoLocation, error) {
+	geo, err := s.validateLocation(strings.TrimSpace(location))
+	if err != nil {
+		return GeoLocation{}, err
+	}
+	return *geo, nil
+}
@@ -1,33 +1,134 @@
 package weather
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
+
+	"learn-go/breaker"
+	"learn-go/internal/flight"
+	"learn-go/retry"
 )
 
 type Service interface {
 	GetWeather(location string) (*WeatherData, error)
+	GetForecast(location string, hourly bool) (*Forecast, error)
+	GetAlerts(location string) ([]Alert, error)
 }
 
 type NWSService struct {
-	client    *http.Client
-	userAgent string
+	client     *http.Client
+	userAgent  string
+	baseURL    string
+	geocodeURL string
+	retry      retry.Policy
+	breakers   breaker.Settings
+	nws        *breaker.Breaker
+	geocoder   *breaker.Breaker
+	// places are looked up without geocoding; near, when set, breaks ties
+	// between places of the same name.
+	places map[string]GeoLocation
+	near   *GeoLocation
+
+	// Concurrent lookups for the same place share one set of upstream
+	// calls.
+	current   flight.Group[*WeatherData]
+	forecasts flight.Group[*Forecast]
+	alerts    flight.Group[[]Alert]
+}
+
+// Option configures an NWSService.
+type Option func(*NWSService)
+
+// WithBaseURL points the service at an alternative api.weather.gov host.
+func WithBaseURL(baseURL string) Option {
+	return func(s *NWSService) {
+		s.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithGeocodeURL overrides the Nominatim search endpoint.
+func WithGeocodeURL(geocodeURL string) Option {
+	return func(s *NWSService) {
+		s.geocodeURL = geocodeURL
+	}
+}
+
+// WithHTTPClient replaces the client used for both NWS and geocoding requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *NWSService) {
+		s.client = client
+	}
+}
+
+// WithTransport keeps the default timeout but routes requests through rt,
+// e.g. a cassette.Recorder.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(s *NWSService) {
+		client := *s.client
+		client.Transport = rt
+		s.client = &client
+	}
+}
+
+// WithRetryPolicy replaces the policy used to retry transient failures of
+// NWS and geocoding requests.
+func WithRetryPolicy(p retry.Policy) Option {
+	return func(s *NWSService) {
+		s.retry = p
+	}
+}
+
+// WithBreakerSettings configures the circuit breakers guarding the NWS and
+// geocoding services. Only errors worth retrying count as failures.
+func WithBreakerSettings(settings breaker.Settings) Option {
+	return func(s *NWSService) {
+		s.breakers = settings
+	}
 }
 
-func NewNWSService() *NWSService {
-	return &NWSService{
+func NewNWSService(opts ...Option) *NWSService {
+	s := &NWSService{
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		userAgent: userAgent,
+		userAgent:  userAgent,
+		baseURL:    nwsBaseURL,
+		geocodeURL: nominatimURL,
+		retry:      retry.Default,
+		breakers:   breaker.DefaultSettings,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	settings := s.breakers
+	if settings.IsFailure == nil {
+		settings.IsFailure = retry.IsRetryable
 	}
+	s.nws = breaker.New("nws", settings)
+	s.geocoder = breaker.New("nominatim", settings)
+	return s
 }
 
+// Breakers returns the circuit breakers guarding the NWS and geocoding
+// services.
+func (s *NWSService) Breakers() []*breaker.Breaker {
+	return []*breaker.Breaker{s.nws, s.geocoder}
+}
+
+// GetWeather returns the latest observation nearest to location.
 func (s *NWSService) GetWeather(location string) (*WeatherData, error) {
+	data, err, _ := s.current.Do(locationKey(location), func() (*WeatherData, error) {
+		return s.getWeather(location)
+	})
+	return data, err
+}
+
+func (s *NWSService) getWeather(location string) (*WeatherData, error) {
 	coords, err := s.validateLocation(location)
 	if err != nil {
 		return nil, err
@@ -46,15 +147,19 @@ func (s *NWSService) GetWeather(location string) (*WeatherData, error) {
 	return s.getWeatherData(station)
 }
 
+// locationKey normalises a location for coalescing, so "Miami, FL" and
+// " miami,  fl" share a lookup.
+func locationKey(location string) string {
+	return strings.ToLower(strings.Join(strings.Fields(location), " "))
+}
+
 func (s *NWSService) validateLocation(location string) (*GeoLocation, error) {
-	geo, err := geocode(location)
+	geo, err := s.geocode(location)
 	if err != nil {
 		return nil, fmt.Errorf("geocoding location: %w", err)
 	}
 
-	lat, _ := strconv.ParseFloat(geo.Lat, 64)
-	lon, _ := strconv.ParseFloat(geo.Lon, 64)
-
+	lat, lon := geo.Coordinates()
 	if !isUSLocation(lat, lon) {
 		return nil, newLocationError(location)
 	}
@@ -63,7 +168,7 @@ func (s *NWSService) validateLocation(location string) (*GeoLocation, error) {
 }
 
 func (s *NWSService) getPoints(geo *GeoLocation) (*PointsResponse, error) {
-	url := fmt.Sprintf("%s/points/%s,%s", nwsBaseURL, geo.Lat, geo.Lon)
+	url := fmt.Sprintf("%s/points/%s,%s", s.baseURL, geo.Lat, geo.Lon)
 	points := &PointsResponse{}
 
 	if err := s.makeRequest(url, points); err != nil {
@@ -75,7 +180,7 @@ func (s *NWSService) getPoints(geo *GeoLocation) (*PointsResponse, error) {
 
 func (s *NWSService) findNearestStation(points *PointsResponse) (string, error) {
 	url := fmt.Sprintf("%s/gridpoints/%s/%d,%d/stations",
-		nwsBaseURL,
+		s.baseURL,
 		points.Properties.GridID,
 		points.Properties.GridX,
 		points.Properties.GridY)
@@ -93,7 +198,7 @@ func (s *NWSService) findNearestStation(points *PointsResponse) (string, error)
 }
 
 func (s *NWSService) getWeatherData(stationID string) (*WeatherData, error) {
-	url := fmt.Sprintf("%s/stations/%s/observations/latest", nwsBaseURL, stationID)
+	url := fmt.Sprintf("%s/stations/%s/observations/latest", s.baseURL, stationID)
 
 	var nwsResp NWSResponse
 	if err := s.makeRequest(url, &nwsResp); err != nil {
@@ -104,27 +209,52 @@ func (s *NWSService) getWeatherData(stationID string) (*WeatherData, error) {
 }
 
 func convertResponse(resp *NWSResponse) *WeatherData {
-	tempF := (resp.Properties.Temperature.Value * 9 / 5) + 32
+	// A null temperature means the reading failed quality control; zero
+	// would convert to a plausible 32°F.
+	var tempF float64
+	temp := resp.Properties.Temperature.Value
+	if temp != nil {
+		tempF = (*temp * 9 / 5) + 32
+	}
 
 	return &WeatherData{
-		Temperature:    tempF,
-		Conditions:     resp.Properties.TextDescription,
-		Humidity:       int(resp.Properties.RelativeHumidity.Value),
-		WindSpeed:      resp.Properties.WindSpeed.Value,
-		WindSpeedUnit:  resp.Properties.WindSpeed.UnitCode,
-		QualityControl: resp.Properties.Temperature.QualityControl,
-		Timestamp:      resp.Properties.Timestamp,
+		Temperature:        tempF,
+		TemperatureMissing: temp == nil,
+		Conditions:         resp.Properties.TextDescription,
+		Humidity:           int(resp.Properties.RelativeHumidity.Value),
+		WindSpeed:          resp.Properties.WindSpeed.Value,
+		WindSpeedUnit:      resp.Properties.WindSpeed.UnitCode,
+		QualityControl:     resp.Properties.Temperature.QualityControl,
+		Timestamp:          resp.Properties.Timestamp,
 	}
 }
 
 func (s *NWSService) makeRequest(url string, result interface{}) error {
-	req, err := http.NewRequest("GET", url, nil)
+	return s.getJSON(s.nws, url, "application/geo+json", result)
+}
+
+// getJSON fetches url through b and decodes the JSON body into result,
+// retrying network errors, rate limiting and server errors according to
+// s.retry. An empty accept leaves the Accept header unset.
+func (s *NWSService) getJSON(b *breaker.Breaker, url, accept string, result interface{}) error {
+	return s.retry.Do(context.Background(), func(ctx context.Context, attempt int) error {
+		return b.Do(func() error {
+			return s.fetchJSON(ctx, url, accept, result)
+		})
+	})
+}
+
+// fetchJSON makes a single GET request.
+func (s *NWSService) fetchJSON(ctx context.Context, url, accept string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Accept", "application/geo+json")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -133,9 +263,11 @@ func (s *NWSService) makeRequest(url string, result interface{}) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("unexpected status: %d, body: %s", resp.StatusCode, string(body))
+		return retry.FromResponse(resp)
 	}
 
-	return json.NewDecoder(resp.Body).Decode(result)
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
 }
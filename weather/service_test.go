@@ -0,0 +1,313 @@
+package weather_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"learn-go/breaker"
+	"learn-go/weather"
+	"learn-go/weather/weathertest"
+)
+
+func TestGetWeather(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+
+	got, err := srv.NewService().GetWeather("Miami, FL")
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+
+	if got.Temperature != 77 {
+		t.Errorf("Temperature = %v, want 77", got.Temperature)
+	}
+	if got.Humidity != 70 {
+		t.Errorf("Humidity = %d, want 70", got.Humidity)
+	}
+	if got.Conditions != "Partly Cloudy" {
+		t.Errorf("Conditions = %q, want %q", got.Conditions, "Partly Cloudy")
+	}
+	if got.WindSpeed != 14.8 || got.WindSpeedUnit != "wmoUnit:km_h-1" {
+		t.Errorf("Wind = %v %s, want 14.8 wmoUnit:km_h-1", got.WindSpeed, got.WindSpeedUnit)
+	}
+
+	for _, path := range []string{
+		weathertest.GeocodePath,
+		weathertest.PointsPath,
+		weathertest.StationsPath,
+		weathertest.ObservationPath,
+	} {
+		if n := srv.Hits(path); n != 1 {
+			t.Errorf("%s hit %d times, want 1", path, n)
+		}
+	}
+}
+
+func TestGetWeatherSendsHeaders(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+
+	if _, err := srv.NewService().GetWeather("Miami, FL"); err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+
+	for _, r := range srv.Requests() {
+		if r.Header.Get("User-Agent") == "" {
+			t.Errorf("%s sent without User-Agent", r.Path)
+		}
+		if r.Path == weathertest.GeocodePath {
+			if !strings.Contains(r.Query, "q=Miami%2C+FL") {
+				t.Errorf("geocode query = %q, want location parameter", r.Query)
+			}
+			continue
+		}
+		if got := r.Header.Get("Accept"); got != "application/geo+json" {
+			t.Errorf("%s Accept = %q, want application/geo+json", r.Path, got)
+		}
+	}
+}
+
+func TestGetWeatherNullObservation(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+	srv.Handle(weathertest.ObservationPath, weathertest.Body(weathertest.NullObservationJSON))
+
+	got, err := srv.NewService().GetWeather("Miami, FL")
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Humidity != 0 || got.WindSpeed != 0 {
+		t.Errorf("null values decoded as humidity=%d wind=%v, want zero", got.Humidity, got.WindSpeed)
+	}
+	if !got.TemperatureMissing || got.Temperature != 0 {
+		t.Errorf("null temperature decoded as %v°F (missing=%v), want it marked missing", got.Temperature, got.TemperatureMissing)
+	}
+	if desc := weather.Describe("Miami, FL", got); strings.Contains(desc, "°F") {
+		t.Errorf("Describe = %q, want no temperature", desc)
+	}
+}
+
+func TestGetWeatherErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		resp    weathertest.Response
+		wantErr string
+	}{
+		{"geocode no results", weathertest.GeocodePath, weathertest.Body(`[]`), "location not found"},
+		{"geocode outside US", weathertest.GeocodePath, weathertest.Body(`[{"lat":"51.5072","lon":"-0.1276"}]`), "outside US coverage"},
+		{"geocode 500", weathertest.GeocodePath, weathertest.Status(http.StatusInternalServerError), "geocoding request failed: unexpected status: 500"},
+		{"points 404", weathertest.PointsPath, weathertest.Status(http.StatusNotFound), "getting points data"},
+		{"no stations", weathertest.StationsPath, weathertest.Body(`{"features":[]}`), "no weather stations found"},
+		{"observation 500", weathertest.ObservationPath, weathertest.Status(http.StatusInternalServerError), "unexpected status: 500"},
+		{"observation malformed", weathertest.ObservationPath, weathertest.Body(weathertest.MalformedJSON), "getting observations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := weathertest.NewServer()
+			defer srv.Close()
+			srv.Handle(tt.path, tt.resp)
+
+			_, err := srv.NewService().GetWeather("Miami, FL")
+			if err == nil {
+				t.Fatal("GetWeather succeeded, want error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetWeatherLocationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want error
+	}{
+		{"not found", `[]`, weather.ErrLocationNotFound},
+		{"outside US", `[{"lat":"51.5072","lon":"-0.1276"}]`, weather.ErrOutsideCoverage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := weathertest.NewServer()
+			defer srv.Close()
+			srv.Handle(weathertest.GeocodePath, weathertest.Body(tt.body))
+
+			_, err := srv.NewService().GetWeather("London")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetWeatherSlowUpstream(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+	srv.Handle(weathertest.ObservationPath, weathertest.Response{
+		Body:  weathertest.ObservationJSON,
+		Delay: time.Second,
+	})
+
+	svc := srv.NewService(weather.WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}))
+
+	start := time.Now()
+	if _, err := svc.GetWeather("Miami, FL"); err == nil {
+		t.Fatal("GetWeather succeeded, want timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("GetWeather took %v, want client timeout to cut it short", elapsed)
+	}
+}
+
+func TestGetWeatherRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    []weathertest.Response
+		wantErr   bool
+		wantCalls int
+	}{
+		{"server error then ok", []weathertest.Response{weathertest.Status(http.StatusServiceUnavailable), weathertest.Body(weathertest.ObservationJSON)}, false, 2},
+		{"rate limited then ok", []weathertest.Response{weathertest.Status(http.StatusTooManyRequests), weathertest.Body(weathertest.ObservationJSON)}, false, 2},
+		{"server error every time", []weathertest.Response{weathertest.Status(http.StatusBadGateway)}, true, 3},
+		{"not found is not retried", []weathertest.Response{weathertest.Status(http.StatusNotFound)}, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := weathertest.NewServer()
+			defer srv.Close()
+			srv.Handle(weathertest.ObservationPath, tt.script...)
+
+			_, err := srv.NewService().GetWeather("Miami, FL")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetWeather error = %v, want error %v", err, tt.wantErr)
+			}
+			if n := srv.Hits(weathertest.ObservationPath); n != tt.wantCalls {
+				t.Errorf("observation fetched %d times, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetWeatherBreakerOpens(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+	srv.Handle(weathertest.PointsPath, weathertest.Status(http.StatusServiceUnavailable))
+
+	svc := srv.NewService(weather.WithBreakerSettings(breaker.Settings{Failures: 3, Cooldown: time.Hour}))
+	if _, err := svc.GetWeather("Miami, FL"); err == nil {
+		t.Fatal("GetWeather succeeded, want error")
+	}
+
+	_, err := svc.GetWeather("Miami, FL")
+	if !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("error = %v, want ErrOpen", err)
+	}
+	if n := srv.Hits(weathertest.PointsPath); n != 3 {
+		t.Errorf("points fetched %d times, want no calls once the breaker opened", n)
+	}
+
+	states := map[string]breaker.State{}
+	for _, b := range svc.Breakers() {
+		states[b.Name()] = b.State()
+	}
+	if states["nws"] != breaker.Open || states["nominatim"] != breaker.Closed {
+		t.Errorf("breaker states = %v, want nws open and nominatim closed", states)
+	}
+}
+
+func TestGetWeatherCoalescesConcurrentLookups(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+	srv.Handle(weathertest.ObservationPath, weathertest.Response{
+		Body:  weathertest.ObservationJSON,
+		Delay: 100 * time.Millisecond,
+	})
+	svc := srv.NewService()
+
+	locations := []string{"Miami, FL", "miami, fl", "  Miami,   FL "}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(locations))
+	for _, loc := range locations {
+		wg.Add(1)
+		go func(loc string) {
+			defer wg.Done()
+			if _, err := svc.GetWeather(loc); err != nil {
+				errs <- err
+			}
+		}(loc)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("GetWeather: %v", err)
+	}
+
+	for _, path := range []string{weathertest.GeocodePath, weathertest.ObservationPath} {
+		if n := srv.Hits(path); n != 1 {
+			t.Errorf("%s fetched %d times, want 1 shared lookup", path, n)
+		}
+	}
+
+	// Once the lookup has finished, the next one goes upstream again.
+	if _, err := svc.GetWeather("Miami, FL"); err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if n := srv.Hits(weathertest.ObservationPath); n != 2 {
+		t.Errorf("observation fetched %d times, want 2", n)
+	}
+}
+
+func TestGetWeatherSavedPlace(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+	svc := srv.NewService(weather.WithPlaces(map[string]weather.GeoLocation{
+		"Miami, FL": weather.NewGeoLocation(25.7741728, -80.19362),
+	}))
+
+	got, err := svc.GetWeather(" miami,  FL")
+	if err != nil {
+		t.Fatalf("GetWeather: %v", err)
+	}
+	if got.Temperature != 77 {
+		t.Errorf("Temperature = %v, want 77", got.Temperature)
+	}
+	if n := srv.Hits(weathertest.GeocodePath); n != 0 {
+		t.Errorf("geocoded %d times, want saved coordinates used", n)
+	}
+}
+
+func TestGeocodePrefersNearby(t *testing.T) {
+	srv := weathertest.NewServer()
+	defer srv.Close()
+	// Nominatim ranks Miami, OK and a place abroad above Miami, FL.
+	srv.Handle(weathertest.GeocodePath, weathertest.Body(`[
+		{"lat":"36.8744","lon":"-94.8775"},
+		{"lat":"51.5072","lon":"-0.1276"},
+		{"lat":"25.7741728","lon":"-80.19362"}]`))
+
+	// From Fort Lauderdale, Miami means Miami, FL.
+	geo, err := srv.NewService(weather.WithNear(26.1224, -80.1373)).Locate("Miami")
+	if err != nil {
+		t.Fatalf("Locate: %v", err)
+	}
+	if geo.Lat != "25.7741728" {
+		t.Errorf("Locate picked %s,%s, want Miami, FL", geo.Lat, geo.Lon)
+	}
+	if reqs := srv.Requests(); len(reqs) != 1 || !strings.Contains(reqs[0].Query, "limit=5") {
+		t.Errorf("geocode requests = %+v, want one asking for several matches", reqs)
+	}
+
+	// Without a reference point the top match is used.
+	if _, err := srv.NewService().Locate("Miami"); err != nil {
+		t.Fatalf("Locate: %v", err)
+	}
+}
@@ -1,8 +1,13 @@
 package weather
 
+import "time"
+
 // WeatherData represents the processed weather information
 type WeatherData struct {
-	Temperature         float64 `json:"temperature"`
+	Temperature float64 `json:"temperature"`
+	// TemperatureMissing is set when the station reported no temperature;
+	// Temperature is then zero and must not be shown as 32°F.
+	TemperatureMissing  bool    `json:"temperature_missing,omitempty"`
 	FeelsLike           float64 `json:"feels_like"`
 	Conditions          string  `json:"conditions"`
 	Humidity            int     `json:"humidity"`
@@ -60,9 +65,9 @@ type StationsResponse struct {
 type NWSResponse struct {
 	Properties struct {
 		Temperature struct {
-			Value          float64 `json:"value"`
-			UnitCode       string  `json:"unitCode"`
-			QualityControl string  `json:"qualityControl"`
+			Value          *float64 `json:"value"`
+			UnitCode       string   `json:"unitCode"`
+			QualityControl string   `json:"qualityControl"`
 		} `json:"temperature"`
 		RelativeHumidity struct {
 			Value          float64 `json:"value"`
@@ -78,4 +83,47 @@ type NWSResponse struct {
 	} `json:"properties"`
 }
 
+type ForecastResponse struct {
+	Properties struct {
+		UpdateTime string `json:"updateTime"`
+		Periods    []struct {
+			Number                     int       `json:"number"`
+			Name                       string    `json:"name"`
+			StartTime                  time.Time `json:"startTime"`
+			EndTime                    time.Time `json:"endTime"`
+			IsDaytime                  bool      `json:"isDaytime"`
+			Temperature                float64   `json:"temperature"`
+			TemperatureUnit            string    `json:"temperatureUnit"`
+			ProbabilityOfPrecipitation struct {
+				Value float64 `json:"value"`
+			} `json:"probabilityOfPrecipitation"`
+			RelativeHumidity struct {
+				Value float64 `json:"value"`
+			} `json:"relativeHumidity"`
+			WindSpeed        string `json:"windSpeed"`
+			WindDirection    string `json:"windDirection"`
+			ShortForecast    string `json:"shortForecast"`
+			DetailedForecast string `json:"detailedForecast"`
+		} `json:"periods"`
+	} `json:"properties"`
+}
+
+type AlertsResponse struct {
+	Features []struct {
+		Properties struct {
+			ID          string `json:"id"`
+			AreaDesc    string `json:"areaDesc"`
+			Effective   string `json:"effective"`
+			Expires     string `json:"expires"`
+			Severity    string `json:"severity"`
+			Certainty   string `json:"certainty"`
+			Urgency     string `json:"urgency"`
+			Event       string `json:"event"`
+			Headline    string `json:"headline"`
+			Description string `json:"description"`
+			Instruction string `json:"instruction"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
 // ... rest of the API types ...
@@ -0,0 +1,34 @@
+package weather
+
+import "strings"
+
+// SpeedToMph converts a speed reported with an NWS/WMO unit code such as
+// "wmoUnit:km_h-1" to miles per hour. NWS observations default to km/h, so
+// an empty or unknown code is treated as km/h.
+func SpeedToMph(value float64, unitCode string) float64 {
+	switch strings.TrimPrefix(unitCode, "wmoUnit:") {
+	case "m_s-1":
+		return value * 2.236936
+	case "kt", "knot":
+		return value * 1.150779
+	case "mi_h-1", "mph":
+		return value
+	default:
+		return value * 0.621371
+	}
+}
+
+// FahrenheitToCelsius converts a temperature in °F to °C.
+func FahrenheitToCelsius(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
+
+// WindSpeedMph is the observed wind speed in miles per hour.
+func (d *WeatherData) WindSpeedMph() float64 {
+	return SpeedToMph(d.WindSpeed, d.WindSpeedUnit)
+}
+
+// WindGustMph is the observed gust speed in miles per hour.
+func (d *WeatherData) WindGustMph() float64 {
+	return SpeedToMph(d.WindGust, d.WindSpeedUnit)
+}
@@ -0,0 +1,159 @@
+// Package weathertest provides an in-process fake of the NWS API and the
+// Nominatim geocoder so the weather package can be exercised offline.
+//
+// A new Server answers the full Miami, FL lookup out of the box; tests
+// override individual routes with Handle to script nulls, error statuses,
+// slow replies or malformed bodies.
+package weathertest
+
+import (
+	"net/http"
+	"strconv"
+
+	"learn-go/internal/fakeserver"
+	"learn-go/retry"
+	"learn-go/weather"
+)
+
+// Response is a canned reply for a single request.
+type Response = fakeserver.Response
+
+// Routes answered by the default Miami fixtures.
+const (
+	GeocodePath     = "/search"
+	PointsPath      = "/points/25.7741728,-80.19362"
+	StationsPath    = "/gridpoints/MFL/110,50/stations"
+	ObservationPath = "/stations/KMIA/observations/latest"
+	ForecastPath    = "/gridpoints/MFL/110,50/forecast"
+	HourlyPath      = "/gridpoints/MFL/110,50/forecast/hourly"
+	AlertsPath      = "/alerts/active"
+)
+
+// Fixture bodies for the default routes.
+const (
+	GeocodeJSON = `[{"lat":"25.7741728","lon":"-80.19362","display_name":"Miami, Miami-Dade County, Florida, United States"}]`
+
+	PointsJSON = `{"properties":{"gridId":"MFL","gridX":110,"gridY":50,
+		"forecast":"/gridpoints/MFL/110,50/forecast",
+		"forecastHourly":"/gridpoints/MFL/110,50/forecast/hourly",
+		"observationStations":"/gridpoints/MFL/110,50/stations",
+		"relativeLocation":{"properties":{"city":"Miami","state":"FL","distance":{"value":1200,"unitCode":"wmoUnit:m"}}}}}`
+
+	StationsJSON = `{"features":[{"properties":{"stationIdentifier":"KMIA","name":"Miami International Airport","timeZone":"America/New_York"}}]}`
+
+	ObservationJSON = `{"properties":{
+		"timestamp":"2025-01-08T15:53:00+00:00",
+		"textDescription":"Partly Cloudy",
+		"temperature":{"value":25,"unitCode":"wmoUnit:degC","qualityControl":"V"},
+		"relativeHumidity":{"value":70.5,"unitCode":"wmoUnit:percent","qualityControl":"V"},
+		"windSpeed":{"value":14.8,"unitCode":"wmoUnit:km_h-1"}}}`
+
+	// NullObservationJSON is what NWS returns when a station reports but
+	// every sensor value failed quality control.
+	NullObservationJSON = `{"properties":{
+		"timestamp":"2025-01-08T15:53:00+00:00",
+		"textDescription":"",
+		"temperature":{"value":null,"unitCode":"wmoUnit:degC","qualityControl":"Z"},
+		"relativeHumidity":{"value":null,"unitCode":"wmoUnit:percent","qualityControl":"Z"},
+		"windSpeed":{"value":null,"unitCode":"wmoUnit:km_h-1"}}}`
+
+	// ForecastJSON covers Wednesday 8 January 2025 through Friday night.
+	ForecastJSON = `{"properties":{"updateTime":"2025-01-08T14:00:00+00:00","periods":[
+		{"number":1,"name":"This Afternoon","startTime":"2025-01-08T10:00:00-05:00","endTime":"2025-01-08T18:00:00-05:00","isDaytime":true,
+		 "temperature":79,"temperatureUnit":"F","probabilityOfPrecipitation":{"value":10},"windSpeed":"10 mph","windDirection":"E",
+		 "shortForecast":"Partly Sunny","detailedForecast":"Partly sunny, with a high near 79."},
+		{"number":2,"name":"Tonight","startTime":"2025-01-08T18:00:00-05:00","endTime":"2025-01-09T06:00:00-05:00","isDaytime":false,
+		 "temperature":68,"temperatureUnit":"F","probabilityOfPrecipitation":{"value":20},"windSpeed":"5 to 10 mph","windDirection":"E",
+		 "shortForecast":"Mostly Cloudy","detailedForecast":"Mostly cloudy, with a low around 68."},
+		{"number":3,"name":"Thursday","startTime":"2025-01-09T06:00:00-05:00","endTime":"2025-01-09T18:00:00-05:00","isDaytime":true,
+		 "temperature":81,"temperatureUnit":"F","probabilityOfPrecipitation":{"value":60},"windSpeed":"15 mph","windDirection":"SE",
+		 "shortForecast":"Showers Likely","detailedForecast":"Showers likely after 1pm. High near 81."},
+		{"number":4,"name":"Thursday Night","startTime":"2025-01-09T18:00:00-05:00","endTime":"2025-01-10T06:00:00-05:00","isDaytime":false,
+		 "temperature":70,"temperatureUnit":"F","probabilityOfPrecipitation":{"value":40},"windSpeed":"10 mph","windDirection":"S",
+		 "shortForecast":"Chance Showers","detailedForecast":"A chance of showers. Low around 70."}]}}`
+
+	// HourlyJSON covers the three hours from 10:00 local on 8 January 2025.
+	HourlyJSON = `{"properties":{"updateTime":"2025-01-08T14:00:00+00:00","periods":[
+		{"number":1,"startTime":"2025-01-08T10:00:00-05:00","endTime":"2025-01-08T11:00:00-05:00","isDaytime":true,
+		 "temperature":76,"temperatureUnit":"F","probabilityOfPrecipitation":{"value":5},"relativeHumidity":{"value":72},
+		 "windSpeed":"10 mph","windDirection":"E","shortForecast":"Partly Sunny"},
+		{"number":2,"startTime":"2025-01-08T11:00:00-05:00","endTime":"2025-01-08T12:00:00-05:00","isDaytime":true,
+		 "temperature":78,"temperatureUnit":"F","probabilityOfPrecipitation":{"value":10},"relativeHumidity":{"value":68},
+		 "windSpeed":"10 mph","windDirection":"E","shortForecast":"Partly Sunny"},
+		{"number":3,"startTime":"2025-01-08T12:00:00-05:00","endTime":"2025-01-08T13:00:00-05:00","isDaytime":true,
+		 "temperature":79,"temperatureUnit":"F","probabilityOfPrecipitation":{"value":15},"relativeHumidity":{"value":65},
+		 "windSpeed":"12 mph","windDirection":"E","shortForecast":"Mostly Cloudy"}]}}`
+
+	// NoAlertsJSON is the default: nothing active.
+	NoAlertsJSON = `{"features":[]}`
+
+	// AlertsJSON holds a single active advisory.
+	AlertsJSON = `{"features":[{"properties":{
+		"id":"urn:oid:2.49.0.1.840.0.abc123",
+		"areaDesc":"Miami-Dade County",
+		"effective":"2025-01-08T09:00:00-05:00",
+		"expires":"2025-01-08T21:00:00-05:00",
+		"severity":"Moderate","certainty":"Likely","urgency":"Expected",
+		"event":"Rip Current Statement",
+		"headline":"Rip Current Statement issued January 8 at 9:00AM EST until January 8 at 9:00PM EST",
+		"description":"Dangerous rip currents expected.",
+		"instruction":"Swim near a lifeguard."}}]}`
+
+	// MalformedJSON is a truncated body that fails to decode.
+	MalformedJSON = `{"properties": {"temperature": `
+)
+
+// Body returns a 200 response with the given body.
+func Body(body string) Response {
+	return Response{Body: body}
+}
+
+// Status returns an NWS-style problem response with the given status code.
+func Status(code int) Response {
+	return Response{
+		Status: code,
+		Body:   `{"title":"` + http.StatusText(code) + `","status":` + strconv.Itoa(code) + `}`,
+		Header: http.Header{"Content-Type": {"application/problem+json"}},
+	}
+}
+
+// Server is a fake NWS and Nominatim server.
+type Server struct {
+	*fakeserver.Server
+}
+
+// NewServer starts a fake server preloaded with the Miami fixtures. Callers
+// must Close it.
+func NewServer() *Server {
+	s := &Server{fakeserver.New()}
+	s.Handle(GeocodePath, Body(GeocodeJSON))
+	s.Handle(PointsPath, Body(PointsJSON))
+	s.Handle(StationsPath, Body(StationsJSON))
+	s.Handle(ObservationPath, Body(ObservationJSON))
+	s.Handle(ForecastPath, Body(ForecastJSON))
+	s.Handle(HourlyPath, Body(HourlyJSON))
+	s.Handle(AlertsPath, Body(NoAlertsJSON))
+	return s
+}
+
+// GeocodeURL is the Nominatim search endpoint served by s.
+func (s *Server) GeocodeURL() string {
+	return s.URL + GeocodePath
+}
+
+// Options returns the weather.NWSService options that route every request
+// to s. Retries keep the default attempt count but don't wait between
+// attempts.
+func (s *Server) Options() []weather.Option {
+	return []weather.Option{
+		weather.WithBaseURL(s.URL),
+		weather.WithGeocodeURL(s.GeocodeURL()),
+		weather.WithRetryPolicy(retry.Policy{MaxAttempts: retry.Default.MaxAttempts}),
+	}
+}
+
+// NewService returns an NWSService wired to s, with any extra options applied
+// afterwards.
+func (s *Server) NewService(opts ...weather.Option) *weather.NWSService {
+	return weather.NewNWSService(append(s.Options(), opts...)...)
+}